@@ -25,6 +25,7 @@ import (
 	"github.com/loggie-io/loggie/pkg/core/reloader"
 	"github.com/loggie-io/loggie/pkg/core/signals"
 	"github.com/loggie-io/loggie/pkg/core/sysconfig"
+	"github.com/loggie-io/loggie/pkg/discovery/docker"
 	"github.com/loggie-io/loggie/pkg/discovery/kubernetes"
 	"github.com/loggie-io/loggie/pkg/eventbus"
 	_ "github.com/loggie-io/loggie/pkg/include"
@@ -97,6 +98,12 @@ func main() {
 	}
 
 	controller := control.NewController()
+	if syscfg.Loggie.Api.Enabled {
+		syscfg.Loggie.Api.ConfigDir = filepath.Dir(pipelineConfigPath)
+		if err := controller.EnableApi(&syscfg.Loggie.Api); err != nil {
+			log.Fatal("enable pipeline api error: %v", err)
+		}
+	}
 	controller.Start(pipecfgs)
 
 	if syscfg.Loggie.Reload.Enabled {
@@ -110,10 +117,25 @@ func main() {
 		k8scfg.NodeName = nodeName
 		k8scfg.ConfigFilePath = filepath.Dir(pipelineConfigPath)
 		k8sDiscovery := kubernetes.NewDiscovery(&k8scfg)
+		// push the pipelines to controller directly, the config files written are snapshots
+		k8sDiscovery.SetConfigHandler(controller)
 
 		go k8sDiscovery.Start(stopCh)
 	}
 
+	if syscfg.Loggie.Discovery.Webhook.Enabled {
+		go kubernetes.StartWebhook(&syscfg.Loggie.Discovery.Kubernetes, &syscfg.Loggie.Discovery.Webhook, stopCh)
+	}
+
+	if syscfg.Loggie.Discovery.Docker.Enabled {
+		dockercfg := syscfg.Loggie.Discovery.Docker
+		dockercfg.ConfigFilePath = filepath.Dir(pipelineConfigPath)
+		dockerDiscovery := docker.NewDiscovery(&dockercfg)
+		dockerDiscovery.SetConfigHandler(controller)
+
+		go dockerDiscovery.Start(stopCh)
+	}
+
 	if syscfg.Loggie.Http.Enabled {
 		go func() {
 			if err = http.ListenAndServe(fmt.Sprintf("%s:%d", syscfg.Loggie.Http.Host, syscfg.Loggie.Http.Port), nil); err != nil {
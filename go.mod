@@ -10,12 +10,14 @@ require (
 	github.com/google/go-cmp v0.5.6
 	github.com/hpcloud/tail v1.0.0
 	github.com/json-iterator/go v1.1.11
+	github.com/klauspost/compress v1.9.8
 	github.com/mattn/go-sqlite3 v1.14.6
 	github.com/mattn/go-zglob v0.0.3
 	github.com/mmaxiaolei/backoff v0.0.0-20210104115436-e015e09efaba
 	github.com/olivere/elastic/v7 v7.0.28
 	github.com/pkg/errors v0.9.1
 	github.com/prometheus/client_golang v1.11.0
+	github.com/prometheus/client_model v0.2.0
 	github.com/prometheus/common v0.26.0
 	github.com/prometheus/prom2json v1.3.0
 	github.com/rs/zerolog v1.20.0
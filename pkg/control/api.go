@@ -0,0 +1,399 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/eventbus"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/util"
+)
+
+const (
+	// handlePipeline serves PUT, DELETE of /{name}, and POST of /{name}/pause, /{name}/resume
+	handlePipeline = handleCurrentPipelines + "/"
+
+	actionPause  = "pause"
+	actionResume = "resume"
+
+	ApiPipelinesFileName = "api-pipelines.yml"
+
+	maxRequestBodySize = 1 << 20
+)
+
+type ApiConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Token authenticates the requests with header `Authorization: Bearer <token>`
+	Token string `yaml:"token,omitempty"`
+	// Persist writes the pipelines accepted to ApiPipelinesFileName in the directory of config.pipeline, they are
+	// started after restarting, and replace the pipelines of the same name in config files
+	Persist   bool   `yaml:"persist,omitempty"`
+	ConfigDir string `yaml:"-"`
+}
+
+func (c *ApiConfig) Validate() error {
+	if c.Enabled && c.Token == "" {
+		return errors.New("token is required when pipeline api enabled")
+	}
+	return nil
+}
+
+// pipelineApi maintains the pipelines created or updated by the api
+type pipelineApi struct {
+	config *ApiConfig
+	// pipelines are the raw configs accepted, they are persisted without defaults
+	pipelines map[string]pipeline.ConfigRaw
+	// persisted are the pipelines loaded from ApiPipelinesFileName, they are started by Controller.Start
+	persisted []pipeline.Config
+}
+
+type ApiResponse struct {
+	Message   string       `json:"message,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	Pipelines []string     `json:"pipelines,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+// EnableApi enables the pipeline management endpoints, it should be called before Start
+func (c *Controller) EnableApi(config *ApiConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	a := &pipelineApi{
+		config:    config,
+		pipelines: make(map[string]pipeline.ConfigRaw),
+	}
+	if config.Persist {
+		// the persisted pipelines are kept, or they would be lost when persisting next time
+		path := filepath.Join(config.ConfigDir, ApiPipelinesFileName)
+		content, err := ioutil.ReadFile(path)
+		if err == nil {
+			raw := &PipelineRawConfig{}
+			if err := cfg.UnpackRaw(content, raw); err != nil {
+				return errors.WithMessagef(err, "unpack %s failed", path)
+			}
+			for _, p := range raw.Pipelines {
+				a.pipelines[p.Name] = p
+			}
+
+			configs, err := defaultsAndValidate(content)
+			if err != nil {
+				return errors.WithMessagef(err, "invalid pipelines in %s", path)
+			}
+			a.persisted = configs.Pipelines
+		}
+	}
+	c.api = a
+	return nil
+}
+
+// merge replaces the pipelines in config files with the persisted pipelines of the same name, so that a pipeline
+// updated by the api is started only once with the config accepted
+func (a *pipelineApi) merge(config *PipelineConfig) *PipelineConfig {
+	ret := &PipelineConfig{}
+	if config != nil {
+		for _, p := range config.Pipelines {
+			if _, ok := a.pipelines[p.Name]; ok {
+				log.Info("pipeline %s in config files is replaced by the one created by api", p.Name)
+				continue
+			}
+			ret.AddPipeline(p)
+		}
+	}
+	ret.AddPipelines(a.persisted)
+	return ret
+}
+
+// ApiManaged returns whether the pipeline is created or updated by the api, reloader should leave it alone
+func (c *Controller) ApiManaged(name string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.api == nil {
+		return false
+	}
+	_, ok := c.api.pipelines[name]
+	return ok
+}
+
+func (a *pipelineApi) auth(handler http.HandlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		authorization := request.Header.Get("Authorization")
+		token := strings.TrimPrefix(authorization, "Bearer ")
+		if token == authorization || subtle.ConstantTimeCompare([]byte(token), []byte(a.config.Token)) != 1 {
+			writeResponse(writer, http.StatusUnauthorized, &ApiResponse{Message: "unauthorized"})
+			return
+		}
+		handler(writer, request)
+	}
+}
+
+// persist writes the pipelines with the change applied, it is called before the change applied to running
+// pipelines, so a failed change is neither applied nor persisted.
+func (a *pipelineApi) persist(apply func(pipelines map[string]pipeline.ConfigRaw)) error {
+	pipelines := make(map[string]pipeline.ConfigRaw, len(a.pipelines))
+	for k, v := range a.pipelines {
+		pipelines[k] = v
+	}
+	apply(pipelines)
+
+	if a.config.Persist {
+		raw := &PipelineRawConfig{}
+		for _, p := range pipelines {
+			raw.Pipelines = append(raw.Pipelines, p)
+		}
+		sort.Slice(raw.Pipelines, func(i, j int) bool {
+			return raw.Pipelines[i].Name < raw.Pipelines[j].Name
+		})
+		content, err := yaml.Marshal(raw)
+		if err != nil {
+			return err
+		}
+		if err := util.WriteFileOrCreate(a.config.ConfigDir, ApiPipelinesFileName, content); err != nil {
+			return err
+		}
+	}
+	a.pipelines = pipelines
+	return nil
+}
+
+func (c *Controller) createPipelinesHandler(writer http.ResponseWriter, request *http.Request) {
+	configs, raws, ok := readPipelines(writer, request)
+	if !ok {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	var names []string
+	for _, p := range configs {
+		if _, ok := c.currentPipeline(p.Name); ok {
+			writeResponse(writer, http.StatusConflict, &ApiResponse{Message: fmt.Sprintf("pipeline %s already exists", p.Name)})
+			return
+		}
+		names = append(names, p.Name)
+	}
+
+	err := c.api.persist(func(pipelines map[string]pipeline.ConfigRaw) {
+		for _, raw := range raws {
+			pipelines[raw.Name] = raw
+		}
+	})
+	if err != nil {
+		writePersistError(writer, err)
+		return
+	}
+	c.startPipelines(configs)
+	writeResponse(writer, http.StatusCreated, &ApiResponse{Message: "created", Pipelines: names})
+}
+
+func (c *Controller) pipelineHandler(writer http.ResponseWriter, request *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(request.URL.Path, handlePipeline), "/")
+	name := parts[0]
+	if name == "" || len(parts) > 2 {
+		writeResponse(writer, http.StatusNotFound, &ApiResponse{Message: "pipeline name is required"})
+		return
+	}
+
+	switch {
+	case len(parts) == 1 && request.Method == http.MethodPut:
+		c.updatePipeline(writer, request, name)
+	case len(parts) == 1 && request.Method == http.MethodDelete:
+		c.deletePipeline(writer, name)
+	case len(parts) == 2 && request.Method == http.MethodPost && (parts[1] == actionPause || parts[1] == actionResume):
+		c.pausePipeline(writer, name, parts[1] == actionPause)
+	default:
+		writeResponse(writer, http.StatusMethodNotAllowed, &ApiResponse{Message: "method not allowed"})
+	}
+}
+
+func (c *Controller) updatePipeline(writer http.ResponseWriter, request *http.Request, name string) {
+	configs, raws, ok := readPipelines(writer, request)
+	if !ok {
+		return
+	}
+	if len(configs) != 1 || configs[0].Name != name {
+		writeResponse(writer, http.StatusBadRequest, &ApiResponse{
+			Errors: []FieldError{{Field: "pipelines", Message: fmt.Sprintf("only pipeline %s could be updated", name)}},
+		})
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	old, ok := c.currentPipeline(name)
+	if !ok {
+		writeResponse(writer, http.StatusNotFound, &ApiResponse{Message: fmt.Sprintf("pipeline %s not found", name)})
+		return
+	}
+
+	err := c.api.persist(func(pipelines map[string]pipeline.ConfigRaw) {
+		pipelines[name] = raws[0]
+	})
+	if err != nil {
+		writePersistError(writer, err)
+		return
+	}
+	// a paused pipeline is kept paused with the new config
+	c.stopPipelines([]pipeline.Config{old})
+	c.startPipelines(configs)
+	writeResponse(writer, http.StatusOK, &ApiResponse{Message: "updated", Pipelines: []string{name}})
+}
+
+func (c *Controller) deletePipeline(writer http.ResponseWriter, name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	old, ok := c.currentPipeline(name)
+	if !ok {
+		writeResponse(writer, http.StatusNotFound, &ApiResponse{Message: fmt.Sprintf("pipeline %s not found", name)})
+		return
+	}
+
+	err := c.api.persist(func(pipelines map[string]pipeline.ConfigRaw) {
+		delete(pipelines, name)
+	})
+	if err != nil {
+		writePersistError(writer, err)
+		return
+	}
+	c.stopPipelines([]pipeline.Config{old})
+	delete(c.paused, name)
+	writeResponse(writer, http.StatusOK, &ApiResponse{Message: "deleted", Pipelines: []string{name}})
+}
+
+func (c *Controller) pausePipeline(writer http.ResponseWriter, name string, pause bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	current, ok := c.currentPipeline(name)
+	if !ok {
+		writeResponse(writer, http.StatusNotFound, &ApiResponse{Message: fmt.Sprintf("pipeline %s not found", name)})
+		return
+	}
+
+	_, paused := c.paused[name]
+	switch {
+	case pause && !paused:
+		if p, ok := c.pipelineRunner[name]; ok {
+			log.Info("pausing pipeline: %s", name)
+			c.reportMetric(current, eventbus.ComponentStop)
+			p.Stop()
+			delete(c.pipelineRunner, name)
+		}
+		c.paused[name] = struct{}{}
+
+	case !pause && paused:
+		delete(c.paused, name)
+		c.runPipeline(current)
+	}
+
+	message := actionResume + "d"
+	if pause {
+		message = actionPause + "d"
+	}
+	writeResponse(writer, http.StatusOK, &ApiResponse{Message: message, Pipelines: []string{name}})
+}
+
+func (c *Controller) currentPipeline(name string) (pipeline.Config, bool) {
+	for _, p := range c.CurrentConfig.Pipelines {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return pipeline.Config{}, false
+}
+
+// readPipelines reads the pipelines in the same format of the pipeline config file, and validates them as the
+// config files
+func readPipelines(writer http.ResponseWriter, request *http.Request) ([]pipeline.Config, []pipeline.ConfigRaw, bool) {
+	content, err := ioutil.ReadAll(http.MaxBytesReader(writer, request.Body, maxRequestBodySize))
+	if err != nil {
+		writeResponse(writer, http.StatusBadRequest, &ApiResponse{Message: err.Error()})
+		return nil, nil, false
+	}
+
+	configs, err := defaultsAndValidate(content)
+	if err != nil {
+		writeResponse(writer, http.StatusBadRequest, &ApiResponse{Message: "invalid pipelines", Errors: fieldErrors(err)})
+		return nil, nil, false
+	}
+	if len(configs.Pipelines) == 0 {
+		writeResponse(writer, http.StatusBadRequest, &ApiResponse{
+			Message: "invalid pipelines",
+			Errors:  []FieldError{{Field: "pipelines", Rule: "required", Message: "no pipeline found"}},
+		})
+		return nil, nil, false
+	}
+
+	raw := &PipelineRawConfig{}
+	if err := cfg.UnpackRaw(content, raw); err != nil {
+		writeResponse(writer, http.StatusBadRequest, &ApiResponse{Message: err.Error()})
+		return nil, nil, false
+	}
+	return configs.Pipelines, raw.Pipelines, true
+}
+
+// fieldErrors converts the errors of validator to structured errors, other errors are returned as one message
+func fieldErrors(err error) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		ret := make([]FieldError, 0, len(validationErrors))
+		for _, e := range validationErrors {
+			ret = append(ret, FieldError{
+				Field:   e.Namespace(),
+				Rule:    e.Tag(),
+				Message: e.Error(),
+			})
+		}
+		return ret
+	}
+	return []FieldError{{Message: err.Error()}}
+}
+
+func writePersistError(writer http.ResponseWriter, err error) {
+	log.Warn("persist pipelines failed: %v", err)
+	writeResponse(writer, http.StatusInternalServerError, &ApiResponse{Message: fmt.Sprintf("persist pipelines failed: %v", err)})
+}
+
+func writeResponse(writer http.ResponseWriter, code int, resp *ApiResponse) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		writer.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(code)
+	writer.Write(data)
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/loggie-io/loggie/pkg/core/log"
+	_ "github.com/loggie-io/loggie/pkg/queue/channel"
+	_ "github.com/loggie-io/loggie/pkg/sink/codec/json"
+	_ "github.com/loggie-io/loggie/pkg/sink/dev"
+	_ "github.com/loggie-io/loggie/pkg/source/dev"
+)
+
+const (
+	testToken = "secret"
+
+	devPipeline = `
+pipelines:
+  - name: p1
+    sources:
+      - type: dev
+        name: s1
+    queue:
+      type: channel
+    sink:
+      type: dev
+      parallelism: %s
+`
+)
+
+func TestMain(m *testing.M) {
+	log.InitDefaultLogger()
+	os.Exit(m.Run())
+}
+
+func apiRequest(t *testing.T, c *Controller, method string, path string, body string, token string) (int, *ApiResponse) {
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	if strings.HasPrefix(path, handlePipeline) {
+		c.api.auth(c.pipelineHandler)(recorder, request)
+	} else {
+		c.pipelinesHandler(recorder, request)
+	}
+
+	resp := &ApiResponse{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), resp); err != nil {
+		t.Fatalf("unmarshal response %s failed: %v", recorder.Body.String(), err)
+	}
+	return recorder.Code, resp
+}
+
+func TestPipelineApi(t *testing.T) {
+	dir := t.TempDir()
+	c := NewController()
+	if err := c.EnableApi(&ApiConfig{Enabled: true, Token: testToken, Persist: true, ConfigDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		c.StopPipelines(c.CurrentPipelines().Pipelines)
+	}()
+
+	p1 := strings.Replace(devPipeline, "%s", "1", 1)
+	if code, _ := apiRequest(t, c, http.MethodPost, handleCurrentPipelines, p1, "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("expect unauthorized, got %d", code)
+	}
+	noPrefix := httptest.NewRequest(http.MethodPost, handleCurrentPipelines, strings.NewReader(p1))
+	noPrefix.Header.Set("Authorization", testToken)
+	recorder := httptest.NewRecorder()
+	c.pipelinesHandler(recorder, noPrefix)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("expect unauthorized without Bearer prefix, got %d", recorder.Code)
+	}
+
+	code, resp := apiRequest(t, c, http.MethodPost, handleCurrentPipelines, p1, testToken)
+	if code != http.StatusCreated || len(resp.Pipelines) != 1 || c.pipelineRunner["p1"] == nil || !c.ApiManaged("p1") {
+		t.Fatalf("create pipeline failed: %d %+v", code, resp)
+	}
+	if code, _ := apiRequest(t, c, http.MethodPost, handleCurrentPipelines, p1, testToken); code != http.StatusConflict {
+		t.Errorf("expect conflict, got %d", code)
+	}
+
+	// pause and update, the pipeline is kept paused
+	if code, _ := apiRequest(t, c, http.MethodPost, handlePipeline+"p1/pause", "", testToken); code != http.StatusOK || c.pipelineRunner["p1"] != nil {
+		t.Fatalf("pause pipeline failed: %d", code)
+	}
+	p1Updated := strings.Replace(devPipeline, "%s", "2", 1)
+	if code, resp := apiRequest(t, c, http.MethodPut, handlePipeline+"p1", p1Updated, testToken); code != http.StatusOK || c.pipelineRunner["p1"] != nil {
+		t.Fatalf("update paused pipeline failed: %d %+v", code, resp)
+	}
+	if code, _ := apiRequest(t, c, http.MethodPost, handlePipeline+"p1/resume", "", testToken); code != http.StatusOK || c.pipelineRunner["p1"] == nil {
+		t.Fatalf("resume pipeline failed: %d", code)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, ApiPipelinesFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "parallelism: 2") {
+		t.Errorf("updated pipeline is not persisted:\n%s", content)
+	}
+
+	// the persisted pipelines are started after restarting, replacing the ones of config files
+	restarted := NewController()
+	if err := restarted.EnableApi(&ApiConfig{Enabled: true, Token: testToken, Persist: true, ConfigDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	fileConfigs, err := defaultsAndValidate([]byte(`
+pipelines:
+  - name: p1
+    sources:
+      - type: dev
+        name: s1
+    queue:
+      type: channel
+    sink:
+      type: dev
+  - name: p2
+    sources:
+      - type: dev
+        name: s1
+    queue:
+      type: channel
+    sink:
+      type: dev
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := restarted.api.merge(fileConfigs)
+	if len(merged.Pipelines) != 2 {
+		t.Fatalf("expect pipelines p1 and p2 after merged, got %+v", merged.Pipelines)
+	}
+	for _, p := range merged.Pipelines {
+		if p.Name == "p1" && p.Sink.Parallelism != 2 {
+			t.Errorf("expect pipeline p1 replaced by the persisted one, got sink parallelism %d", p.Sink.Parallelism)
+		}
+	}
+
+	if code, _ := apiRequest(t, c, http.MethodDelete, handlePipeline+"p1", "", testToken); code != http.StatusOK || c.pipelineRunner["p1"] != nil {
+		t.Fatalf("delete pipeline failed: %d", code)
+	}
+	if code, _ := apiRequest(t, c, http.MethodDelete, handlePipeline+"p1", "", testToken); code != http.StatusNotFound {
+		t.Errorf("expect not found, got %d", code)
+	}
+}
+
+func TestPipelineApi_ValidationErrors(t *testing.T) {
+	c := NewController()
+	if err := c.EnableApi(&ApiConfig{Enabled: true, Token: testToken}); err != nil {
+		t.Fatal(err)
+	}
+
+	code, resp := apiRequest(t, c, http.MethodPost, handleCurrentPipelines, sourceNameRequired, testToken)
+	if code != http.StatusBadRequest || len(resp.Errors) == 0 {
+		t.Fatalf("expect validation errors, got %d %+v", code, resp)
+	}
+
+	invalid := `
+pipelines:
+  - sources:
+      - type: dev
+        name: s1
+    sink:
+      type: dev
+`
+	code, resp = apiRequest(t, c, http.MethodPost, handleCurrentPipelines, invalid, testToken)
+	if code != http.StatusBadRequest || len(resp.Errors) == 0 || resp.Errors[0].Rule != "required" {
+		t.Fatalf("expect structured validation errors, got %d %+v", code, resp)
+	}
+	if len(c.CurrentPipelines().Pipelines) != 0 {
+		t.Error("invalid pipelines should not be started")
+	}
+}
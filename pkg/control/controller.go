@@ -24,48 +24,145 @@ import (
 	"gopkg.in/yaml.v2"
 	"net/http"
 	_ "net/http/pprof"
+	"sync"
 	"time"
 )
 
 const handleCurrentPipelines = "/api/v1/controller/pipelines"
 
 func (c *Controller) initHttp() {
-	http.HandleFunc(handleCurrentPipelines, c.currentPipelinesHandler)
+	http.HandleFunc(handleCurrentPipelines, c.pipelinesHandler)
+	if c.api != nil {
+		http.HandleFunc(handlePipeline, c.api.auth(c.pipelineHandler))
+	}
+}
+
+func (c *Controller) pipelinesHandler(writer http.ResponseWriter, request *http.Request) {
+	if request.Method == http.MethodPost && c.api != nil {
+		c.api.auth(c.createPipelinesHandler)(writer, request)
+		return
+	}
+	c.currentPipelinesHandler(writer, request)
 }
 
 type Controller struct {
 	CurrentConfig  *PipelineConfig
 	pipelineRunner map[string]*pipeline.Pipeline
+
+	// lock serializes the changes from reloader and api
+	lock sync.Mutex
+	// paused pipelines are kept in CurrentConfig without running
+	paused map[string]struct{}
+	api    *pipelineApi
+	// provided are the pipeline names pushed by ConfigProvider, key: provider key
+	provided map[string]map[string]struct{}
 }
 
 func NewController() *Controller {
 	return &Controller{
 		CurrentConfig:  &PipelineConfig{},
 		pipelineRunner: make(map[string]*pipeline.Pipeline),
+		paused:         make(map[string]struct{}),
+		provided:       make(map[string]map[string]struct{}),
 	}
 }
 
 func (c *Controller) Start(config *PipelineConfig) {
 	c.initHttp()
+	if c.api != nil {
+		config = c.api.merge(config)
+	}
 	c.StartPipelines(config.Pipelines)
 }
 
 func (c *Controller) StartPipelines(configs []pipeline.Config) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.startPipelines(configs)
+}
+
+func (c *Controller) StopPipelines(configs []pipeline.Config) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.stopPipelines(configs)
+}
+
+// ReloadPipelines applies the changed pipeline configs to the running pipelines in place, the pipelines which could not
+// be reloaded in place are restarted. It returns the names of pipelines restarted and the components reloaded.
+func (c *Controller) ReloadPipelines(configs []pipeline.Config) ([]string, map[string][]eventbus.ComponentBaseConfig) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.reloadPipelines(configs)
+}
+
+func (c *Controller) reloadPipelines(configs []pipeline.Config) ([]string, map[string][]eventbus.ComponentBaseConfig) {
+	restarted := make([]string, 0)
+	reloaded := make(map[string][]eventbus.ComponentBaseConfig)
+	for _, pConfig := range configs {
+		c.CurrentConfig.RemovePipelines([]pipeline.Config{pConfig})
+		c.CurrentConfig.AddPipeline(pConfig)
+
+		if _, ok := c.paused[pConfig.Name]; ok {
+			continue
+		}
+		p, ok := c.pipelineRunner[pConfig.Name]
+		if !ok {
+			c.runPipeline(pConfig)
+			continue
+		}
+
+		log.Info("reloading pipeline: %s", pConfig.Name)
+		components, err := p.Reload(pConfig)
+		if len(components) > 0 {
+			reloaded[pConfig.Name] = components
+		}
+		if err == nil {
+			continue
+		}
+
+		log.Warn("reload pipeline %s in place failed: %v, restarting it", pConfig.Name, err)
+		c.reportMetric(pConfig, eventbus.ComponentStop)
+		p.Stop()
+		delete(c.pipelineRunner, pConfig.Name)
+		c.runPipeline(pConfig)
+		restarted = append(restarted, pConfig.Name)
+	}
+	return restarted, reloaded
+}
+
+// CurrentPipelines returns a copy of the current pipeline configs
+func (c *Controller) CurrentPipelines() *PipelineConfig {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	ret := &PipelineConfig{}
+	ret.AddPipelines(c.CurrentConfig.Pipelines)
+	return ret
+}
+
+func (c *Controller) startPipelines(configs []pipeline.Config) {
 	// add new pipeline configs to currentConfig
 	c.CurrentConfig.AddPipelines(configs)
 
 	// start new pipelines
 	for _, pConfig := range configs {
-		p := pipeline.NewPipeline()
-		log.Info("starting pipeline: %s", pConfig.Name)
-		c.reportMetric(pConfig, eventbus.ComponentStart)
-		p.Start(pConfig)
-
-		c.pipelineRunner[pConfig.Name] = p
+		if _, ok := c.paused[pConfig.Name]; ok {
+			log.Info("pipeline %s is paused, it would be started when resumed", pConfig.Name)
+			continue
+		}
+		c.runPipeline(pConfig)
 	}
 }
 
-func (c *Controller) StopPipelines(configs []pipeline.Config) {
+func (c *Controller) runPipeline(pConfig pipeline.Config) {
+	p := pipeline.NewPipeline()
+	log.Info("starting pipeline: %s", pConfig.Name)
+	c.reportMetric(pConfig, eventbus.ComponentStart)
+	p.Start(pConfig)
+
+	c.pipelineRunner[pConfig.Name] = p
+}
+
+func (c *Controller) stopPipelines(configs []pipeline.Config) {
 	// remove pipeline configs from currentConfig
 	c.CurrentConfig.RemovePipelines(configs)
 
@@ -79,6 +176,7 @@ func (c *Controller) StopPipelines(configs []pipeline.Config) {
 		log.Info("stopping pipeline: %s", pConfig.Name)
 		c.reportMetric(pConfig, eventbus.ComponentStop)
 		p.Stop()
+		delete(c.pipelineRunner, pConfig.Name)
 	}
 }
 
@@ -121,7 +219,7 @@ func (c *Controller) reportMetric(p pipeline.Config, eventType eventbus.Componen
 }
 
 func (c *Controller) currentPipelinesHandler(writer http.ResponseWriter, request *http.Request) {
-	data, err := yaml.Marshal(c.CurrentConfig)
+	data, err := yaml.Marshal(c.CurrentPipelines())
 	if err != nil {
 		log.Warn("marshal current pipeline config err: %v", err)
 		writer.WriteHeader(http.StatusInternalServerError)
@@ -0,0 +1,261 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/result"
+	"github.com/loggie-io/loggie/pkg/eventbus"
+	_ "github.com/loggie-io/loggie/pkg/interceptor/maxbytes"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+const seqType = "seq"
+
+func init() {
+	pipeline.Register(api.SOURCE, seqType, func(info pipeline.Info) api.Component {
+		return &seqSource{eventPool: info.EventPool, done: make(chan struct{})}
+	})
+	pipeline.Register(api.SINK, seqType, func(info pipeline.Info) api.Component {
+		return &seqSink{config: &seqSinkConfig{}}
+	})
+}
+
+// seqConsumed receives the sequences of the events consumed by seqSink
+var seqConsumed = make(chan int, seqTotal)
+
+const seqTotal = 30
+
+// seqSource produces seqTotal events with the sequence in header
+type seqSource struct {
+	eventPool *event.Pool
+	done      chan struct{}
+}
+
+func (s *seqSource) Config() interface{} {
+	return nil
+}
+
+func (s *seqSource) Category() api.Category {
+	return api.SOURCE
+}
+
+func (s *seqSource) Type() api.Type {
+	return seqType
+}
+
+func (s *seqSource) String() string {
+	return fmt.Sprintf("%s/%s", api.SOURCE, seqType)
+}
+
+func (s *seqSource) Init(context api.Context) {
+}
+
+func (s *seqSource) Start() {
+}
+
+func (s *seqSource) Stop() {
+	close(s.done)
+}
+
+func (s *seqSource) Commit(events []api.Event) {
+	s.eventPool.PutAll(events)
+}
+
+func (s *seqSource) ProductLoop(productFunc api.ProductFunc) {
+	for i := 0; i < seqTotal; i++ {
+		e := s.eventPool.Get()
+		e.Fill(e.Meta(), map[string]interface{}{"seq": i}, []byte("seq"))
+		productFunc(e)
+	}
+	<-s.done
+}
+
+type seqSinkConfig struct {
+	Round int `yaml:"round"`
+}
+
+// seqSink consumes the events slowly, so there are batches queued when reloading
+type seqSink struct {
+	config *seqSinkConfig
+}
+
+func (s *seqSink) Config() interface{} {
+	return s.config
+}
+
+func (s *seqSink) Category() api.Category {
+	return api.SINK
+}
+
+func (s *seqSink) Type() api.Type {
+	return seqType
+}
+
+func (s *seqSink) String() string {
+	return fmt.Sprintf("%s/%s", api.SINK, seqType)
+}
+
+func (s *seqSink) Init(context api.Context) {
+}
+
+func (s *seqSink) Start() {
+}
+
+func (s *seqSink) Stop() {
+}
+
+func (s *seqSink) Consume(batch api.Batch) api.Result {
+	time.Sleep(5 * time.Millisecond)
+	for _, e := range batch.Events() {
+		seqConsumed <- e.Header()["seq"].(int)
+	}
+	return result.NewResult(api.SUCCESS)
+}
+
+const reloadPipeline = `
+pipelines:
+  - name: p1
+    sources:
+      - type: dev
+        name: s1
+%s
+    queue:
+      type: channel
+      batchSize: %d
+    interceptors:
+      - type: maxbytes
+        maxBytes: %d
+    sink:
+      type: dev
+      parallelism: %d
+`
+
+const queuedPipeline = `
+pipelines:
+  - name: p2
+    sources:
+      - type: seq
+        name: s1
+    queue:
+      type: channel
+      batchSize: 1
+    interceptors:
+      - type: maxbytes
+        maxBytes: %d
+    sink:
+      type: seq
+      round: %d
+`
+
+const reloadSource = `
+      - type: dev
+        name: s2
+`
+
+func readReloadPipelines(t *testing.T, sources string, batchSize int, maxBytes int, parallelism int) *PipelineConfig {
+	content := fmt.Sprintf(reloadPipeline, sources, batchSize, maxBytes, parallelism)
+	config, err := defaultsAndValidate([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func componentNames(components []eventbus.ComponentBaseConfig) []string {
+	names := make([]string, 0, len(components))
+	for _, c := range components {
+		names = append(names, fmt.Sprintf("%s/%s/%s", c.Category, c.Type, c.Name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestController_ReloadPipelines(t *testing.T) {
+	c := NewController()
+	c.StartPipelines(readReloadPipelines(t, "", 100, 1024, 1).Pipelines)
+	defer func() {
+		c.StopPipelines(c.CurrentPipelines().Pipelines)
+	}()
+	running := c.pipelineRunner["p1"]
+
+	// sources, interceptors and sink changed, the pipeline is reloaded in place
+	restarted, reloaded := c.ReloadPipelines(readReloadPipelines(t, reloadSource, 100, 2048, 2).Pipelines)
+	if len(restarted) != 0 || c.pipelineRunner["p1"] != running {
+		t.Fatalf("pipeline should not be restarted: %v", restarted)
+	}
+	want := []string{"interceptor/maxbytes/", "sink/dev/", "source/dev/s2"}
+	if got := componentNames(reloaded["p1"]); !reflect.DeepEqual(got, want) {
+		t.Errorf("reloaded components: got %v, want %v", got, want)
+	}
+
+	// source removed
+	_, reloaded = c.ReloadPipelines(readReloadPipelines(t, "", 100, 2048, 2).Pipelines)
+	want = []string{"source/dev/s2"}
+	if got := componentNames(reloaded["p1"]); !reflect.DeepEqual(got, want) {
+		t.Errorf("reloaded components: got %v, want %v", got, want)
+	}
+
+	// queue changed, the pipeline is restarted
+	restarted, _ = c.ReloadPipelines(readReloadPipelines(t, "", 200, 2048, 2).Pipelines)
+	if !reflect.DeepEqual(restarted, []string{"p1"}) || c.pipelineRunner["p1"] == running {
+		t.Errorf("pipeline should be restarted: %v", restarted)
+	}
+	if len(c.CurrentPipelines().Pipelines) != 1 {
+		t.Errorf("expect 1 pipeline, got %d", len(c.CurrentPipelines().Pipelines))
+	}
+
+	// the batches queued survive the sink and interceptors reloaded in place
+	queued := NewController()
+	queued.StartPipelines(readQueuedPipelines(t, 1024, 1).Pipelines)
+	defer func() {
+		queued.StopPipelines(queued.CurrentPipelines().Pipelines)
+	}()
+	consumed := make(map[int]struct{})
+	receiveSeq := func() {
+		select {
+		case seq := <-seqConsumed:
+			consumed[seq] = struct{}{}
+		case <-time.After(time.Second):
+			t.Fatalf("events lost after reload, %d of %d consumed", len(consumed), seqTotal)
+		}
+	}
+	receiveSeq()
+	restarted, reloaded = queued.ReloadPipelines(readQueuedPipelines(t, 2048, 2).Pipelines)
+	want = []string{"interceptor/maxbytes/", "sink/seq/"}
+	if got := componentNames(reloaded["p2"]); len(restarted) != 0 || !reflect.DeepEqual(got, want) {
+		t.Fatalf("reloaded components: got %v, want %v, restarted: %v", got, want, restarted)
+	}
+	for len(consumed) < seqTotal {
+		receiveSeq()
+	}
+}
+
+func readQueuedPipelines(t *testing.T, maxBytes int, round int) *PipelineConfig {
+	config, err := defaultsAndValidate([]byte(fmt.Sprintf(queuedPipeline, maxBytes, round)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/eventbus"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+// ConfigHandler receives the pipelines pushed by ConfigProvider
+type ConfigHandler interface {
+	// SyncPipelines replaces all the pipelines previously pushed with the key, which is the path of the snapshot file
+	// of the pipelines written by the provider
+	SyncPipelines(key string, configs *PipelineRawConfig) error
+}
+
+// ConfigProvider generates pipelines at runtime, eg. kubernetes discovery. The pipelines are pushed to the handler as
+// soon as they are changed, rather than polled by the reloader from the config files.
+type ConfigProvider interface {
+	SetConfigHandler(handler ConfigHandler)
+}
+
+// SyncPipelines starts, reloads or stops the pipelines pushed with the key. The pipelines are validated with the same
+// defaults as the config files, nothing is applied if any of them is invalid.
+func (c *Controller) SyncPipelines(key string, configs *PipelineRawConfig) error {
+	content, err := yaml.Marshal(configs)
+	if err != nil {
+		return err
+	}
+	pipelines, err := defaultsAndValidate(content)
+	if err != nil {
+		return errors.WithMessagef(err, "invalidate pipelines of %s", key)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	owned, synced := c.provided[key]
+	if !synced {
+		// the pipelines in the snapshot file are started from the config files at boot, they are stale if not pushed
+		owned = snapshotPipelines(key)
+	}
+	current := make(map[string]pipeline.Config)
+	for _, p := range c.CurrentConfig.Pipelines {
+		current[p.Name] = p
+	}
+
+	names := make(map[string]struct{})
+	changed := make([]pipeline.Config, 0)
+	for _, p := range pipelines.Pipelines {
+		names[p.Name] = struct{}{}
+		if old, ok := current[p.Name]; ok && reflect.DeepEqual(old, p) {
+			continue
+		}
+		changed = append(changed, p)
+	}
+	removed := make([]pipeline.Config, 0)
+	for name := range owned {
+		if _, ok := names[name]; ok {
+			continue
+		}
+		if p, ok := current[name]; ok {
+			removed = append(removed, p)
+		}
+	}
+	c.provided[key] = names
+
+	if len(changed) == 0 && len(removed) == 0 {
+		return nil
+	}
+	log.Info("syncing pipelines of %s, changed: %d, removed: %d", key, len(changed), len(removed))
+
+	c.stopPipelines(removed)
+	restarted, reloaded := c.reloadPipelines(changed)
+	eventbus.Publish(eventbus.ReloadTopic, eventbus.ReloadMetricData{
+		Tick:               1,
+		RestartedPipelines: restarted,
+		ReloadedComponents: reloaded,
+	})
+	return nil
+}
+
+// Provided returns true if the pipeline is pushed by ConfigProvider
+func (c *Controller) Provided(name string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, names := range c.provided {
+		if _, ok := names[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshotPipelines returns the names of the pipelines in the snapshot file
+func snapshotPipelines(path string) map[string]struct{} {
+	names := make(map[string]struct{})
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("read snapshot of pipelines %s failed: %v", path, err)
+		}
+		return names
+	}
+	raws := &PipelineRawConfig{}
+	if err := yaml.Unmarshal(content, raws); err != nil {
+		log.Warn("unmarshal snapshot of pipelines %s failed: %v", path, err)
+		return names
+	}
+	for _, p := range raws.Pipelines {
+		names[p.Name] = struct{}{}
+	}
+	return names
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package control
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+const providedPipeline = `
+  - name: %s
+    sources:
+      - type: dev
+        name: %s
+    queue:
+      type: channel
+    sink:
+      type: dev
+`
+
+func providedPipelines(t *testing.T, pipelines ...string) *PipelineRawConfig {
+	content := "pipelines:"
+	for i := 0; i < len(pipelines); i += 2 {
+		content += fmt.Sprintf(providedPipeline, pipelines[i], pipelines[i+1])
+	}
+	raws := &PipelineRawConfig{}
+	if err := yaml.Unmarshal([]byte(content), raws); err != nil {
+		t.Fatal(err)
+	}
+	return raws
+}
+
+func TestController_SyncPipelines(t *testing.T) {
+	c := NewController()
+	defer func() {
+		c.StopPipelines(c.CurrentPipelines().Pipelines)
+	}()
+
+	if err := c.SyncPipelines("kube-loggie.yml", providedPipelines(t, "p1", "s1", "p2", "s1")); err != nil {
+		t.Fatal(err)
+	}
+	if c.pipelineRunner["p1"] == nil || c.pipelineRunner["p2"] == nil || !c.Provided("p1") || !c.Provided("p2") {
+		t.Fatalf("pipelines pushed are not started")
+	}
+	running := c.pipelineRunner["p1"]
+
+	// p1 changed and p2 removed
+	if err := c.SyncPipelines("kube-loggie.yml", providedPipelines(t, "p1", "s2")); err != nil {
+		t.Fatal(err)
+	}
+	if c.pipelineRunner["p1"] != running || c.pipelineRunner["p2"] != nil || c.Provided("p2") {
+		t.Errorf("p1 should be reloaded and p2 should be stopped")
+	}
+
+	// pipelines of other keys are kept
+	if err := c.SyncPipelines("node-config.yml", providedPipelines(t, "p3", "s1")); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.CurrentPipelines().Pipelines) != 2 {
+		t.Errorf("expect 2 pipelines, got %d", len(c.CurrentPipelines().Pipelines))
+	}
+
+	// nothing applied when invalid
+	if err := c.SyncPipelines("node-config.yml", providedPipelines(t, "p3", "")); err == nil {
+		t.Errorf("expect error of source name required")
+	}
+	if c.pipelineRunner["p3"] == nil {
+		t.Errorf("p3 should be kept when pushed pipelines are invalid")
+	}
+}
+
+func TestController_SyncPipelinesOfSnapshot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "provider")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the snapshot written by the last run is started from the config files at boot
+	snapshot := providedPipelines(t, "p1", "s1", "p2", "s1")
+	content, err := yaml.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := filepath.Join(dir, "kube-loggie.yml")
+	if err := ioutil.WriteFile(key, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	boot, err := defaultsAndValidate(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewController()
+	c.StartPipelines(boot.Pipelines)
+	defer func() {
+		c.StopPipelines(c.CurrentPipelines().Pipelines)
+	}()
+
+	// p2 is stale in the snapshot
+	if err := c.SyncPipelines(key, providedPipelines(t, "p1", "s1")); err != nil {
+		t.Fatal(err)
+	}
+	if c.pipelineRunner["p1"] == nil || c.pipelineRunner["p2"] != nil {
+		t.Errorf("stale pipeline of snapshot should be stopped")
+	}
+}
@@ -174,6 +174,26 @@ func NewPool(capacity int, factory Factory) *Pool {
 	return pool
 }
 
+// Grow raises the capacity of pool, the events in use are not affected. The capacity is never reduced.
+func (p *Pool) Grow(capacity int) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if capacity <= p.capacity {
+		return
+	}
+	added := capacity - p.capacity
+	events := make([]api.Event, capacity)
+	copy(events, p.events[:p.free])
+	for i := p.free; i < p.free+added; i++ {
+		events[i] = p.factory()
+	}
+	p.events = events
+	p.free += added
+	p.capacity = capacity
+	p.cond.Broadcast()
+}
+
 func (p *Pool) Get() api.Event {
 	p.lock.Lock()
 
@@ -93,7 +93,7 @@ func NewLogger(config *LoggerConfig) *Logger {
 	if err != nil {
 		panic("set log level error, choose trace/debug/info/warn/error/fatal/panic")
 	}
-	multi := zerolog.MultiLevelWriter(mw)
+	multi := zerolog.MultiLevelWriter(mw, tapWriter{})
 	logger := zerolog.New(multi).Level(level).With().Timestamp().Caller().Logger()
 	return &Logger{
 		l: &logger,
@@ -0,0 +1,46 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+// PipelineKey is the field of the records logged by PipelineLogger
+const PipelineKey = "pipeline"
+
+// PipelineLogger adds the name of pipeline to the records, so that the loggie source could tell the records of its own
+// pipeline apart from the ones of the same components in other pipelines.
+type PipelineLogger struct {
+	logger *Logger
+}
+
+func ForPipeline(name string) *PipelineLogger {
+	l := defaultLogger.l.With().Str(PipelineKey, name).Logger()
+	return &PipelineLogger{
+		logger: &Logger{l: &l},
+	}
+}
+
+func (p *PipelineLogger) Info(format string, a ...interface{}) {
+	p.logger.Info(format, a...)
+}
+
+func (p *PipelineLogger) Warn(format string, a ...interface{}) {
+	p.logger.Warn(format, a...)
+}
+
+func (p *PipelineLogger) Error(format string, a ...interface{}) {
+	defer afterErrorOpt(format, a...)
+	p.logger.Error(format, a...)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// TapFunc receives a copy of each json log record, which is shared by all the taps and must not be modified.
+// It is called synchronously by the logging goroutine, so it must not block, and must not log either, or the records
+// would be fed back to itself.
+type TapFunc func(level zerolog.Level, record []byte)
+
+var (
+	tapLock sync.RWMutex
+	taps    = make(map[string]TapFunc)
+)
+
+// AddTap registers a TapFunc by name, the one registered with the same name would be replaced.
+func AddTap(name string, f TapFunc) {
+	tapLock.Lock()
+	defer tapLock.Unlock()
+	taps[name] = f
+}
+
+func RemoveTap(name string) {
+	tapLock.Lock()
+	defer tapLock.Unlock()
+	delete(taps, name)
+}
+
+// tapWriter passes the records to taps, it is one of the writers of the logger
+type tapWriter struct{}
+
+func (tapWriter) Write(p []byte) (int, error) {
+	return tapWriter{}.WriteLevel(zerolog.NoLevel, p)
+}
+
+func (tapWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	tapLock.RLock()
+	defer tapLock.RUnlock()
+	if len(taps) == 0 {
+		return len(p), nil
+	}
+
+	// the buffer of zerolog would be reused after writing
+	record := make([]byte, len(p))
+	copy(record, p)
+	for _, f := range taps {
+		f(level, record)
+	}
+	return len(p), nil
+}
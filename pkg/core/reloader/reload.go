@@ -81,34 +81,74 @@ func (r *Reloader) Run(stopCh <-chan struct{}) {
 			}
 
 			// diff config
-			stopList, startList := diffConfig(newConfig, r.controller.CurrentConfig)
+			stopList, startList, reloadList := diffConfig(r.excludeManaged(newConfig), r.excludeManaged(r.controller.CurrentPipelines()))
 
-			if len(stopList) > 0 || len(startList) > 0 {
-				log.Info("loggie is reloading..")
+			if len(stopList) == 0 && len(startList) == 0 && len(reloadList) == 0 {
+				continue
+			}
 
-				if newConfig != nil {
-					out, err := yaml.Marshal(newConfig)
-					if err == nil {
-						log.Info("reload latest pipelines config:\n%s", string(out))
-					}
+			log.Info("loggie is reloading..")
+			if newConfig != nil {
+				out, err := yaml.Marshal(newConfig)
+				if err == nil {
+					log.Info("reload latest pipelines config:\n%s", string(out))
 				}
-
-				eventbus.Publish(eventbus.ReloadTopic, eventbus.ReloadMetricData{
-					Tick: 1,
-				})
 			}
 
+			restarted := restartedPipelines(stopList, startList)
 			if len(stopList) > 0 {
 				r.controller.StopPipelines(stopList)
 			}
 			if len(startList) > 0 {
 				r.controller.StartPipelines(startList)
 			}
+			var reloaded map[string][]eventbus.ComponentBaseConfig
+			if len(reloadList) > 0 {
+				var fallback []string
+				fallback, reloaded = r.controller.ReloadPipelines(reloadList)
+				restarted = append(restarted, fallback...)
+			}
+
+			eventbus.Publish(eventbus.ReloadTopic, eventbus.ReloadMetricData{
+				Tick:               1,
+				RestartedPipelines: restarted,
+				ReloadedComponents: reloaded,
+			})
+		}
+	}
+}
+
+// excludeManaged excludes the pipelines created or updated by the api, and the pipelines pushed by config providers
+// which are only written to the config files as snapshots, or they would be stopped when absent in the config files
+func (r *Reloader) excludeManaged(config *control.PipelineConfig) *control.PipelineConfig {
+	ret := &control.PipelineConfig{}
+	for _, p := range config.Pipelines {
+		if r.controller.ApiManaged(p.Name) || r.controller.Provided(p.Name) {
+			continue
+		}
+		ret.AddPipeline(p)
+	}
+	return ret
+}
+
+// restartedPipelines returns the names of pipelines both stopped and started
+func restartedPipelines(stopList []pipeline.Config, startList []pipeline.Config) []string {
+	stopped := make(map[string]struct{})
+	for _, p := range stopList {
+		stopped[p.Name] = struct{}{}
+	}
+	restarted := make([]string, 0)
+	for _, p := range startList {
+		if _, ok := stopped[p.Name]; ok {
+			restarted = append(restarted, p.Name)
 		}
 	}
+	return restarted
 }
 
-func diffConfig(newConfig *control.PipelineConfig, oldConfig *control.PipelineConfig) (stopComponentList []pipeline.Config, startComponentList []pipeline.Config) {
+// diffConfig returns the pipelines to stop, to start, and to reload in place. A changed pipeline is restarted entirely
+// only when its queue changed, otherwise the changes of sources, interceptors and sink are reloaded in place.
+func diffConfig(newConfig *control.PipelineConfig, oldConfig *control.PipelineConfig) (stopComponentList []pipeline.Config, startComponentList []pipeline.Config, reloadComponentList []pipeline.Config) {
 	oldPipeIndex := make(map[string]pipeline.Config)
 	for _, p := range oldConfig.Pipelines {
 		oldPipeIndex[p.Name] = p
@@ -116,6 +156,7 @@ func diffConfig(newConfig *control.PipelineConfig, oldConfig *control.PipelineCo
 
 	stopList := make([]pipeline.Config, 0)
 	startList := make([]pipeline.Config, 0)
+	reloadList := make([]pipeline.Config, 0)
 
 	sourceComparer := cmp.Comparer(func(i, j []source.Config) bool {
 		return cmp.Equal(i, j, cmpopts.SortSlices(func(a, b source.Config) bool {
@@ -153,10 +194,15 @@ func diffConfig(newConfig *control.PipelineConfig, oldConfig *control.PipelineCo
 
 		// diff
 		equal := cmp.Equal(newPipe, oldPipe, sourceComparer, interceptorComparer, sinkComparer)
-		if !equal {
-			startList = append(startList, newPipe)
-			stopList = append(stopList, oldPipe)
+		if equal {
+			continue
+		}
+		if cmp.Equal(newPipe.Queue, oldPipe.Queue) {
+			reloadList = append(reloadList, newPipe)
+			continue
 		}
+		startList = append(startList, newPipe)
+		stopList = append(stopList, oldPipe)
 	}
 
 	// add old pipelines to stopList
@@ -164,5 +210,5 @@ func diffConfig(newConfig *control.PipelineConfig, oldConfig *control.PipelineCo
 		stopList = append(stopList, oldPipeIndex[k])
 	}
 
-	return stopList, startList
+	return stopList, startList, reloadList
 }
@@ -0,0 +1,87 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reloader
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/loggie-io/loggie/pkg/control"
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+	"github.com/loggie-io/loggie/pkg/core/queue"
+	"github.com/loggie-io/loggie/pkg/core/sink"
+	"github.com/loggie-io/loggie/pkg/core/source"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+func newPipelineConfig(name string, batchSize int, sources ...string) pipeline.Config {
+	p := pipeline.Config{
+		Name: name,
+		Queue: &queue.Config{
+			ComponentBaseConfig: cfg.ComponentBaseConfig{Type: "channel"},
+			BatchSize:           batchSize,
+		},
+		Sink: &sink.Config{
+			ComponentBaseConfig: cfg.ComponentBaseConfig{Type: "dev"},
+		},
+	}
+	for _, s := range sources {
+		p.Sources = append(p.Sources, source.Config{
+			ComponentBaseConfig: cfg.ComponentBaseConfig{Name: s, Type: "dev"},
+		})
+	}
+	return p
+}
+
+func names(configs []pipeline.Config) []string {
+	ret := make([]string, 0, len(configs))
+	for _, c := range configs {
+		ret = append(ret, c.Name)
+	}
+	return ret
+}
+
+func TestDiffConfig(t *testing.T) {
+	oldConfig := &control.PipelineConfig{}
+	oldConfig.AddPipelines([]pipeline.Config{
+		newPipelineConfig("unchanged", 100, "a"),
+		newPipelineConfig("sourceChanged", 100, "a"),
+		newPipelineConfig("queueChanged", 100, "a"),
+		newPipelineConfig("removed", 100, "a"),
+	})
+	newConfig := &control.PipelineConfig{}
+	newConfig.AddPipelines([]pipeline.Config{
+		newPipelineConfig("unchanged", 100, "a"),
+		newPipelineConfig("sourceChanged", 100, "a", "b"),
+		newPipelineConfig("queueChanged", 200, "a"),
+		newPipelineConfig("added", 100, "a"),
+	})
+
+	stopList, startList, reloadList := diffConfig(newConfig, oldConfig)
+	if got, want := names(stopList), []string{"queueChanged", "removed"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stop list: got %v, want %v", got, want)
+	}
+	if got, want := names(startList), []string{"queueChanged", "added"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("start list: got %v, want %v", got, want)
+	}
+	if got, want := names(reloadList), []string{"sourceChanged"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reload list: got %v, want %v", got, want)
+	}
+	if got, want := restartedPipelines(stopList, startList), []string{"queueChanged"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("restarted pipelines: got %v, want %v", got, want)
+	}
+}
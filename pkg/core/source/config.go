@@ -18,6 +18,7 @@ package source
 
 import (
 	"github.com/loggie-io/loggie/pkg/core/cfg"
+	"github.com/loggie-io/loggie/pkg/util/leaderelection"
 	"github.com/pkg/errors"
 )
 
@@ -31,11 +32,13 @@ type Config struct {
 	FieldsUnderKey          string                 `yaml:"fieldsUnderKey,omitempty" default:"fields"`
 	Fields                  map[string]interface{} `yaml:"fields,omitempty"`
 	FieldsFromEnv           map[string]string      `yaml:"fieldsFromEnv,omitempty"`
+	// LeaderElection runs the source only on the loggie holding the lease, for the cluster-scoped sources
+	LeaderElection leaderelection.Config `yaml:"leaderElection,omitempty"`
 }
 
 func (c *Config) Validate() error {
 	if c.Name == "" {
 		return ErrSourceNameRequired
 	}
-	return nil
+	return c.LeaderElection.Validate()
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package sysconfig
 
 import (
+	"github.com/loggie-io/loggie/pkg/control"
 	"github.com/loggie-io/loggie/pkg/core/cfg"
 	"github.com/loggie-io/loggie/pkg/core/reloader"
 	"github.com/loggie-io/loggie/pkg/discovery"
@@ -35,6 +36,7 @@ type Loggie struct {
 	Reload          reloader.ReloadConfig `yaml:"reload"`
 	Discovery       discovery.Config      `yaml:"discovery"`
 	Http            Http                  `yaml:"http" validate:"dive"`
+	Api             control.ApiConfig     `yaml:"api"`
 	MonitorEventBus eventbus.Config       `yaml:"monitor"`
 	Defaults        Defaults              `yaml:"defaults"`
 }
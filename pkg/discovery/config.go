@@ -17,11 +17,17 @@ limitations under the License.
 package discovery
 
 import (
+	"github.com/loggie-io/loggie/pkg/discovery/docker"
 	kubernetes "github.com/loggie-io/loggie/pkg/discovery/kubernetes/controller"
+	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/webhook"
 )
 
 // TODO validate and defaults
 type Config struct {
 	Enabled    bool              `yaml:"enabled"`
 	Kubernetes kubernetes.Config `yaml:"kubernetes" validate:"dive"`
+	// Webhook runs apart from discovery, the kubernetes config is used to connect to the apiserver
+	Webhook webhook.Config `yaml:"webhook"`
+	// Docker runs apart from discovery, for the hosts without kubernetes
+	Docker docker.Config `yaml:"docker"`
 }
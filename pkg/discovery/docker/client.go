@@ -0,0 +1,165 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// client is a minimal client of Docker Engine API, only the container APIs used by discovery are implemented
+type client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+func newClient(host string) (*client, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "parse docker host %s", host)
+	}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &client{httpClient: &http.Client{Transport: transport}, baseURL: "http://docker"}, nil
+
+	case "tcp", "http":
+		return &client{httpClient: &http.Client{}, baseURL: "http://" + u.Host}, nil
+	}
+	return nil, errors.Errorf("docker host scheme %s is not supported", u.Scheme)
+}
+
+type containerSummary struct {
+	ID string `json:"Id"`
+}
+
+type containerJSON struct {
+	ID      string `json:"Id"`
+	Name    string `json:"Name"`
+	LogPath string `json:"LogPath"`
+	State   struct {
+		Running bool `json:"Running"`
+	} `json:"State"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"Config"`
+	HostConfig struct {
+		LogConfig struct {
+			Type string `json:"Type"`
+		} `json:"LogConfig"`
+	} `json:"HostConfig"`
+	Mounts []struct {
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+	} `json:"Mounts"`
+	GraphDriver struct {
+		Name string            `json:"Name"`
+		Data map[string]string `json:"Data"`
+	} `json:"GraphDriver"`
+}
+
+// name returns the container name without the leading slash
+func (c *containerJSON) name() string {
+	return strings.TrimPrefix(c.Name, "/")
+}
+
+type event struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID string `json:"ID"`
+	} `json:"Actor"`
+}
+
+func (c *client) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return nil, errors.Errorf("request docker %s failed, status: %d, body: %s", path, resp.StatusCode, body)
+	}
+	return resp, nil
+}
+
+func (c *client) getJSON(ctx context.Context, path string, out interface{}) error {
+	resp, err := c.get(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// listContainers lists the containers not destroyed, the logs of the stopped ones may be not read completely
+func (c *client) listContainers(ctx context.Context) ([]containerSummary, error) {
+	var containers []containerSummary
+	err := c.getJSON(ctx, "/containers/json?all=1", &containers)
+	return containers, err
+}
+
+func (c *client) inspectContainer(ctx context.Context, id string) (*containerJSON, error) {
+	container := &containerJSON{}
+	if err := c.getJSON(ctx, fmt.Sprintf("/containers/%s/json", id), container); err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+// events streams the start and destroy events of containers until ctx is done or the stream is broken
+func (c *client) events(ctx context.Context, handle func(e event)) error {
+	filters := url.QueryEscape(`{"type":["container"],"event":["start","destroy"]}`)
+	resp, err := c.get(ctx, "/events?filters="+filters)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var e event
+		if err := decoder.Decode(&e); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		handle(e)
+	}
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+)
+
+type Config struct {
+	Enabled        bool   `yaml:"enabled"`
+	Host           string `yaml:"host" default:"unix:///var/run/docker.sock"`
+	ConfigFilePath string `yaml:"-"`
+
+	// CollectStdout collects the json-file logs of containers without loggie.io/paths label
+	CollectStdout bool `yaml:"collectStdout" default:"true"`
+	// Sink of the pipeline generated, the default sink is used when absent
+	Sink cfg.CommonCfg `yaml:"sink"`
+
+	Fields Fields `yaml:"fields"`
+	// LabelKey are the container labels added to fields
+	LabelKey []string `yaml:"labelKey"`
+}
+
+type Fields struct {
+	ContainerName  string `yaml:"container.name"`
+	ContainerID    string `yaml:"container.id"`
+	ContainerImage string `yaml:"container.image"`
+	ComposeProject string `yaml:"compose.project"`
+	ComposeService string `yaml:"compose.service"`
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/loggie-io/loggie/pkg/control"
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/util"
+)
+
+const (
+	PipelineName       = "docker"
+	GenerateConfigName = "docker-loggie.yml"
+
+	retryInterval = 5 * time.Second
+)
+
+// Discovery watches the containers of Docker Engine, and generates a pipeline with file sources of them
+type Discovery struct {
+	config  *Config
+	handler control.ConfigHandler
+	client  *client
+
+	// containers not destroyed, key: container id
+	containers map[string]*containerJSON
+}
+
+func NewDiscovery(config *Config) *Discovery {
+	return &Discovery{
+		config:     config,
+		containers: make(map[string]*containerJSON),
+	}
+}
+
+// SetConfigHandler implements control.ConfigProvider, the pipelines generated are pushed to the handler
+func (d *Discovery) SetConfigHandler(handler control.ConfigHandler) {
+	d.handler = handler
+}
+
+func (d *Discovery) Start(stopCh <-chan struct{}) {
+	cli, err := newClient(d.config.Host)
+	if err != nil {
+		log.Panic("Error building docker client: %s", err.Error())
+	}
+	d.client = cli
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	log.Info("Starting docker discovery, host: %s", d.config.Host)
+	for {
+		if err := d.run(ctx); err != nil {
+			log.Warn("docker discovery failed: %v, retry after %s", err, retryInterval)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Info("Shutting down docker discovery")
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// run lists all the containers after the events are watched so nothing is missed, and handles the events
+// until the stream is broken
+func (d *Discovery) run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan event, 128)
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- d.client.events(ctx, func(e event) {
+			select {
+			case events <- e:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	if err := d.resync(ctx); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			if err != nil {
+				return errors.WithMessage(err, "watch docker events")
+			}
+			return nil
+		case e := <-events:
+			d.handleEvent(ctx, e)
+		}
+	}
+}
+
+func (d *Discovery) resync(ctx context.Context) error {
+	summaries, err := d.client.listContainers(ctx)
+	if err != nil {
+		return errors.WithMessage(err, "list docker containers")
+	}
+
+	containers := make(map[string]*containerJSON)
+	for _, s := range summaries {
+		c, err := d.client.inspectContainer(ctx, s.ID)
+		if err != nil {
+			log.Warn("inspect docker container %s failed: %v", s.ID, err)
+			continue
+		}
+		containers[c.ID] = c
+	}
+	d.containers = containers
+	d.sync()
+	return nil
+}
+
+func (d *Discovery) handleEvent(ctx context.Context, e event) {
+	log.Debug("docker event received: %s %s", e.Action, e.Actor.ID)
+
+	switch e.Action {
+	case "start":
+		c, err := d.client.inspectContainer(ctx, e.Actor.ID)
+		if err != nil {
+			log.Warn("inspect docker container %s failed: %v", e.Actor.ID, err)
+			return
+		}
+		d.containers[c.ID] = c
+
+	// the log file is kept after the container died, so the source is removed only when destroyed, otherwise
+	// the tail of the log would not be read
+	case "destroy":
+		if _, ok := d.containers[e.Actor.ID]; !ok {
+			return
+		}
+		delete(d.containers, e.Actor.ID)
+
+	default:
+		return
+	}
+	d.sync()
+}
+
+// sync pushes the pipeline to the config handler if set, and writes it to the config file
+func (d *Discovery) sync() {
+	cfgRaws := d.pipelines()
+
+	if d.handler != nil {
+		if err := d.handler.SyncPipelines(filepath.Join(d.config.ConfigFilePath, GenerateConfigName), cfgRaws); err != nil {
+			log.Warn("sync docker pipelines failed: %v", err)
+			return
+		}
+	}
+
+	if d.config.ConfigFilePath == "" {
+		return
+	}
+	content, err := yaml.Marshal(cfgRaws)
+	if err != nil {
+		log.Warn("marshal docker pipelines failed: %v", err)
+		return
+	}
+	if err := util.WriteFileOrCreate(d.config.ConfigFilePath, GenerateConfigName, content); err != nil {
+		log.Warn("write docker pipelines to file failed: %v", err)
+	}
+}
+
+func (d *Discovery) pipelines() *control.PipelineRawConfig {
+	containers := make([]*containerJSON, 0, len(d.containers))
+	for _, c := range d.containers {
+		containers = append(containers, c)
+	}
+	sort.Slice(containers, func(i, j int) bool {
+		return containers[i].name() < containers[j].name()
+	})
+
+	var sources []cfg.CommonCfg
+	for _, c := range containers {
+		srcs, err := containerSources(d.config, c)
+		if err != nil {
+			log.Warn("generate sources of docker container %s failed: %v", c.name(), err)
+			continue
+		}
+		sources = append(sources, srcs...)
+	}
+
+	cfgRaws := &control.PipelineRawConfig{}
+	if len(sources) == 0 {
+		return cfgRaws
+	}
+	cfgRaws.Pipelines = []pipeline.ConfigRaw{{
+		Name:    PipelineName,
+		Sources: sources,
+		Sink:    d.config.Sink,
+	}}
+	return cfgRaws
+}
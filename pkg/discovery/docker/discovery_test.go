@@ -0,0 +1,217 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/loggie-io/loggie/pkg/control"
+	"github.com/loggie-io/loggie/pkg/core/log"
+)
+
+func newContainer(id, name string, labels map[string]string) *containerJSON {
+	c := &containerJSON{ID: id, Name: "/" + name, LogPath: "/var/lib/docker/containers/" + id + "/" + id + "-json.log"}
+	c.Config.Image = "nginx:1.21"
+	c.Config.Labels = labels
+	c.HostConfig.LogConfig.Type = logDriverJSONFile
+	c.Mounts = append(c.Mounts, struct {
+		Source      string `json:"Source"`
+		Destination string `json:"Destination"`
+	}{Source: "/data/logs", Destination: "/var/log"})
+	c.GraphDriver.Data = map[string]string{"UpperDir": "/var/lib/docker/overlay2/abc/diff"}
+	return c
+}
+
+func TestContainerSources(t *testing.T) {
+	config := &Config{
+		CollectStdout: true,
+		Fields: Fields{
+			ContainerName:  "container.name",
+			ComposeService: "compose.service",
+		},
+		LabelKey: []string{"app"},
+	}
+
+	tests := []struct {
+		name      string
+		container *containerJSON
+		want      []string
+		wantMulti bool
+	}{
+		{
+			name:      "stdout by default",
+			container: newContainer("c1", "web", nil),
+			want:      []string{"/var/lib/docker/containers/c1/c1-json.log"},
+		},
+		{
+			name:      "excluded",
+			container: newContainer("c1", "web", map[string]string{LabelExclude: "true"}),
+		},
+		{
+			name: "paths in mounts and rootfs",
+			container: newContainer("c1", "web", map[string]string{
+				LabelPaths:            "/var/log/nginx/*.log, /app/logs/app.log,stdout",
+				LabelMultilinePattern: "^\\d{4}",
+			}),
+			want: []string{
+				"/data/logs/nginx/*.log",
+				"/var/lib/docker/overlay2/abc/diff/app/logs/app.log",
+				"/var/lib/docker/containers/c1/c1-json.log",
+			},
+			wantMulti: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srcs, err := containerSources(config, tt.container)
+			if err != nil {
+				t.Fatalf("containerSources() error = %v", err)
+			}
+			if len(tt.want) == 0 {
+				if len(srcs) != 0 {
+					t.Fatalf("containerSources() = %v, want none", srcs)
+				}
+				return
+			}
+			if len(srcs) != 1 {
+				t.Fatalf("containerSources() got %d sources, want 1", len(srcs))
+			}
+			if got := srcs[0]["paths"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paths = %v, want %v", got, tt.want)
+			}
+			if _, ok := srcs[0]["multi"]; ok != tt.wantMulti {
+				t.Errorf("multi = %v, want %v", ok, tt.wantMulti)
+			}
+		})
+	}
+}
+
+func TestContainerFields(t *testing.T) {
+	config := &Config{
+		Fields: Fields{
+			ContainerName:  "container.name",
+			ContainerImage: "container.image",
+			ComposeProject: "compose.project",
+			ComposeService: "compose.service",
+		},
+		LabelKey: []string{"app", "absent"},
+	}
+	c := newContainer("c1", "shop_web_1", map[string]string{
+		LabelComposeProject: "shop",
+		LabelComposeService: "web",
+		"app":               "shop",
+	})
+
+	want := map[string]interface{}{
+		"container.name":  "shop_web_1",
+		"container.image": "nginx:1.21",
+		"compose.project": "shop",
+		"compose.service": "web",
+		"app":             "shop",
+	}
+	if got := containerFields(config, c); !reflect.DeepEqual(got, want) {
+		t.Errorf("containerFields() = %v, want %v", got, want)
+	}
+}
+
+type fakeHandler struct {
+	configs *control.PipelineRawConfig
+}
+
+func (h *fakeHandler) SyncPipelines(key string, configs *control.PipelineRawConfig) error {
+	h.configs = configs
+	return nil
+}
+
+func TestDiscovery(t *testing.T) {
+	log.InitDefaultLogger()
+
+	containers := map[string]*containerJSON{
+		"c1": newContainer("c1", "web", nil),
+		"c2": newContainer("c2", "db", nil),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]containerSummary{{ID: "c1"}})
+	})
+	for id, c := range containers {
+		c := c
+		mux.HandleFunc(fmt.Sprintf("/containers/%s/json", id), func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(c)
+		})
+	}
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cli, err := newClient(server.URL)
+	if err != nil {
+		t.Fatalf("newClient() error = %v", err)
+	}
+	handler := &fakeHandler{}
+	d := NewDiscovery(&Config{CollectStdout: true})
+	d.client = cli
+	d.SetConfigHandler(handler)
+
+	ctx := context.Background()
+	if err := d.resync(ctx); err != nil {
+		t.Fatalf("resync() error = %v", err)
+	}
+	assertSources(t, handler, []string{"web"})
+
+	start := event{Type: "container", Action: "start"}
+	start.Actor.ID = "c2"
+	d.handleEvent(ctx, start)
+	assertSources(t, handler, []string{"db", "web"})
+
+	// the source is kept until the container destroyed
+	die := event{Type: "container", Action: "die"}
+	die.Actor.ID = "c1"
+	d.handleEvent(ctx, die)
+	assertSources(t, handler, []string{"db", "web"})
+
+	destroy := event{Type: "container", Action: "destroy"}
+	destroy.Actor.ID = "c1"
+	d.handleEvent(ctx, destroy)
+	assertSources(t, handler, []string{"db"})
+
+	destroy.Actor.ID = "c2"
+	d.handleEvent(ctx, destroy)
+	if len(handler.configs.Pipelines) != 0 {
+		t.Errorf("pipelines = %v, want none", handler.configs.Pipelines)
+	}
+}
+
+func assertSources(t *testing.T, handler *fakeHandler, want []string) {
+	t.Helper()
+	if len(handler.configs.Pipelines) != 1 {
+		t.Fatalf("got %d pipelines, want 1", len(handler.configs.Pipelines))
+	}
+	var got []string
+	for _, src := range handler.configs.Pipelines[0].Sources {
+		got = append(got, src["name"].(string))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sources = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package docker
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/source/file"
+)
+
+// Labels on containers to customize the collection
+const (
+	// LabelPaths are the comma separated log paths in the container, or stdout
+	LabelPaths            = "loggie.io/paths"
+	LabelMultilinePattern = "loggie.io/multiline-pattern"
+	// LabelExclude is "true" to exclude the container from collection
+	LabelExclude = "loggie.io/exclude"
+
+	LabelComposeProject = "com.docker.compose.project"
+	LabelComposeService = "com.docker.compose.service"
+
+	PathStdout = "stdout"
+
+	logDriverJSONFile = "json-file"
+)
+
+// containerSources generates the file source of the container, the paths in the container are converted to the paths
+// on the host by mounts or the rootfs of the container
+func containerSources(config *Config, c *containerJSON) ([]cfg.CommonCfg, error) {
+	labels := c.Config.Labels
+	if labels[LabelExclude] == "true" {
+		return nil, nil
+	}
+
+	var containerPaths []string
+	for _, p := range strings.Split(labels[LabelPaths], ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			containerPaths = append(containerPaths, p)
+		}
+	}
+	if len(containerPaths) == 0 && config.CollectStdout {
+		containerPaths = []string{PathStdout}
+	}
+
+	var paths []string
+	for _, p := range containerPaths {
+		if p == PathStdout {
+			if c.HostConfig.LogConfig.Type != logDriverJSONFile || c.LogPath == "" {
+				log.Debug("log driver of docker container %s is %s, stdout is ignored", c.name(), c.HostConfig.LogConfig.Type)
+				continue
+			}
+			paths = append(paths, c.LogPath)
+			continue
+		}
+
+		hostPath, err := pathInHost(c, p)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, hostPath)
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	src := cfg.CommonCfg{
+		"type":   file.Type,
+		"name":   c.name(),
+		"paths":  paths,
+		"fields": containerFields(config, c),
+	}
+	if pattern := strings.TrimSpace(labels[LabelMultilinePattern]); pattern != "" {
+		src["multi"] = map[string]interface{}{
+			"active":  true,
+			"pattern": pattern,
+		}
+	}
+	return []cfg.CommonCfg{src}, nil
+}
+
+func pathInHost(c *containerJSON, containerPath string) (string, error) {
+	// the longest destination of mounts matched
+	var source, destination string
+	for _, m := range c.Mounts {
+		dest := strings.TrimSuffix(m.Destination, "/")
+		if containerPath != dest && !strings.HasPrefix(containerPath, dest+"/") {
+			continue
+		}
+		if len(dest) > len(destination) {
+			source, destination = m.Source, dest
+		}
+	}
+	if destination != "" {
+		return filepath.Join(source, strings.TrimPrefix(containerPath, destination)), nil
+	}
+
+	// logs not written to volumes are in the upperdir of overlay2
+	if upperDir := c.GraphDriver.Data["UpperDir"]; upperDir != "" {
+		return filepath.Join(upperDir, containerPath), nil
+	}
+	return "", errors.Errorf("path %s is neither in the mounts nor the rootfs of container %s", containerPath, c.name())
+}
+
+func containerFields(config *Config, c *containerJSON) map[string]interface{} {
+	fields := make(map[string]interface{})
+
+	m := config.Fields
+	if m.ContainerName != "" {
+		fields[m.ContainerName] = c.name()
+	}
+	if m.ContainerID != "" {
+		fields[m.ContainerID] = c.ID
+	}
+	if m.ContainerImage != "" {
+		fields[m.ContainerImage] = c.Config.Image
+	}
+	if m.ComposeProject != "" && c.Config.Labels[LabelComposeProject] != "" {
+		fields[m.ComposeProject] = c.Config.Labels[LabelComposeProject]
+	}
+	if m.ComposeService != "" && c.Config.Labels[LabelComposeService] != "" {
+		fields[m.ComposeService] = c.Config.Labels[LabelComposeService]
+	}
+	for _, key := range config.LabelKey {
+		if v, ok := c.Config.Labels[key]; ok {
+			fields[key] = v
+		}
+	}
+	return fields
+}
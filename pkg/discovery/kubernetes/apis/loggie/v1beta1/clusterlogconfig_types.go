@@ -0,0 +1,59 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterLogConfig is the cluster scoped LogConfig, which selects pods in all namespaces and can only be edited by
+// cluster administrators
+type ClusterLogConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   LogConfigSpec   `json:"spec"`
+	Status LogConfigStatus `json:"status"`
+}
+
+// ToLogConfig converts to a LogConfig without namespace, so they are handled in the same way
+func (in *ClusterLogConfig) ToLogConfig() *LogConfig {
+	lgc := &LogConfig{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       KindClusterLogConfig,
+			APIVersion: SchemeGroupVersion.String(),
+		},
+	}
+	in.ObjectMeta.DeepCopyInto(&lgc.ObjectMeta)
+	lgc.Namespace = ""
+	in.Spec.DeepCopyInto(&lgc.Spec)
+	in.Status.DeepCopyInto(&lgc.Status)
+	return lgc
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ClusterLogConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ClusterLogConfig `json:"items"`
+}
@@ -18,6 +18,7 @@ package v1beta1
 
 import (
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -26,6 +27,8 @@ const (
 	SelectorTypeNode   = "node"
 	SelectorTypeLoggie = "loggie"
 
+	KindClusterLogConfig = "ClusterLogConfig"
+
 	PathStdout = "stdout"
 )
 
@@ -46,14 +49,26 @@ type LogConfigSpec struct {
 }
 
 type Selector struct {
-	Cluster      string `json:"cluster,omitempty"`
-	Type         string `json:"type,omitempty"`
-	PodSelector  `json:",inline"`
-	NodeSelector `json:",inline"`
+	Cluster           string `json:"cluster,omitempty"`
+	Type              string `json:"type,omitempty"`
+	PodSelector       `json:",inline"`
+	NodeSelector      `json:",inline"`
+	NamespaceSelector `json:",inline"`
 }
 
 type PodSelector struct {
-	LabelSelector map[string]string `json:"labelSelector,omitempty"`
+	LabelSelector    map[string]string                 `json:"labelSelector,omitempty"`
+	MatchExpressions []metav1.LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+	// Containers are the names of containers collected, all containers are collected when empty
+	Containers        []string `json:"containers,omitempty"`
+	ExcludeContainers []string `json:"excludeContainers,omitempty"`
+}
+
+// NamespaceSelector selects the namespaces of pods, only valid for ClusterLogConfig
+type NamespaceSelector struct {
+	NamespaceSelector map[string]string `json:"namespaceSelector,omitempty"`
+	Namespaces        []string          `json:"namespaces,omitempty"`
+	ExcludeNamespaces []string          `json:"excludeNamespaces,omitempty"`
 }
 
 type NodeSelector struct {
@@ -69,6 +84,37 @@ type Pipeline struct {
 
 type LogConfigStatus struct {
 	Message Message `json:"message,omitempty"`
+	// Nodes are reported by loggie on each node, key: node name. Each loggie only patches its own key to avoid
+	// conflicts, and nodes without any pod matched(selector.type=pod) or not selected are absent.
+	Nodes map[string]NodeStatus `json:"nodes,omitempty"`
+}
+
+const (
+	ConditionTypeApplied = "Applied"
+	// ConditionTypeSinkResolved is false when the sinkRef is not found and the default sink is used instead
+	ConditionTypeSinkResolved = "SinkResolved"
+
+	ReasonApplied        = "Applied"
+	ReasonVolumeNotFound = "VolumeNotFound"
+	ReasonSinkNotFound   = "SinkNotFound"
+	ReasonInvalidConfig  = "InvalidConfig"
+	ReasonApplyFailed    = "ApplyFailed"
+)
+
+type NodeStatus struct {
+	// MatchedPods is the count of pods on the node collected, only for selector.type=pod
+	MatchedPods int `json:"matchedPods,omitempty"`
+	// AppliedGeneration is the latest generation of the LogConfig applied successfully on the node
+	AppliedGeneration int64       `json:"appliedGeneration,omitempty"`
+	Conditions        []Condition `json:"conditions,omitempty"`
+}
+
+type Condition struct {
+	Type               string                 `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
 }
 
 type Message struct {
@@ -90,8 +136,16 @@ func (in *LogConfig) Validate() error {
 		return errors.New("spec.selector.type is invalidate")
 	}
 
-	if tp == SelectorTypePod && len(in.Spec.Selector.LabelSelector) == 0 {
-		return errors.New("selector.labelSelector is required when selector.type=pod")
+	if tp == SelectorTypePod && !in.Spec.Selector.HasLabelSelector() && !in.Spec.Selector.HasNamespaceSelector() {
+		return errors.New("selector.labelSelector, selector.matchExpressions or namespace selection is required when selector.type=pod")
+	}
+
+	if !in.IsCluster() && in.Spec.Selector.HasNamespaceSelector() {
+		return errors.New("selector.namespaceSelector, namespaces and excludeNamespaces are only valid for ClusterLogConfig")
+	}
+
+	if _, err := metav1.LabelSelectorAsSelector(in.Spec.Selector.PodSelector.LabelSelectorAsMeta()); err != nil {
+		return errors.WithMessage(err, "selector.matchExpressions is invalid")
 	}
 
 	if tp == SelectorTypeLoggie && in.Spec.Selector.Cluster == "" {
@@ -105,6 +159,62 @@ func (in *LogConfig) Validate() error {
 	return nil
 }
 
+// IsCluster returns true if the LogConfig is converted from a ClusterLogConfig
+func (in *LogConfig) IsCluster() bool {
+	return in.Kind == KindClusterLogConfig
+}
+
+// HasLabelSelector returns true if labelSelector or matchExpressions is set
+func (s *PodSelector) HasLabelSelector() bool {
+	return len(s.LabelSelector) > 0 || len(s.MatchExpressions) > 0
+}
+
+// LabelSelectorAsMeta merges labelSelector and matchExpressions to a metav1.LabelSelector
+func (s *PodSelector) LabelSelectorAsMeta() *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels:      s.LabelSelector,
+		MatchExpressions: s.MatchExpressions,
+	}
+}
+
+// MatchContainer checks if the container is collected by containers and excludeContainers
+func (s *PodSelector) MatchContainer(name string) bool {
+	if len(s.Containers) > 0 && !contains(s.Containers, name) {
+		return false
+	}
+	return !contains(s.ExcludeContainers, name)
+}
+
+// HasNamespaceSelector returns true if any of namespaceSelector, namespaces and excludeNamespaces is set
+func (s *NamespaceSelector) HasNamespaceSelector() bool {
+	return len(s.NamespaceSelector) > 0 || len(s.Namespaces) > 0 || len(s.ExcludeNamespaces) > 0
+}
+
+// MatchNamespace checks if the namespace with labels is selected by namespaceSelector, namespaces and excludeNamespaces
+func (s *NamespaceSelector) MatchNamespace(name string, labels map[string]string) bool {
+	if len(s.Namespaces) > 0 && !contains(s.Namespaces, name) {
+		return false
+	}
+	if contains(s.ExcludeNamespaces, name) {
+		return false
+	}
+	for k, v := range s.NamespaceSelector {
+		if l, ok := labels[k]; !ok || l != v {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 type LogConfigList struct {
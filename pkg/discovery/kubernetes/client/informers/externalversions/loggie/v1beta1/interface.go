@@ -23,6 +23,8 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// ClusterLogConfigs returns a ClusterLogConfigInformer.
+	ClusterLogConfigs() ClusterLogConfigInformer
 	// Interceptors returns a InterceptorInformer.
 	Interceptors() InterceptorInformer
 	// LogConfigs returns a LogConfigInformer.
@@ -42,6 +44,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// ClusterLogConfigs returns a ClusterLogConfigInformer.
+func (v *version) ClusterLogConfigs() ClusterLogConfigInformer {
+	return &clusterLogConfigInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // Interceptors returns a InterceptorInformer.
 func (v *version) Interceptors() InterceptorInformer {
 	return &interceptorInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
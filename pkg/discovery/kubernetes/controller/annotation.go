@@ -0,0 +1,181 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/loggie-io/loggie/pkg/core/log"
+	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
+	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/helper"
+	"github.com/loggie-io/loggie/pkg/source/file"
+)
+
+// annotationLogConfigPrefix is the name prefix of the LogConfigs generated from pod annotations, which is not a valid
+// kubernetes name so that they never conflict with the real ones
+const annotationLogConfigPrefix = "annotation:"
+
+func isAnnotationLogConfig(lgcKey string) bool {
+	return strings.Contains(lgcKey, annotationLogConfigPrefix)
+}
+
+// handlePodAnnotations generates the pipeline configs of the pod from annotations, and handles them in the same way
+// as LogConfigs. The precedence rules are:
+//  1. a container selected by any LogConfig or ClusterLogConfig is not collected by annotations
+//  2. the container level annotations take precedence over the pod level ones
+//  3. loggie.io/exclude=true takes precedence over loggie.io/paths
+func (c *Controller) handlePodAnnotations(pod *corev1.Pod) error {
+	if !c.config.EnableAnnotation {
+		return nil
+	}
+
+	lgcList, err := helper.GetPodRelatedLogConfigs(pod, c.logConfigLister, c.clusterLogConfigLister, c.namespaceLister)
+	if err != nil {
+		return err
+	}
+	var related []*logconfigv1beta1.LogConfig
+	for _, lgc := range lgcList {
+		if !c.belongOfCluster(lgc.Spec.Selector.Cluster) || lgc.Validate() != nil {
+			continue
+		}
+		related = append(related, lgc)
+	}
+
+	lgc, err := annotationLogConfig(pod, related)
+	if err != nil {
+		return err
+	}
+
+	// remove the stale configs, eg: the sinkRef changed or all containers are selected by LogConfigs
+	podKey := helper.MetaNamespaceKey(pod.Namespace, pod.Name)
+	removed := false
+	for _, lgcKey := range c.typePodIndex.GetLogConfigKeysByPod(podKey) {
+		if !isAnnotationLogConfig(lgcKey) || (lgc != nil && lgcKey == helper.MetaNamespaceKey(lgc.Namespace, lgc.Name)) {
+			continue
+		}
+		if c.typePodIndex.DeletePipeConfigs(podKey, lgcKey) {
+			removed = true
+		}
+	}
+
+	if lgc == nil {
+		if removed {
+			if err := c.syncConfigToFile(logconfigv1beta1.SelectorTypePod); err != nil {
+				return errors.WithMessage(err, "sync config to file failed")
+			}
+		}
+		return nil
+	}
+
+	if err := c.handleLogConfigPerPod(lgc, pod); err != nil {
+		msg := fmt.Sprintf(MessageSyncFailed, "annotation", pod.Name, err.Error())
+		c.record.Event(pod, corev1.EventTypeWarning, ReasonFailed, msg)
+		return err
+	}
+	return nil
+}
+
+// handleAnnotationsOfPods handles the annotations of pods again when the LogConfigs related are changed
+func (c *Controller) handleAnnotationsOfPods(podKeys []string) {
+	if !c.config.EnableAnnotation {
+		return
+	}
+	for _, podKey := range podKeys {
+		namespace, name, err := cache.SplitMetaNamespaceKey(podKey)
+		if err != nil {
+			continue
+		}
+		pod, err := c.podsLister.Pods(namespace).Get(name)
+		if err != nil || !helper.IsPodReady(pod) {
+			continue
+		}
+		if err := c.handlePodAnnotations(pod); err != nil {
+			log.Warn("handle annotations of pod %s failed: %v", podKey, err)
+		}
+	}
+}
+
+// annotationLogConfig generates a LogConfig from the annotations of the pod, the pods with the same sinkRef in a
+// namespace share the LogConfig, and nil is returned when no container is collected by annotations
+func annotationLogConfig(pod *corev1.Pod, related []*logconfigv1beta1.LogConfig) (*logconfigv1beta1.LogConfig, error) {
+	selected := sets.NewString()
+	for _, lgc := range related {
+		for _, c := range pod.Spec.Containers {
+			if lgc.Spec.Selector.MatchContainer(c.Name) {
+				selected.Insert(c.Name)
+			}
+		}
+	}
+
+	sources := make([]map[string]interface{}, 0)
+	for _, c := range pod.Spec.Containers {
+		if selected.Has(c.Name) || helper.IsAnnotationExcluded(pod, c.Name) {
+			continue
+		}
+		paths := helper.GetAnnotationPaths(pod, c.Name)
+		if len(paths) == 0 {
+			continue
+		}
+
+		src := map[string]interface{}{
+			"type":          file.Type,
+			"name":          c.Name,
+			"containerName": c.Name,
+			"paths":         paths,
+		}
+		if pattern := helper.GetContainerAnnotation(pod, c.Name, helper.AnnotationMultilinePattern); pattern != "" {
+			src["multi"] = map[string]interface{}{
+				"active":  true,
+				"pattern": pattern,
+			}
+		}
+		sources = append(sources, src)
+	}
+	if len(sources) == 0 {
+		return nil, nil
+	}
+
+	content, err := yaml.Marshal(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	sinkRef := strings.TrimSpace(pod.Annotations[helper.AnnotationSinkRef])
+	return &logconfigv1beta1.LogConfig{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pod.Namespace,
+			Name:      annotationLogConfigPrefix + sinkRef,
+		},
+		Spec: logconfigv1beta1.LogConfigSpec{
+			Selector: &logconfigv1beta1.Selector{
+				Type: logconfigv1beta1.SelectorTypePod,
+			},
+			Pipeline: &logconfigv1beta1.Pipeline{
+				Sources: string(content),
+				SinkRef: sinkRef,
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
+)
+
+func TestAnnotationLogConfig(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+			Annotations: map[string]string{
+				"loggie.io/paths":                 "stdout",
+				"loggie.io/sink-ref":              "kafka",
+				"app.loggie.io/paths":             "/var/log/app/*.log, /var/log/app/gc.log",
+				"app.loggie.io/multiline-pattern": "^\\d{4}-",
+				"istio-proxy.loggie.io/exclude":   "true",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}, {Name: "istio-proxy"}, {Name: "selected"}},
+		},
+	}
+	related := []*logconfigv1beta1.LogConfig{{
+		Spec: logconfigv1beta1.LogConfigSpec{
+			Selector: &logconfigv1beta1.Selector{
+				PodSelector: logconfigv1beta1.PodSelector{Containers: []string{"selected"}},
+			},
+		},
+	}}
+
+	lgc, err := annotationLogConfig(pod, related)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lgc.Name != "annotation:kafka" || lgc.Namespace != "default" || lgc.Spec.Pipeline.SinkRef != "kafka" {
+		t.Fatalf("unexpected logConfig: %+v", lgc.ObjectMeta)
+	}
+
+	var sources []fileSource
+	if err := cfg.UnpackRaw([]byte(lgc.Spec.Pipeline.Sources), &sources); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]interface{})
+	for _, s := range sources {
+		got[s.ContainerName] = s.CommonCfg["paths"]
+	}
+	if len(got) != 2 {
+		t.Fatalf("want sources of app and sidecar, got %v", got)
+	}
+	if paths := got["app"].([]interface{}); len(paths) != 2 || paths[1] != "/var/log/app/gc.log" {
+		t.Errorf("unexpected paths of app: %v", paths)
+	}
+	if paths := got["sidecar"].([]interface{}); len(paths) != 1 || paths[0] != logconfigv1beta1.PathStdout {
+		t.Errorf("unexpected paths of sidecar: %v", paths)
+	}
+
+	// nothing collected when all containers are selected by LogConfigs
+	related[0].Spec.Selector.Containers = nil
+	if lgc, _ := annotationLogConfig(pod, related); lgc != nil {
+		t.Errorf("want nil, got %+v", lgc)
+	}
+}
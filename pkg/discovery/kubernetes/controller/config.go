@@ -19,6 +19,9 @@ package controller
 const (
 	RuntimeDocker     = "docker"
 	RuntimeContainerd = "containerd"
+	RuntimeCrio       = "cri-o"
+	// RuntimeAuto detects the runtime of each container by the prefix of containerID in pod status
+	RuntimeAuto = "auto"
 )
 
 type Config struct {
@@ -28,18 +31,51 @@ type Config struct {
 	NodeName       string `yaml:"-"`
 	ConfigFilePath string `yaml:"-"`
 
-	ContainerRuntime string `yaml:"containerRuntime" default:"docker" validate:"oneof=docker containerd"`
-	DockerDataRoot   string `yaml:"dockerDataRoot" default:"/var/lib/docker"`
-	PodLogDirPrefix  string `yaml:"podLogDirPrefix" default:"/var/log/pods"`
-	KubeletRootDir   string `yaml:"kubeletRootDir" default:"/var/lib/kubelet"`
+	ContainerRuntime   string `yaml:"containerRuntime" default:"docker" validate:"oneof=docker containerd cri-o auto"`
+	DockerDataRoot     string `yaml:"dockerDataRoot" default:"/var/lib/docker"`
+	ContainerdStateDir string `yaml:"containerdStateDir" default:"/run/containerd"`
+	CrioStorageRoot    string `yaml:"crioStorageRoot" default:"/var/lib/containers/storage"`
+	PodLogDirPrefix    string `yaml:"podLogDirPrefix" default:"/var/log/pods"`
+	KubeletRootDir     string `yaml:"kubeletRootDir" default:"/var/lib/kubelet"`
+
+	// EnableAnnotation collects logs of pods by annotations like loggie.io/paths without LogConfig
+	EnableAnnotation bool `yaml:"enableAnnotation" default:"false"`
 
 	Fields Fields `yaml:"fields"`
+
+	// Aggregator shards the pipelines of selector type loggie across the aggregator replicas
+	Aggregator Aggregator `yaml:"aggregator"`
+}
+
+type Aggregator struct {
+	// Service is the headless Service selecting the aggregator pods. The pipelines are assigned to the ready endpoints
+	// of it by the pipeline names, every aggregator runs all the pipelines when Service is empty.
+	Service   string `yaml:"service"`
+	Namespace string `yaml:"namespace" default:"loggie-aggregator"`
+	// PodName identifies this aggregator in the endpoints, defaults to the hostname
+	PodName string `yaml:"podName"`
+}
+
+// ShardingEnabled returns true if the pipelines of selector type loggie should be sharded
+func (a *Aggregator) ShardingEnabled() bool {
+	return a.Service != ""
 }
 
 type Fields struct {
-	NodeName      string `yaml:"node.name"`
-	Namespace     string `yaml:"namespace"`
-	PodName       string `yaml:"pod.name"`
-	ContainerName string `yaml:"container.name"`
-	LogConfig     string `yaml:"logConfig"`
+	NodeName          string `yaml:"node.name"`
+	Namespace         string `yaml:"namespace"`
+	PodName           string `yaml:"pod.name"`
+	PodIP             string `yaml:"pod.ip"`
+	PodUID            string `yaml:"pod.uid"`
+	ContainerName     string `yaml:"container.name"`
+	ContainerImage    string `yaml:"container.image"`
+	ContainerImageTag string `yaml:"container.imageTag"`
+	WorkloadKind      string `yaml:"workload.kind"`
+	WorkloadName      string `yaml:"workload.name"`
+	LogConfig         string `yaml:"logConfig"`
+}
+
+// WorkloadEnabled returns true if the workload of pods should be resolved
+func (f *Fields) WorkloadEnabled() bool {
+	return f.WorkloadKind != "" || f.WorkloadName != ""
 }
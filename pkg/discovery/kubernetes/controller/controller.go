@@ -18,6 +18,7 @@ package controller
 
 import (
 	"fmt"
+	"github.com/loggie-io/loggie/pkg/control"
 	"github.com/loggie-io/loggie/pkg/core/log"
 	logconfigClientset "github.com/loggie-io/loggie/pkg/discovery/kubernetes/client/clientset/versioned"
 	logconfigSchema "github.com/loggie-io/loggie/pkg/discovery/kubernetes/client/clientset/versioned/scheme"
@@ -25,6 +26,7 @@ import (
 	logconfigLister "github.com/loggie-io/loggie/pkg/discovery/kubernetes/client/listers/loggie/v1beta1"
 	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/helper"
 	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/index"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -39,16 +41,24 @@ import (
 	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	batchv1Informers "k8s.io/client-go/informers/batch/v1"
 	corev1Informers "k8s.io/client-go/informers/core/v1"
+	batchv1Listers "k8s.io/client-go/listers/batch/v1"
 	corev1Listers "k8s.io/client-go/listers/core/v1"
 )
 
 const (
-	EventPod     = "pod"
-	EventLogConf = "logConfig"
-	EventNode    = "node"
+	EventPod            = "pod"
+	EventLogConf        = "logConfig"
+	EventClusterLogConf = "clusterLogConfig"
+	EventNode           = "node"
+	EventEndpoints      = "endpoints"
+	EventJob            = "job"
+	EventPodAnnotations = "podAnnotations"
 )
 
+const syncStalledInterval = time.Minute
+
 // Element the item add to queue
 type Element struct {
 	Type         string `json:"type"` // resource type, eg: pod
@@ -63,24 +73,48 @@ type Controller struct {
 	kubeClientset      kubernetes.Interface
 	logConfigClientset logconfigClientset.Interface
 
-	podsLister        corev1Listers.PodLister
-	podsSynced        cache.InformerSynced
-	logConfigLister   logconfigLister.LogConfigLister
-	logConfigSynced   cache.InformerSynced
-	sinkLister        logconfigLister.SinkLister
-	sinkSynced        cache.InformerSynced
-	interceptorLister logconfigLister.InterceptorLister
-	interceptorSynced cache.InformerSynced
-	nodeLister        corev1Listers.NodeLister
-	nodeSynced        cache.InformerSynced
+	podsLister      corev1Listers.PodLister
+	podsSynced      cache.InformerSynced
+	logConfigLister logconfigLister.LogConfigLister
+	logConfigSynced cache.InformerSynced
+	// clusterLogConfigLister and namespaceLister are nil when the ClusterLogConfig CRD is not served or not allowed by RBAC
+	clusterLogConfigLister logconfigLister.ClusterLogConfigLister
+	clusterLogConfigSynced cache.InformerSynced
+	sinkLister             logconfigLister.SinkLister
+	sinkSynced             cache.InformerSynced
+	interceptorLister      logconfigLister.InterceptorLister
+	interceptorSynced      cache.InformerSynced
+	nodeLister             corev1Listers.NodeLister
+	nodeSynced             cache.InformerSynced
+	namespaceLister        corev1Listers.NamespaceLister
+	namespaceSynced        cache.InformerSynced
+	// jobLister is used to resolve the CronJob of pods, nil if the workload fields are absent
+	jobLister batchv1Listers.JobLister
+	jobSynced cache.InformerSynced
+	// endpointsLister gets the endpoints of aggregator Service, nil if the sharding is disabled
+	endpointsLister corev1Listers.EndpointsLister
+	endpointsSynced cache.InformerSynced
 
 	typePodIndex    *index.LogConfigTypePodIndex
 	typeLoggieIndex *index.LogConfigTypeLoggieIndex
 	typeNodeIndex   *index.LogConfigTypeNodeIndex
 
 	nodeLabels map[string]string
+	// shardMembers are the aggregators which the pipelines of selector type loggie are sharded to
+	shardMembers []string
 
 	record record.EventRecorder
+
+	// configHandler receives the pipelines as soon as they are reconciled
+	configHandler control.ConfigHandler
+	// nodeStatus are the status of this node reported to LogConfigs, key: namespace/name
+	nodeStatus map[string]logconfigv1beta1.NodeStatus
+	// podErrors are the errors of the pods on this node applied with LogConfigs, key: namespace/name of LogConfig
+	podErrors map[string]map[string]error
+}
+
+func (c *Controller) SetConfigHandler(handler control.ConfigHandler) {
+	c.configHandler = handler
 }
 
 func NewController(
@@ -89,9 +123,13 @@ func NewController(
 	logConfigClientset logconfigClientset.Interface,
 	podInformer corev1Informers.PodInformer,
 	logConfigInformer logconfigInformers.LogConfigInformer,
+	clusterLogConfigInformer logconfigInformers.ClusterLogConfigInformer,
 	sinkInformer logconfigInformers.SinkInformer,
 	interceptorInformer logconfigInformers.InterceptorInformer,
 	nodeInformer corev1Informers.NodeInformer,
+	namespaceInformer corev1Informers.NamespaceInformer,
+	jobInformer batchv1Informers.JobInformer,
+	endpointsInformer corev1Informers.EndpointsInformer,
 ) *Controller {
 
 	log.Info("Creating event broadcaster")
@@ -121,9 +159,45 @@ func NewController(
 		typeLoggieIndex: index.NewLogConfigTypeLoggieIndex(),
 		typeNodeIndex:   index.NewLogConfigTypeNodeIndex(),
 
+		nodeStatus: make(map[string]logconfigv1beta1.NodeStatus),
+		podErrors:  make(map[string]map[string]error),
+
 		record: recorder,
 	}
 
+	if jobInformer != nil {
+		controller.jobLister = jobInformer.Lister()
+		controller.jobSynced = jobInformer.Informer().HasSynced
+
+		// the pods may be handled before the Job is in cache, their CronJob is resolved when the Job added
+		jobInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				controller.enqueue(obj, EventJob, logconfigv1beta1.SelectorTypePod)
+			},
+		})
+	}
+	if endpointsInformer != nil {
+		controller.endpointsLister = endpointsInformer.Lister()
+		controller.endpointsSynced = endpointsInformer.Informer().HasSynced
+
+		endpointsInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				controller.enqueue(obj, EventEndpoints, logconfigv1beta1.SelectorTypeLoggie)
+			},
+			UpdateFunc: func(old, new interface{}) {
+				newEndpoints := new.(*corev1.Endpoints)
+				oldEndpoints := old.(*corev1.Endpoints)
+				if newEndpoints.ResourceVersion == oldEndpoints.ResourceVersion {
+					return
+				}
+				controller.enqueue(new, EventEndpoints, logconfigv1beta1.SelectorTypeLoggie)
+			},
+			DeleteFunc: func(obj interface{}) {
+				controller.enqueueForDelete(obj, EventEndpoints, logconfigv1beta1.SelectorTypeLoggie)
+			},
+		})
+	}
+
 	log.Info("Setting up event handlers")
 	utilruntime.Must(logconfigSchema.AddToScheme(scheme.Scheme))
 	logConfigInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -173,6 +247,58 @@ func NewController(
 		},
 	})
 
+	if clusterLogConfigInformer != nil {
+		controller.clusterLogConfigLister = clusterLogConfigInformer.Lister()
+		controller.clusterLogConfigSynced = clusterLogConfigInformer.Informer().HasSynced
+
+		clusterLogConfigInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				config := obj.(*logconfigv1beta1.ClusterLogConfig)
+				if config.Spec.Selector == nil {
+					return
+				}
+				if !controller.belongOfCluster(config.Spec.Selector.Cluster) {
+					return
+				}
+
+				controller.enqueue(obj, EventClusterLogConf, config.Spec.Selector.Type)
+			},
+			UpdateFunc: func(old, new interface{}) {
+				newConfig := new.(*logconfigv1beta1.ClusterLogConfig)
+				oldConfig := old.(*logconfigv1beta1.ClusterLogConfig)
+				if newConfig.ResourceVersion == oldConfig.ResourceVersion {
+					return
+				}
+				if newConfig.Generation == oldConfig.Generation {
+					return
+				}
+
+				if newConfig.Spec.Selector == nil {
+					return
+				}
+				if !controller.belongOfCluster(newConfig.Spec.Selector.Cluster) {
+					return
+				}
+
+				controller.enqueue(new, EventClusterLogConf, newConfig.Spec.Selector.Type)
+			},
+			DeleteFunc: func(obj interface{}) {
+				config, ok := obj.(*logconfigv1beta1.ClusterLogConfig)
+				if !ok {
+					return
+				}
+				if config.Spec.Selector == nil {
+					return
+				}
+				if !controller.belongOfCluster(config.Spec.Selector.Cluster) {
+					return
+				}
+
+				controller.enqueueForDelete(obj, EventClusterLogConf, config.Spec.Selector.Type)
+			},
+		})
+	}
+
 	podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			po := obj.(*corev1.Pod)
@@ -191,6 +317,10 @@ func NewController(
 				return
 			}
 			controller.enqueue(new, EventPod, logconfigv1beta1.SelectorTypePod)
+			// the pod in index is not handled again by the pod event
+			if controller.config.EnableAnnotation && helper.IsAnnotationsChanged(oldPod, newPod) {
+				controller.enqueue(new, EventPodAnnotations, logconfigv1beta1.SelectorTypePod)
+			}
 		},
 		DeleteFunc: func(obj interface{}) {
 			po := obj.(*corev1.Pod)
@@ -219,9 +349,55 @@ func NewController(
 		},
 	})
 
+	if namespaceInformer != nil {
+		controller.namespaceLister = namespaceInformer.Lister()
+		controller.namespaceSynced = namespaceInformer.Informer().HasSynced
+
+		namespaceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(old, new interface{}) {
+				newNs := new.(*corev1.Namespace)
+				oldNs := old.(*corev1.Namespace)
+				if newNs.ResourceVersion == oldNs.ResourceVersion {
+					return
+				}
+
+				if reflect.DeepEqual(newNs.Labels, oldNs.Labels) {
+					return
+				}
+
+				controller.enqueueClusterLogConfigsByNamespace()
+			},
+		})
+	}
+
 	return controller
 }
 
+// enqueueClusterLogConfigsByNamespace enqueues the ClusterLogConfigs selecting namespaces by labels
+func (c *Controller) enqueueClusterLogConfigsByNamespace() {
+	if c.clusterLogConfigLister == nil {
+		return
+	}
+	configs, err := c.clusterLogConfigLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, config := range configs {
+		if config.Spec.Selector == nil || config.Spec.Selector.Type != logconfigv1beta1.SelectorTypePod {
+			continue
+		}
+		if len(config.Spec.Selector.NamespaceSelector.NamespaceSelector) == 0 {
+			continue
+		}
+		if !c.belongOfCluster(config.Spec.Selector.Cluster) {
+			continue
+		}
+
+		c.enqueue(config, EventClusterLogConf, config.Spec.Selector.Type)
+	}
+}
+
 func (c *Controller) enqueue(obj interface{}, eleType string, selectorType string) {
 	var key string
 	var err error
@@ -252,6 +428,30 @@ func (c *Controller) enqueueForDelete(obj interface{}, eleType string, selectorT
 	c.workqueue.Add(e)
 }
 
+// warnSyncStalled keeps logging an error while the informer caches are not synced, so that a missing CRD or RBAC
+// permission does not stop the discovery silently
+func (c *Controller) warnSyncStalled(done <-chan struct{}, stopCh <-chan struct{}, synced []cache.InformerSynced) {
+	ticker := time.NewTicker(syncStalledInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-stopCh:
+			return
+		case <-ticker.C:
+			unsynced := 0
+			for _, s := range synced {
+				if !s() {
+					unsynced++
+				}
+			}
+			log.Error("%d informer caches are still not synced after waiting, kubernetes discovery is blocked, "+
+				"please check the CRDs are installed and the RBAC permissions of loggie", unsynced)
+		}
+	}
+}
+
 func (c *Controller) Run(stopCh <-chan struct{}) error {
 	defer utilruntime.HandleCrash()
 	defer c.workqueue.ShutDown()
@@ -261,7 +461,24 @@ func (c *Controller) Run(stopCh <-chan struct{}) error {
 
 	// Wait for the caches to be synced before starting workers
 	log.Info("Waiting for informer caches to sync")
-	if ok := cache.WaitForCacheSync(stopCh, c.podsSynced, c.logConfigSynced); !ok {
+	synced := []cache.InformerSynced{c.podsSynced, c.logConfigSynced, c.nodeSynced}
+	if c.clusterLogConfigSynced != nil {
+		synced = append(synced, c.clusterLogConfigSynced)
+	}
+	if c.namespaceSynced != nil {
+		synced = append(synced, c.namespaceSynced)
+	}
+	if c.jobSynced != nil {
+		synced = append(synced, c.jobSynced)
+	}
+	if c.endpointsSynced != nil {
+		synced = append(synced, c.endpointsSynced)
+	}
+	syncDone := make(chan struct{})
+	go c.warnSyncStalled(syncDone, stopCh, synced)
+	ok := cache.WaitForCacheSync(stopCh, synced...)
+	close(syncDone)
+	if !ok {
 		return fmt.Errorf("failed to wait for caches to sync")
 	}
 
@@ -348,11 +565,31 @@ func (c *Controller) syncHandler(element Element) error {
 			log.Warn("reconcile logConfig %s err: %+v", element.Key, err)
 		}
 
+	case EventClusterLogConf:
+		if err = c.reconcileClusterLogConfig(element); err != nil {
+			log.Warn("reconcile clusterLogConfig %s err: %+v", element.Key, err)
+		}
+
 	case EventNode:
 		if err = c.reconcileNode(element.Key); err != nil {
 			log.Warn("reconcile node %s err: %v", element.Key, err)
 		}
 
+	case EventEndpoints:
+		if err = c.reconcileEndpoints(element.Key); err != nil {
+			log.Warn("reconcile endpoints %s err: %v", element.Key, err)
+		}
+
+	case EventPodAnnotations:
+		if err = c.reconcilePodAnnotations(element.Key); err != nil {
+			log.Warn("reconcile annotations of pod %s err: %v", element.Key, err)
+		}
+
+	case EventJob:
+		if err = c.reconcileJob(element.Key); err != nil {
+			log.Warn("reconcile job %s err: %v", element.Key, err)
+		}
+
 	default:
 		utilruntime.HandleError(fmt.Errorf("element type: %s not supported", element.Type))
 		return nil
@@ -21,13 +21,19 @@ import (
 	"github.com/loggie-io/loggie/pkg/control"
 	"github.com/loggie-io/loggie/pkg/core/log"
 	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
+	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/helper"
 	"github.com/loggie-io/loggie/pkg/util"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/cache"
+	"path/filepath"
+	"reflect"
 )
 
 const (
@@ -55,7 +61,25 @@ func (c *Controller) reconcileLogConfig(element Element) error {
 		return err
 	}
 
+	return c.reconcileLogConfigAndRecord(logConf)
+}
+
+func (c *Controller) reconcileClusterLogConfig(element Element) error {
+	clusterLogConf, err := c.clusterLogConfigLister.Get(element.Key)
+
+	if kerrors.IsNotFound(err) {
+		return c.reconcileLogConfigDelete(element.Key, element.SelectorType)
+	} else if err != nil {
+		runtime.HandleError(fmt.Errorf("{crd: %s} failed to get clusterLogConfig by lister", element.Key))
+		return err
+	}
+
+	return c.reconcileLogConfigAndRecord(clusterLogConf.ToLogConfig())
+}
+
+func (c *Controller) reconcileLogConfigAndRecord(logConf *logconfigv1beta1.LogConfig) error {
 	err, keys := c.reconcileLogConfigAddOrUpdate(logConf)
+	c.reportStatus(logConf, err)
 	if err != nil {
 		msg := fmt.Sprintf(MessageSyncFailed, logConf.Spec.Selector.Type, keys, err.Error())
 		c.record.Event(logConf, corev1.EventTypeWarning, ReasonFailed, msg)
@@ -86,6 +110,67 @@ func (c *Controller) reconcilePod(key string) error {
 	return err
 }
 
+// reconcilePodAnnotations handles the annotations changed of the pod in index, the pods not in index are handled along
+// with the LogConfigs by the pod events
+func (c *Controller) reconcilePodAnnotations(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	pod, err := c.podsLister.Pods(namespace).Get(name)
+	if kerrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !c.typePodIndex.IsPodExist(pod.Namespace, pod.Name) {
+		return nil
+	}
+	return c.handlePodAnnotations(pod)
+}
+
+// reconcileJob handles the pods of the Job indexed again, they are handled before the Job is in cache, so the Job is
+// taken as the workload instead of the CronJob
+func (c *Controller) reconcileJob(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		runtime.HandleError(fmt.Errorf("invalid resource key: %s", key))
+		return nil
+	}
+
+	job, err := c.jobLister.Jobs(namespace).Get(name)
+	if kerrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if metav1.GetControllerOf(job) == nil {
+		return nil
+	}
+
+	// pods lister only contains the pods of this node
+	pods, err := c.podsLister.Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		owner := metav1.GetControllerOf(pod)
+		if owner == nil || owner.Kind != helper.KindJob || owner.Name != job.Name {
+			continue
+		}
+		// the pods not indexed yet are handled by the pod events
+		if !c.typePodIndex.IsPodExist(pod.Namespace, pod.Name) {
+			continue
+		}
+		if err := c.handleLogConfigsOfPod(pod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (c *Controller) reconcileNode(name string) error {
 	node, err := c.nodeLister.Get(name)
 	if kerrors.IsNotFound(err) {
@@ -98,8 +183,14 @@ func (c *Controller) reconcileNode(name string) error {
 
 	// update node labels
 	n := node.DeepCopy()
+	changed := !reflect.DeepEqual(c.nodeLabels, n.Labels)
 	c.nodeLabels = n.Labels
 	log.Info("node label %v is set", c.nodeLabels)
+
+	// the LogConfigs of selector type node are selected and rendered by the labels
+	if changed {
+		c.enqueueNodeTypeLogConfigs()
+	}
 	return nil
 }
 
@@ -107,7 +198,7 @@ func (c *Controller) reconcileLogConfigAddOrUpdate(lgc *logconfigv1beta1.LogConf
 	log.Info("logConfig: %s/%s add or update event received", lgc.Namespace, lgc.Name)
 
 	if err := lgc.Validate(); err != nil {
-		return err, nil
+		return errors.WithMessage(errInvalidLogConfig, err.Error()), nil
 	}
 
 	switch lgc.Spec.Selector.Type {
@@ -128,9 +219,13 @@ func (c *Controller) reconcileLogConfigAddOrUpdate(lgc *logconfigv1beta1.LogConf
 
 func (c *Controller) reconcileLogConfigDelete(key string, selectorType string) error {
 	log.Info("logConfig: %s delete event received", key)
+	delete(c.nodeStatus, key)
+	delete(c.podErrors, key)
 
+	var podKeys []string
 	switch selectorType {
 	case logconfigv1beta1.SelectorTypePod:
+		podKeys = c.typePodIndex.GetPodKeysByLogConfig(key)
 		if ok := c.typePodIndex.DeletePipeConfigsByLogConfigKey(key); !ok {
 			return nil
 		}
@@ -156,6 +251,9 @@ func (c *Controller) reconcileLogConfigDelete(key string, selectorType string) e
 	}
 
 	log.Info("handle logConfig %s delete event and sync config file success", key)
+
+	// the pods may be collected by annotations now
+	c.handleAnnotationsOfPods(podKeys)
 	return nil
 }
 
@@ -168,21 +266,52 @@ func (c *Controller) reconcilePodAddOrUpdate(pod *corev1.Pod) error {
 func (c *Controller) reconcilePodDelete(key string) error {
 	log.Debug("pod: %s delete event received", key)
 
+	// the pod failed is not in index, but its errors are reported to the logConfigs
+	lgcKeys := sets.NewString(c.removePodErrors(key)...)
+
 	// delete from index
-	if ok := c.typePodIndex.DeletePipeConfigsByPodKey(key); !ok {
-		return nil
+	lgcKeys.Insert(c.typePodIndex.GetLogConfigKeysByPod(key)...)
+	if ok := c.typePodIndex.DeletePipeConfigsByPodKey(key); ok {
+		// sync to file
+		err := c.syncConfigToFile(logconfigv1beta1.SelectorTypePod)
+		if err != nil {
+			return errors.WithMessage(err, "sync config to file failed")
+		}
+		log.Info("handle pod %s delete event and sync config file success", key)
 	}
 
-	// sync to file
-	err := c.syncConfigToFile(logconfigv1beta1.SelectorTypePod)
-	if err != nil {
-		return errors.WithMessage(err, "sync config to file failed")
+	// matched pods of the related logConfigs changed
+	for _, lgcKey := range lgcKeys.List() {
+		lgc, err := c.getLogConfig(lgcKey)
+		if err != nil {
+			continue
+		}
+		c.reportStatus(lgc, nil)
 	}
-	log.Info("handle pod %s delete event and sync config file success", key)
-
 	return nil
 }
 
+// getLogConfig gets the LogConfig by key, which is the name for ClusterLogConfig
+func (c *Controller) getLogConfig(key string) (*logconfigv1beta1.LogConfig, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == "" {
+		if c.clusterLogConfigLister == nil {
+			return nil, fmt.Errorf("clusterLogConfig %s is not supported, the ClusterLogConfig informer is disabled", name)
+		}
+		clusterLgc, err := c.clusterLogConfigLister.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		return clusterLgc.ToLogConfig(), nil
+	}
+	return c.logConfigLister.LogConfigs(namespace).Get(name)
+}
+
+// syncConfigToFile pushes the pipelines of the selector type to the config handler if set, and writes them to the
+// config file, which is a snapshot for restarts and debugging when pushed
 func (c *Controller) syncConfigToFile(selectorType string) error {
 	fileName := GenerateConfigName
 	var cfgRaws *control.PipelineRawConfig
@@ -191,7 +320,7 @@ func (c *Controller) syncConfigToFile(selectorType string) error {
 		cfgRaws = c.typePodIndex.GetAllGroupByLogConfig()
 
 	case logconfigv1beta1.SelectorTypeLoggie:
-		cfgRaws = c.typeLoggieIndex.GetAll()
+		cfgRaws = c.shardPipelines(c.typeLoggieIndex.GetAll())
 		fileName = GenerateTypeLoggieConfigName
 
 	case logconfigv1beta1.SelectorTypeNode:
@@ -202,6 +331,12 @@ func (c *Controller) syncConfigToFile(selectorType string) error {
 		return errors.New("selector.type unsupported")
 	}
 
+	if c.configHandler != nil {
+		if err := c.configHandler.SyncPipelines(filepath.Join(c.config.ConfigFilePath, fileName), cfgRaws); err != nil {
+			return err
+		}
+	}
+
 	content, err := yaml.Marshal(cfgRaws)
 	if err != nil {
 		return err
@@ -17,10 +17,15 @@ limitations under the License.
 package controller
 
 import (
+	"reflect"
+
+	"github.com/loggie-io/loggie/pkg/control"
 	"github.com/loggie-io/loggie/pkg/core/log"
 	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
 	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/helper"
 	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/cache"
 )
 
 func (c *Controller) handleLogConfigTypeLoggie(lgc *logconfigv1beta1.LogConfig) error {
@@ -50,3 +55,46 @@ func (c *Controller) handleLogConfigTypeLoggie(lgc *logconfigv1beta1.LogConfig)
 	log.Info("handle logConfig %s/%s addOrUpdate event and sync config file success", lgc.Namespace, lgc.Name)
 	return nil
 }
+
+// reconcileEndpoints resyncs the pipelines of selector type loggie when the aggregators are changed
+func (c *Controller) reconcileEndpoints(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	var members []string
+	endpoints, err := c.endpointsLister.Endpoints(namespace).Get(name)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		members = helper.GetShardMembers(endpoints)
+	}
+
+	if reflect.DeepEqual(members, c.shardMembers) {
+		return nil
+	}
+	log.Info("aggregators are changed from %v to %v", c.shardMembers, members)
+	c.shardMembers = members
+
+	return c.syncConfigToFile(logconfigv1beta1.SelectorTypeLoggie)
+}
+
+// shardPipelines returns the pipelines assigned to this aggregator. The pipelines are not run until this aggregator
+// is ready in the endpoints, otherwise they would be duplicated on the aggregator owning them.
+func (c *Controller) shardPipelines(all *control.PipelineRawConfig) *control.PipelineRawConfig {
+	if c.endpointsLister == nil {
+		return all
+	}
+
+	self := c.config.Aggregator.PodName
+	shard := &control.PipelineRawConfig{}
+	for _, p := range all.Pipelines {
+		if helper.IsShardable(p) && helper.GetShardOwner(c.shardMembers, p.Name) != self {
+			continue
+		}
+		shard.Pipelines = append(shard.Pipelines, p)
+	}
+	return shard
+}
@@ -17,18 +17,56 @@ limitations under the License.
 package controller
 
 import (
+	"github.com/loggie-io/loggie/pkg/core/cfg"
 	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/core/source"
 	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
 	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/helper"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 )
 
+// nodeSource is the source when selector.type=node, the fields of matchFields are got from the node
+type nodeSource struct {
+	MatchFields   *matchFields `yaml:"matchFields,omitempty"`
+	cfg.CommonCfg `yaml:",inline"`
+}
+
 func (c *Controller) handleLogConfigTypeNode(lgc *logconfigv1beta1.LogConfig) error {
+	lgcKey := helper.MetaNamespaceKey(lgc.Namespace, lgc.Name)
+
+	node, err := c.nodeLister.Get(c.config.NodeName)
+	if err != nil {
+		return errors.WithMessagef(err, "get node %s failed", c.config.NodeName)
+	}
+
+	// check node selector
+	if lgc.Spec.Selector.NodeSelector.NodeSelector != nil {
+		if !helper.LabelsSubset(lgc.Spec.Selector.NodeSelector.NodeSelector, node.Labels) {
+			log.Debug("logConfig %s/%s is not belong to this node", lgc.Namespace, lgc.Name)
+			// the labels of node may be changed
+			if c.typeNodeIndex.DeleteConfig(lgcKey) {
+				return c.syncConfigToFile(logconfigv1beta1.SelectorTypeNode)
+			}
+			return nil
+		}
+	}
+
 	pipRaws, err := helper.ToPipeline(lgc, c.sinkLister, c.interceptorLister)
 	if err != nil {
 		return errors.WithMessage(err, "convert to pipeline config failed")
 	}
 
+	sources, err := getNodeSources(c.config, lgc, node)
+	if err != nil {
+		return err
+	}
+	for i := range pipRaws.Pipelines {
+		pipRaws.Pipelines[i].Sources = sources
+	}
+
 	pipCopy, err := pipRaws.DeepCopy()
 	if err != nil {
 		return errors.WithMessage(err, "deep copy pipeline config error")
@@ -38,22 +76,112 @@ func (c *Controller) handleLogConfigTypeNode(lgc *logconfigv1beta1.LogConfig) er
 		return err
 	}
 
-	lgcKey := helper.MetaNamespaceKey(lgc.Namespace, lgc.Name)
 	if err := c.typeNodeIndex.ValidateAndSetConfig(lgcKey, pipRaws.Pipelines); err != nil {
 		return err
 	}
 
-	// check node selector
-	if lgc.Spec.Selector.NodeSelector.NodeSelector != nil {
-		if !helper.LabelsSubset(lgc.Spec.Selector.NodeSelector.NodeSelector, c.nodeLabels) {
-			log.Debug("logConfig %s/%s is not belong to this node", lgc.Namespace, lgc.Name)
-			return nil
-		}
-	}
-
 	if err = c.syncConfigToFile(logconfigv1beta1.SelectorTypeNode); err != nil {
 		return errors.WithMessage(err, "failed to sync config to file")
 	}
 	log.Info("handle logConfig %s/%s addOrUpdate event and sync config file success", lgc.Namespace, lgc.Name)
 	return nil
 }
+
+// getNodeSources applies the presets, renders the variables of node in paths and fields, and injects the node fields
+func getNodeSources(config *Config, lgc *logconfigv1beta1.LogConfig, node *corev1.Node) ([]cfg.CommonCfg, error) {
+	var nodeSources []nodeSource
+	if err := cfg.UnpackRaw([]byte(lgc.Spec.Pipeline.Sources), &nodeSources); err != nil {
+		return nil, errors.WithMessage(err, "unpack sources failed")
+	}
+
+	sources := make([]cfg.CommonCfg, 0, len(nodeSources))
+	for _, s := range nodeSources {
+		src, err := helper.ApplyNodePreset(s.CommonCfg)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range []string{"paths", "fields"} {
+			v, ok := src[key]
+			if !ok {
+				continue
+			}
+			if src[key], err = helper.RenderNodeVars(v, node.Name, node.Labels); err != nil {
+				return nil, errors.WithMessagef(err, "render %s of source %s failed", key, src.GetName())
+			}
+		}
+
+		srccfg := &source.Config{}
+		if err := cfg.Unpack(src, srccfg); err != nil {
+			return nil, err
+		}
+		injectNodeFields(config, s.MatchFields, srccfg, node, lgc.Name)
+		c, err := cfg.Pack(srccfg)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, cfg.MergeCommonCfg(src, c, true))
+	}
+	return sources, nil
+}
+
+func injectNodeFields(config *Config, match *matchFields, src *source.Config, node *corev1.Node, lgcName string) {
+	if src.Fields == nil {
+		src.Fields = make(map[string]interface{})
+	}
+
+	m := config.Fields
+	if m.NodeName != "" {
+		src.Fields[m.NodeName] = node.Name
+	}
+	if m.LogConfig != "" {
+		src.Fields[m.LogConfig] = lgcName
+	}
+
+	if match != nil {
+		// labelKey are the labels of node, the same as nodeLabelKey
+		keys := append(append([]string(nil), match.LabelKey...), match.NodeLabelKey...)
+		if len(keys) > 0 {
+			for k, v := range helper.GetMatchedNodeLabel(keys, node.Labels) {
+				src.Fields[k] = v
+			}
+		}
+	}
+}
+
+// enqueueNodeTypeLogConfigs enqueues the LogConfigs and ClusterLogConfigs of selector type node, which are selected
+// and rendered by the labels of node
+func (c *Controller) enqueueNodeTypeLogConfigs() {
+	lgcs, err := c.logConfigLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, lgc := range lgcs {
+		if lgc.Spec.Selector == nil || lgc.Spec.Selector.Type != logconfigv1beta1.SelectorTypeNode {
+			continue
+		}
+		if !c.belongOfCluster(lgc.Spec.Selector.Cluster) {
+			continue
+		}
+		c.enqueue(lgc, EventLogConf, logconfigv1beta1.SelectorTypeNode)
+	}
+
+	if c.clusterLogConfigLister == nil {
+		return
+	}
+	clgcs, err := c.clusterLogConfigLister.List(labels.Everything())
+	if err != nil {
+		utilruntime.HandleError(err)
+		return
+	}
+	for _, clgc := range clgcs {
+		if clgc.Spec.Selector == nil || clgc.Spec.Selector.Type != logconfigv1beta1.SelectorTypeNode {
+			continue
+		}
+		if !c.belongOfCluster(clgc.Spec.Selector.Cluster) {
+			continue
+		}
+		c.enqueue(clgc, EventClusterLogConf, logconfigv1beta1.SelectorTypeNode)
+	}
+}
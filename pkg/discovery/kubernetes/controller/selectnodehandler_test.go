@@ -0,0 +1,89 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+	"github.com/loggie-io/loggie/pkg/core/source"
+	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
+)
+
+func TestGetNodeSources(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node-1",
+			Labels: map[string]string{"zone": "east", "rack": "r1"},
+		},
+	}
+	lgc := &logconfigv1beta1.LogConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "system", Namespace: "loggie"},
+		Spec: logconfigv1beta1.LogConfigSpec{
+			Pipeline: &logconfigv1beta1.Pipeline{
+				Sources: `
+- type: file
+  name: app
+  paths:
+  - /data/${node.labels.zone}/*.log
+  fields:
+    host: ${node.name}
+  matchFields:
+    labelKey: [rack]
+- preset: kubelet
+`,
+			},
+		},
+	}
+	config := &Config{Fields: Fields{NodeName: "node.name", LogConfig: "logconfig"}}
+
+	sources, err := getNodeSources(config, lgc, node)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 {
+		t.Fatalf("got %d sources, want 2", len(sources))
+	}
+
+	app := sources[0]
+	if _, ok := app["matchFields"]; ok {
+		t.Errorf("matchFields should be removed: %v", app)
+	}
+	if paths := app["paths"].([]interface{}); len(paths) != 1 || paths[0] != "/data/east/*.log" {
+		t.Errorf("unexpected paths: %v", paths)
+	}
+	srccfg := &source.Config{}
+	if err := cfg.Unpack(app, srccfg); err != nil {
+		t.Fatal(err)
+	}
+	for k, want := range map[string]string{"host": "node-1", "rack": "r1", "node.name": "node-1", "logconfig": "system"} {
+		if srccfg.Fields[k] != want {
+			t.Errorf("field %s = %v, want %s", k, srccfg.Fields[k], want)
+		}
+	}
+
+	kubelet := sources[1]
+	if kubelet.GetName() != "kubelet" || kubelet.GetType() != "file" {
+		t.Errorf("unexpected kubelet preset source: %v", kubelet)
+	}
+	if _, ok := kubelet["preset"]; ok {
+		t.Errorf("preset should be removed: %v", kubelet)
+	}
+}
@@ -33,6 +33,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"path/filepath"
 	"strings"
 )
 
@@ -86,31 +87,98 @@ type matchFields struct {
 	LabelKey      []string `yaml:"labelKey,omitempty"`
 	AnnotationKey []string `yaml:"annotationKey,omitempty"`
 	Env           []string `yaml:"env,omitempty"`
+	NodeLabelKey  []string `yaml:"nodeLabelKey,omitempty"`
+}
+
+// podMeta are the metadata of the resources related to the pod
+type podMeta struct {
+	workload   helper.Workload
+	nodeLabels map[string]string
+}
+
+func (c *Controller) getPodMeta(pod *corev1.Pod) podMeta {
+	meta := podMeta{}
+	if c.config.Fields.WorkloadEnabled() {
+		meta.workload = helper.GetPodWorkload(pod, c.jobLister)
+	}
+	if node, err := c.nodeLister.Get(pod.Spec.NodeName); err == nil {
+		meta.nodeLabels = node.Labels
+	}
+	return meta
 }
 
 func (c *Controller) handleLogConfigTypePodAddOrUpdate(lgc *logconfigv1beta1.LogConfig) (err error, podsName []string) {
 
 	// find pods related in the node
-	podList, err := helper.GetLogConfigRelatedPod(lgc, c.podsLister)
+	podList, err := helper.GetLogConfigRelatedPod(lgc, c.podsLister, c.namespaceLister)
 	if err != nil {
 		return err, nil
 	}
+
+	// remove the pods not matched anymore, eg: selector or namespace labels changed
+	if err := c.removeUnmatchedPods(lgc, podList); err != nil {
+		return err, nil
+	}
+
 	if len(podList) == 0 {
 		log.Info("logConfig %s/%s matched pods is null", lgc.Namespace, lgc.Name)
 		return nil, nil
 	}
 
-	var ret []string
+	lgcKey := helper.MetaNamespaceKey(lgc.Namespace, lgc.Name)
+	var ret, failed []string
 	for _, pod := range podList {
-		if err := c.handleLogConfigPerPod(lgc, pod); err != nil {
-			return err, []string{pod.Name}
+		err := c.handleLogConfigPerPod(lgc, pod)
+		c.setPodError(lgcKey, helper.MetaNamespaceKey(pod.Namespace, pod.Name), err)
+		if err != nil {
+			failed = append(failed, pod.Name)
+			continue
 		}
 		ret = append(ret, pod.Name)
 	}
+	// the containers selected by the LogConfig are not collected by annotations
+	c.handleAnnotationsOfPods(c.typePodIndex.GetPodKeysByLogConfig(lgcKey))
 
+	if len(failed) > 0 {
+		return c.podsError(lgcKey), failed
+	}
 	return nil, ret
 }
 
+func (c *Controller) removeUnmatchedPods(lgc *logconfigv1beta1.LogConfig, podList []*corev1.Pod) error {
+	matched := sets.NewString()
+	for _, pod := range podList {
+		matched.Insert(helper.MetaNamespaceKey(pod.Namespace, pod.Name))
+	}
+
+	lgcKey := helper.MetaNamespaceKey(lgc.Namespace, lgc.Name)
+	for podKey := range c.podErrors[lgcKey] {
+		if !matched.Has(podKey) {
+			c.setPodError(lgcKey, podKey, nil)
+		}
+	}
+
+	var removed []string
+	for _, podKey := range c.typePodIndex.GetPodKeysByLogConfig(lgcKey) {
+		if matched.Has(podKey) {
+			continue
+		}
+		if c.typePodIndex.DeletePipeConfigs(podKey, lgcKey) {
+			log.Info("pod %s is not matched by logConfig %s anymore", podKey, lgcKey)
+			removed = append(removed, podKey)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	if err := c.syncConfigToFile(logconfigv1beta1.SelectorTypePod); err != nil {
+		return errors.WithMessage(err, "sync config to file failed")
+	}
+	c.handleAnnotationsOfPods(removed)
+	return nil
+}
+
 func (c *Controller) handlePodAddOrUpdate(pod *corev1.Pod) error {
 
 	// check if pod is in the index
@@ -119,9 +187,14 @@ func (c *Controller) handlePodAddOrUpdate(pod *corev1.Pod) error {
 		return nil
 	}
 
+	return c.handleLogConfigsOfPod(pod)
+}
+
+// handleLogConfigsOfPod applies the related LogConfigs to the pod, the pipelines in index are updated when changed
+func (c *Controller) handleLogConfigsOfPod(pod *corev1.Pod) error {
 	// find pod related logConfigs
-	lgcList, err := helper.GetPodRelatedLogConfigs(pod, c.logConfigLister)
-	if err != nil || len(lgcList) == 0 {
+	lgcList, err := helper.GetPodRelatedLogConfigs(pod, c.logConfigLister, c.clusterLogConfigLister, c.namespaceLister)
+	if err != nil {
 		return nil
 	}
 
@@ -135,7 +208,10 @@ func (c *Controller) handlePodAddOrUpdate(pod *corev1.Pod) error {
 			continue
 		}
 
-		if err := c.handleLogConfigPerPod(lgc, pod); err != nil {
+		err := c.handleLogConfigPerPod(lgc, pod)
+		c.setPodError(helper.MetaNamespaceKey(lgc.Namespace, lgc.Name), helper.MetaNamespaceKey(pod.Namespace, pod.Name), err)
+		c.reportStatus(lgc, nil)
+		if err != nil {
 			msg := fmt.Sprintf(MessageSyncFailed, lgc.Spec.Selector.Type, pod.Name, err.Error())
 			c.record.Event(lgc, corev1.EventTypeWarning, ReasonFailed, msg)
 			return err
@@ -145,13 +221,13 @@ func (c *Controller) handlePodAddOrUpdate(pod *corev1.Pod) error {
 		c.record.Event(lgc, corev1.EventTypeNormal, ReasonSuccess, msg)
 	}
 
-	return nil
+	return c.handlePodAnnotations(pod)
 }
 
 func (c *Controller) handleLogConfigPerPod(lgc *logconfigv1beta1.LogConfig, pod *corev1.Pod) error {
 
 	// generate pod related pipeline configs
-	pipeRaw, err := getConfigFromPodAndLogConfig(c.config, lgc, pod, c.sinkLister, c.interceptorLister)
+	pipeRaw, err := getConfigFromPodAndLogConfig(c.config, lgc, pod, c.getPodMeta(pod), c.sinkLister, c.interceptorLister)
 	if err != nil {
 		return err
 	}
@@ -170,7 +246,8 @@ func (c *Controller) handleLogConfigPerPod(lgc *logconfigv1beta1.LogConfig, pod
 	}
 
 	// get pod related pipeline configs from index
-	cfgsInIndex := c.typePodIndex.GetPipeConfigs(pod.Namespace, pod.Name, lgc.Name)
+	lgcKey := helper.MetaNamespaceKey(lgc.Namespace, lgc.Name)
+	cfgsInIndex := c.typePodIndex.GetPipeConfigs(pod.Namespace, pod.Name, lgcKey)
 
 	// compare and check if we should update
 	// FIXME Array order may causes inequality
@@ -179,7 +256,7 @@ func (c *Controller) handleLogConfigPerPod(lgc *logconfigv1beta1.LogConfig, pod
 	}
 
 	// update index
-	if err := c.typePodIndex.ValidateAndSetConfigs(pod.Namespace, pod.Name, lgc.Name, pipeRaw); err != nil {
+	if err := c.typePodIndex.ValidateAndSetConfigs(pod.Namespace, pod.Name, lgcKey, pipeRaw); err != nil {
 		return err
 	}
 
@@ -194,20 +271,20 @@ func (c *Controller) handleLogConfigPerPod(lgc *logconfigv1beta1.LogConfig, pod
 	return nil
 }
 
-func getConfigFromPodAndLogConfig(config *Config, lgc *logconfigv1beta1.LogConfig, pod *corev1.Pod,
+func getConfigFromPodAndLogConfig(config *Config, lgc *logconfigv1beta1.LogConfig, pod *corev1.Pod, meta podMeta,
 	sinkLister v1beta1.SinkLister, interceptorLister v1beta1.InterceptorLister) (*pipeline.ConfigRaw, error) {
 
 	if len(pod.Status.ContainerStatuses) == 0 {
 		return nil, nil
 	}
-	cfgs, err := getConfigFromContainerAndLogConfig(config, lgc, pod, sinkLister, interceptorLister)
+	cfgs, err := getConfigFromContainerAndLogConfig(config, lgc, pod, meta, sinkLister, interceptorLister)
 	if err != nil {
 		return nil, err
 	}
 	return cfgs, nil
 }
 
-func getConfigFromContainerAndLogConfig(config *Config, lgc *logconfigv1beta1.LogConfig, pod *corev1.Pod,
+func getConfigFromContainerAndLogConfig(config *Config, lgc *logconfigv1beta1.LogConfig, pod *corev1.Pod, meta podMeta,
 	sinkLister v1beta1.SinkLister, interceptorLister v1beta1.InterceptorLister) (*pipeline.ConfigRaw, error) {
 
 	logConf := lgc.DeepCopy()
@@ -217,10 +294,14 @@ func getConfigFromContainerAndLogConfig(config *Config, lgc *logconfigv1beta1.Lo
 		return nil, errors.WithMessagef(err, "unpack logConfig %s sources failed", lgc.Namespace)
 	}
 
-	filesources, err := updateSources(sourceConfList, config, pod, logConf.Name)
+	filesources, err := updateSources(sourceConfList, config, pod, meta, logConf)
 	if err != nil {
 		return nil, err
 	}
+	// all containers are excluded
+	if len(filesources) == 0 {
+		return nil, nil
+	}
 	pipecfg, err := toPipeConfig(lgc.Namespace, lgc.Name, logConf.Spec.Pipeline, filesources, sinkLister, interceptorLister)
 	if err != nil {
 		return nil, err
@@ -228,10 +309,10 @@ func getConfigFromContainerAndLogConfig(config *Config, lgc *logconfigv1beta1.Lo
 	return pipecfg, nil
 }
 
-func updateSources(sourceConfList []fileSource, config *Config, pod *corev1.Pod, logConfigName string) ([]fileSource, error) {
+func updateSources(sourceConfList []fileSource, config *Config, pod *corev1.Pod, meta podMeta, lgc *logconfigv1beta1.LogConfig) ([]fileSource, error) {
 	filesources := make([]fileSource, 0)
 	for _, sourceConf := range sourceConfList {
-		filesrc, err := getConfigPerSource(config, sourceConf, pod, logConfigName)
+		filesrc, err := getConfigPerSource(config, sourceConf, pod, meta, lgc)
 		if err != nil {
 			return nil, err
 		}
@@ -240,19 +321,24 @@ func updateSources(sourceConfList []fileSource, config *Config, pod *corev1.Pod,
 	return filesources, nil
 }
 
-func getConfigPerSource(config *Config, s fileSource, pod *corev1.Pod, logconfigName string) ([]fileSource, error) {
+func getConfigPerSource(config *Config, s fileSource, pod *corev1.Pod, meta podMeta, lgc *logconfigv1beta1.LogConfig) ([]fileSource, error) {
 
 	filesrcList := make([]fileSource, 0)
 	for _, status := range pod.Status.ContainerStatuses {
+		if !lgc.Spec.Selector.MatchContainer(status.Name) {
+			continue
+		}
 		filesrc := fileSource{}
 		if err := util.Clone(s, &filesrc); err != nil {
 			return nil, err
 		}
-		statusContainerId := strings.Split(status.ContainerID, "//")
-		if len(statusContainerId) < 2 {
-			return nil, errors.Errorf("parse containerId in pod status err, status.ContainerID: %s", status.ContainerID)
+		runtime, containerId, err := helper.ParseContainerID(status.ContainerID)
+		if err != nil {
+			return nil, err
+		}
+		if config.ContainerRuntime != RuntimeAuto {
+			runtime = config.ContainerRuntime
 		}
-		containerId := statusContainerId[1]
 
 		src, err := filesrc.getSource()
 		if err != nil {
@@ -269,7 +355,7 @@ func getConfigPerSource(config *Config, s fileSource, pod *corev1.Pod, logconfig
 		src.Name = genTypePodSourceName(pod.Name, status.Name, src.Name)
 
 		// inject default pod metadata
-		if err = injectFields(config, s.MatchFields, src, pod, logconfigName, status.Name); err != nil {
+		if err = injectFields(config, s.MatchFields, src, pod, meta, lgc.Name, status.Name); err != nil {
 			return nil, err
 		}
 		if err := filesrc.setSource(src); err != nil {
@@ -277,7 +363,7 @@ func getConfigPerSource(config *Config, s fileSource, pod *corev1.Pod, logconfig
 		}
 
 		// use paths of the node
-		if err = updatePaths(config, &filesrc, pod, status.Name, containerId); err != nil {
+		if err = updatePaths(config, &filesrc, pod, status.Name, runtime, containerId); err != nil {
 			return nil, err
 		}
 
@@ -299,13 +385,13 @@ func getTypePodOriginSourceName(podSourceName string) string {
 	return res[len(res)-1]
 }
 
-func updatePaths(config *Config, s *fileSource, pod *corev1.Pod, containerName, containerId string) error {
+func updatePaths(config *Config, s *fileSource, pod *corev1.Pod, containerName, runtime, containerId string) error {
 	filecfg, err := s.getFileConfig()
 	if err != nil {
 		return err
 	}
 	// update paths with real paths in the node
-	nodePaths, err := getPathsInNode(config, filecfg.CollectConfig.Paths, pod, containerName, containerId)
+	nodePaths, err := getPathsInNode(config, filecfg.CollectConfig.Paths, pod, containerName, runtime, containerId)
 	if err != nil {
 		return err
 	}
@@ -318,7 +404,7 @@ func updatePaths(config *Config, s *fileSource, pod *corev1.Pod, containerName,
 	return nil
 }
 
-func getPathsInNode(config *Config, containerPaths []string, pod *corev1.Pod, containerName string, containerId string) ([]string, error) {
+func getPathsInNode(config *Config, containerPaths []string, pod *corev1.Pod, containerName string, runtime string, containerId string) ([]string, error) {
 	if len(containerPaths) == 0 {
 		return nil, errors.New("path is empty")
 	}
@@ -328,7 +414,7 @@ func getPathsInNode(config *Config, containerPaths []string, pod *corev1.Pod, co
 
 		// container stdout logs
 		if p == logconfigv1beta1.PathStdout {
-			if config.ContainerRuntime == RuntimeDocker {
+			if runtime == RuntimeDocker {
 				paths = append(paths, helper.GenDockerStdoutLog(config.DockerDataRoot, containerId))
 			} else {
 				paths = append(paths, helper.GenContainerdStdoutLog(config.PodLogDirPrefix, pod.Namespace, pod.Name, string(pod.UID), containerName)...)
@@ -337,18 +423,38 @@ func getPathsInNode(config *Config, containerPaths []string, pod *corev1.Pod, co
 			continue
 		}
 
-		// TODO find log files in docker rootfs
-		p, err := helper.PathsInNode(config.KubeletRootDir, []string{p}, pod, containerName)
+		nodePaths, err := helper.PathsInNode(config.KubeletRootDir, []string{p}, pod, containerName)
+		if errors.Is(err, helper.ErrVolumeNotFound) {
+			// logs not written to volumes are in the rootfs of container
+			rootfs, rootfsErr := getRootfsInNode(config, runtime, containerId)
+			if rootfsErr != nil {
+				return nil, errors.WithMessage(err, rootfsErr.Error())
+			}
+			paths = append(paths, filepath.Join(rootfs, p))
+			continue
+		}
 		if err != nil {
 			return nil, err
 		}
-		paths = append(paths, p...)
+		paths = append(paths, nodePaths...)
 	}
 
 	return paths, nil
 }
 
-func injectFields(config *Config, match *matchFields, src *source.Config, pod *corev1.Pod, lgcName string, containerName string) error {
+func getRootfsInNode(config *Config, runtime string, containerId string) (string, error) {
+	switch runtime {
+	case RuntimeDocker:
+		return helper.DockerRootfs(config.DockerDataRoot, containerId)
+	case RuntimeContainerd:
+		return helper.ContainerdRootfs(config.ContainerdStateDir, containerId), nil
+	case RuntimeCrio:
+		return helper.CrioRootfs(config.CrioStorageRoot, containerId)
+	}
+	return "", errors.Errorf("rootfs of container runtime %s is not supported", runtime)
+}
+
+func injectFields(config *Config, match *matchFields, src *source.Config, pod *corev1.Pod, meta podMeta, lgcName string, containerName string) error {
 	if src.Fields == nil {
 		src.Fields = make(map[string]interface{})
 	}
@@ -363,9 +469,31 @@ func injectFields(config *Config, match *matchFields, src *source.Config, pod *c
 	if m.PodName != "" {
 		src.Fields[m.PodName] = pod.Name
 	}
+	if m.PodIP != "" {
+		src.Fields[m.PodIP] = pod.Status.PodIP
+	}
+	if m.PodUID != "" {
+		src.Fields[m.PodUID] = string(pod.UID)
+	}
 	if m.ContainerName != "" {
 		src.Fields[m.ContainerName] = containerName
 	}
+	if m.ContainerImage != "" || m.ContainerImageTag != "" {
+		image := helper.GetContainerImage(pod, containerName)
+		if m.ContainerImage != "" {
+			src.Fields[m.ContainerImage] = image
+		}
+		if m.ContainerImageTag != "" {
+			_, tag := helper.SplitImage(image)
+			src.Fields[m.ContainerImageTag] = tag
+		}
+	}
+	if m.WorkloadKind != "" && meta.workload.Kind != "" {
+		src.Fields[m.WorkloadKind] = meta.workload.Kind
+	}
+	if m.WorkloadName != "" && meta.workload.Name != "" {
+		src.Fields[m.WorkloadName] = meta.workload.Name
+	}
 	if m.LogConfig != "" {
 		src.Fields[m.LogConfig] = lgcName
 	}
@@ -386,6 +514,11 @@ func injectFields(config *Config, match *matchFields, src *source.Config, pod *c
 				src.Fields[k] = v
 			}
 		}
+		if len(match.NodeLabelKey) > 0 {
+			for k, v := range helper.GetMatchedNodeLabel(match.NodeLabelKey, meta.nodeLabels) {
+				src.Fields[k] = v
+			}
+		}
 	}
 
 	return nil
@@ -394,7 +527,7 @@ func injectFields(config *Config, match *matchFields, src *source.Config, pod *c
 func toPipeConfig(lgcNamespace string, lgcName string, lgcPipe *logconfigv1beta1.Pipeline, filesources []fileSource, sinkLister v1beta1.SinkLister, interceptorLister v1beta1.InterceptorLister) (*pipeline.ConfigRaw, error) {
 	pipecfg := &pipeline.ConfigRaw{}
 
-	pipecfg.Name = fmt.Sprintf("%s/%s", lgcNamespace, lgcName)
+	pipecfg.Name = helper.MetaNamespaceKey(lgcNamespace, lgcName)
 
 	src, err := toPipelineSource(filesources)
 	if err != nil {
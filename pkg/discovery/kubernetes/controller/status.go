@@ -0,0 +1,243 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/loggie-io/loggie/pkg/core/log"
+	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
+	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/helper"
+)
+
+var errInvalidLogConfig = errors.New("invalid logConfig")
+
+// reportStatus patches the status of this node to the LogConfig when it is changed. The status is removed when the
+// LogConfig has no pod matched or is not selected on this node without error, so only the related nodes write status.
+// err is the error of the LogConfig itself, the errors of pods recorded by setPodError are reported when it is nil.
+func (c *Controller) reportStatus(lgc *logconfigv1beta1.LogConfig, err error) {
+	key := helper.MetaNamespaceKey(lgc.Namespace, lgc.Name)
+	if err == nil {
+		err = c.podsError(key)
+	}
+	previous, reported := c.nodeStatus[key]
+	if !reported {
+		previous, reported = lgc.Status.Nodes[c.config.NodeName]
+	}
+
+	matchedPods, selected := c.matchedOnNode(lgc)
+	if err == nil && !selected {
+		if !reported {
+			return
+		}
+		if err := c.patchNodeStatus(lgc, nil); err != nil {
+			log.Warn("remove status of logConfig %s failed: %v", key, err)
+			return
+		}
+		delete(c.nodeStatus, key)
+		return
+	}
+
+	status := newNodeStatus(previous, lgc.Generation, matchedPods, err, c.sinkRefError(lgc))
+	if reported && reflect.DeepEqual(previous, status) {
+		c.nodeStatus[key] = status
+		return
+	}
+	if err := c.patchNodeStatus(lgc, &status); err != nil {
+		log.Warn("update status of logConfig %s failed: %v", key, err)
+		return
+	}
+	c.nodeStatus[key] = status
+}
+
+// setPodError records the error of the pod applied with the LogConfig, the error is removed when nil
+func (c *Controller) setPodError(lgcKey string, podKey string, err error) {
+	errs, ok := c.podErrors[lgcKey]
+	if err == nil {
+		if ok {
+			delete(errs, podKey)
+			if len(errs) == 0 {
+				delete(c.podErrors, lgcKey)
+			}
+		}
+		return
+	}
+	if !ok {
+		errs = make(map[string]error)
+		c.podErrors[lgcKey] = errs
+	}
+	errs[podKey] = err
+}
+
+// removePodErrors removes the errors of the pod deleted, and returns the keys of the LogConfigs it failed with
+func (c *Controller) removePodErrors(podKey string) []string {
+	var lgcKeys []string
+	for lgcKey, errs := range c.podErrors {
+		if _, ok := errs[podKey]; ok {
+			c.setPodError(lgcKey, podKey, nil)
+			lgcKeys = append(lgcKeys, lgcKey)
+		}
+	}
+	return lgcKeys
+}
+
+// podsError combines the errors of the pods applied with the LogConfig, so one pod succeeded does not hide the others
+func (c *Controller) podsError(lgcKey string) error {
+	errs := c.podErrors[lgcKey]
+	if len(errs) == 0 {
+		return nil
+	}
+
+	podKeys := make([]string, 0, len(errs))
+	for podKey := range errs {
+		podKeys = append(podKeys, podKey)
+	}
+	sort.Strings(podKeys)
+	messages := make([]string, 0, len(podKeys))
+	for _, podKey := range podKeys {
+		messages = append(messages, fmt.Sprintf("pod %s: %v", podKey, errs[podKey]))
+	}
+	return &podsError{
+		first:   errs[podKeys[0]],
+		message: strings.Join(messages, "; "),
+	}
+}
+
+// podsError is reported with the reason of the error of the first pod
+type podsError struct {
+	first   error
+	message string
+}
+
+func (e *podsError) Error() string {
+	return e.message
+}
+
+func (e *podsError) Unwrap() error {
+	return e.first
+}
+
+func (c *Controller) matchedOnNode(lgc *logconfigv1beta1.LogConfig) (matchedPods int, selected bool) {
+	switch lgc.Spec.Selector.Type {
+	case logconfigv1beta1.SelectorTypePod:
+		// pods lister only contains the pods of this node
+		pods, _ := helper.GetLogConfigRelatedPod(lgc, c.podsLister, c.namespaceLister)
+		return len(pods), len(pods) > 0
+
+	case logconfigv1beta1.SelectorTypeNode:
+		nodeSelector := lgc.Spec.Selector.NodeSelector.NodeSelector
+		return 0, nodeSelector == nil || helper.LabelsSubset(nodeSelector, c.nodeLabels)
+	}
+	return 0, true
+}
+
+// sinkRefError returns ErrSinkNotFound when the sinkRef is absent, the LogConfig is applied with the default sink
+func (c *Controller) sinkRefError(lgc *logconfigv1beta1.LogConfig) error {
+	if lgc.Spec.Pipeline == nil || lgc.Spec.Pipeline.SinkRef == "" {
+		return nil
+	}
+	if _, err := c.sinkLister.Get(lgc.Spec.Pipeline.SinkRef); kerrors.IsNotFound(err) {
+		return errors.WithMessagef(helper.ErrSinkNotFound, "sinkRef %s, the default sink is used", lgc.Spec.Pipeline.SinkRef)
+	}
+	return nil
+}
+
+func newNodeStatus(previous logconfigv1beta1.NodeStatus, generation int64, matchedPods int, err error, sinkErr error) logconfigv1beta1.NodeStatus {
+	status := logconfigv1beta1.NodeStatus{
+		MatchedPods:       matchedPods,
+		AppliedGeneration: previous.AppliedGeneration,
+	}
+	condition := logconfigv1beta1.Condition{
+		Type:   logconfigv1beta1.ConditionTypeApplied,
+		Status: corev1.ConditionTrue,
+		Reason: logconfigv1beta1.ReasonApplied,
+	}
+	if err != nil {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = statusReason(err)
+		condition.Message = err.Error()
+	} else {
+		status.AppliedGeneration = generation
+	}
+
+	status.Conditions = []logconfigv1beta1.Condition{transit(previous, condition)}
+
+	if sinkErr != nil {
+		status.Conditions = append(status.Conditions, transit(previous, logconfigv1beta1.Condition{
+			Type:    logconfigv1beta1.ConditionTypeSinkResolved,
+			Status:  corev1.ConditionFalse,
+			Reason:  logconfigv1beta1.ReasonSinkNotFound,
+			Message: sinkErr.Error(),
+		}))
+	}
+	return status
+}
+
+// transit sets the transition time of the condition, which is kept when the condition status not changed
+func transit(previous logconfigv1beta1.NodeStatus, condition logconfigv1beta1.Condition) logconfigv1beta1.Condition {
+	condition.LastTransitionTime = metav1.Now()
+	for _, cond := range previous.Conditions {
+		if cond.Type == condition.Type && cond.Status == condition.Status {
+			condition.LastTransitionTime = cond.LastTransitionTime
+		}
+	}
+	return condition
+}
+
+func statusReason(err error) string {
+	switch {
+	case errors.Is(err, helper.ErrVolumeNotFound):
+		return logconfigv1beta1.ReasonVolumeNotFound
+	case errors.Is(err, errInvalidLogConfig):
+		return logconfigv1beta1.ReasonInvalidConfig
+	}
+	return logconfigv1beta1.ReasonApplyFailed
+}
+
+// patchNodeStatus merges the status of this node only, status is removed when nil
+func (c *Controller) patchNodeStatus(lgc *logconfigv1beta1.LogConfig, status *logconfigv1beta1.NodeStatus) error {
+	patch := map[string]interface{}{
+		"status": map[string]interface{}{
+			"nodes": map[string]interface{}{
+				c.config.NodeName: status,
+			},
+		},
+	}
+	data, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	if lgc.IsCluster() {
+		_, err = c.logConfigClientset.LoggieV1beta1().ClusterLogConfigs().Patch(context.Background(), lgc.Name,
+			types.MergePatchType, data, metav1.PatchOptions{}, "status")
+		return err
+	}
+	_, err = c.logConfigClientset.LoggieV1beta1().LogConfigs(lgc.Namespace).Patch(context.Background(), lgc.Name,
+		types.MergePatchType, data, metav1.PatchOptions{}, "status")
+	return err
+}
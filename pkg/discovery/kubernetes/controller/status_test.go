@@ -0,0 +1,101 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+
+	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
+	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/helper"
+)
+
+func TestNewNodeStatus(t *testing.T) {
+	applied := newNodeStatus(logconfigv1beta1.NodeStatus{}, 2, 3, nil, nil)
+	if applied.AppliedGeneration != 2 || applied.MatchedPods != 3 || applied.Conditions[0].Status != corev1.ConditionTrue {
+		t.Fatalf("unexpected status: %+v", applied)
+	}
+
+	// the generation applied is kept when failed
+	err := errors.WithMessagef(helper.ErrVolumeNotFound, "cannot find volume mounts by path: %s", "/var/log")
+	failed := newNodeStatus(applied, 3, 3, err, nil)
+	cond := failed.Conditions[0]
+	if failed.AppliedGeneration != 2 || cond.Status != corev1.ConditionFalse || cond.Reason != logconfigv1beta1.ReasonVolumeNotFound || cond.Message != err.Error() {
+		t.Fatalf("unexpected status: %+v", failed)
+	}
+
+	// transition time is kept when the condition status not changed
+	again := newNodeStatus(failed, 3, 3, err, nil)
+	if !again.Conditions[0].LastTransitionTime.Equal(&cond.LastTransitionTime) {
+		t.Errorf("transition time changed")
+	}
+
+	// the missing sinkRef is reported along with the applied condition
+	sinkErr := errors.WithMessagef(helper.ErrSinkNotFound, "sinkRef %s, the default sink is used", "kafka")
+	fallback := newNodeStatus(applied, 3, 3, nil, sinkErr)
+	if fallback.AppliedGeneration != 3 || len(fallback.Conditions) != 2 || fallback.Conditions[0].Status != corev1.ConditionTrue {
+		t.Fatalf("unexpected status: %+v", fallback)
+	}
+	if cond := fallback.Conditions[1]; cond.Type != logconfigv1beta1.ConditionTypeSinkResolved ||
+		cond.Status != corev1.ConditionFalse || cond.Reason != logconfigv1beta1.ReasonSinkNotFound {
+		t.Errorf("unexpected sink condition: %+v", cond)
+	}
+}
+
+func TestStatusReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{errors.WithMessage(errInvalidLogConfig, "spec.selector is required"), logconfigv1beta1.ReasonInvalidConfig},
+		{errors.New("path is empty"), logconfigv1beta1.ReasonApplyFailed},
+	}
+	for _, tt := range tests {
+		if got := statusReason(tt.err); got != tt.want {
+			t.Errorf("statusReason(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestPodsError(t *testing.T) {
+	c := &Controller{podErrors: make(map[string]map[string]error)}
+	volumeErr := errors.WithMessagef(helper.ErrVolumeNotFound, "cannot find volume mounts by path: %s", "/var/log")
+
+	// pod b succeeded does not hide the error of pod a
+	c.setPodError("default/lgc", "default/a", volumeErr)
+	c.setPodError("default/lgc", "default/b", nil)
+	err := c.podsError("default/lgc")
+	if err == nil || statusReason(err) != logconfigv1beta1.ReasonVolumeNotFound || err.Error() != "pod default/a: "+volumeErr.Error() {
+		t.Fatalf("unexpected error of pods: %v", err)
+	}
+
+	c.setPodError("default/lgc", "default/b", errors.New("path is empty"))
+	if err := c.podsError("default/lgc"); err.Error() != "pod default/a: "+volumeErr.Error()+"; pod default/b: path is empty" {
+		t.Fatalf("unexpected error of pods: %v", err)
+	}
+
+	// the errors are removed along with the pods deleted
+	if lgcKeys := c.removePodErrors("default/a"); len(lgcKeys) != 1 || lgcKeys[0] != "default/lgc" {
+		t.Fatalf("unexpected logConfigs of pod a: %v", lgcKeys)
+	}
+	c.removePodErrors("default/b")
+	if err := c.podsError("default/lgc"); err != nil || len(c.podErrors) != 0 {
+		t.Fatalf("errors of pods deleted are not removed: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package external
+
+import (
+	"sync"
+
+	corev1Listers "k8s.io/client-go/listers/core/v1"
+)
+
+// The informers of kubernetes discovery are shared with the components like sources,
+// they are only available when discovery is enabled.
+
+var (
+	lock      sync.RWMutex
+	podLister corev1Listers.PodLister
+)
+
+// SetPodLister is called by discovery, the pods listed are those on the node which loggie running on
+func SetPodLister(lister corev1Listers.PodLister) {
+	lock.Lock()
+	defer lock.Unlock()
+	podLister = lister
+}
+
+// PodLister returns false when kubernetes discovery is not enabled
+func PodLister() (corev1Listers.PodLister, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+	return podLister, podLister != nil
+}
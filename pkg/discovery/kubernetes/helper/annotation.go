@@ -0,0 +1,75 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"reflect"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Annotations on pods to collect logs without LogConfig, the container level annotations are prefixed with the
+// container name, eg: nginx.loggie.io/paths, which take precedence over the pod level ones.
+const (
+	// AnnotationPaths are the comma separated log paths in the container, or stdout
+	AnnotationPaths            = "loggie.io/paths"
+	AnnotationMultilinePattern = "loggie.io/multiline-pattern"
+	// AnnotationSinkRef is the Sink to send logs to, only the pod level annotation is used
+	AnnotationSinkRef = "loggie.io/sink-ref"
+	// AnnotationExclude is "true" to exclude the pod or container from annotation collection
+	AnnotationExclude = "loggie.io/exclude"
+
+	annotationDomain = "loggie.io/"
+)
+
+// IsAnnotationsChanged returns true if any of the pod or container level annotations of loggie changed
+func IsAnnotationsChanged(oldPod *corev1.Pod, newPod *corev1.Pod) bool {
+	loggieAnnotations := func(pod *corev1.Pod) map[string]string {
+		annotations := make(map[string]string)
+		for k, v := range pod.Annotations {
+			if strings.Contains(k, annotationDomain) {
+				annotations[k] = v
+			}
+		}
+		return annotations
+	}
+	return !reflect.DeepEqual(loggieAnnotations(oldPod), loggieAnnotations(newPod))
+}
+
+// GetContainerAnnotation returns the annotation of the container, and falls back to the pod level annotation
+func GetContainerAnnotation(pod *corev1.Pod, containerName string, key string) string {
+	if val, ok := pod.Annotations[containerName+"."+key]; ok {
+		return strings.TrimSpace(val)
+	}
+	return strings.TrimSpace(pod.Annotations[key])
+}
+
+// GetAnnotationPaths returns the paths of the container set by annotations
+func GetAnnotationPaths(pod *corev1.Pod, containerName string) []string {
+	var paths []string
+	for _, p := range strings.Split(GetContainerAnnotation(pod, containerName, AnnotationPaths), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+func IsAnnotationExcluded(pod *corev1.Pod, containerName string) bool {
+	return GetContainerAnnotation(pod, containerName, AnnotationExclude) == "true"
+}
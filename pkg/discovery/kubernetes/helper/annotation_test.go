@@ -0,0 +1,69 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsAnnotationsChanged(t *testing.T) {
+	pod := func(annotations map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default", Annotations: annotations}}
+	}
+
+	tests := []struct {
+		name   string
+		old    map[string]string
+		new    map[string]string
+		change bool
+	}{
+		{
+			name:   "other annotations",
+			old:    map[string]string{AnnotationPaths: "stdout", "app": "v1"},
+			new:    map[string]string{AnnotationPaths: "stdout", "app": "v2"},
+			change: false,
+		},
+		{
+			name:   "paths added",
+			old:    nil,
+			new:    map[string]string{AnnotationPaths: "stdout"},
+			change: true,
+		},
+		{
+			name:   "container level changed",
+			old:    map[string]string{"nginx." + AnnotationExclude: "false"},
+			new:    map[string]string{"nginx." + AnnotationExclude: "true"},
+			change: true,
+		},
+		{
+			name:   "sinkRef removed",
+			old:    map[string]string{AnnotationSinkRef: "kafka"},
+			new:    map[string]string{},
+			change: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsAnnotationsChanged(pod(tt.old), pod(tt.new)); got != tt.change {
+				t.Errorf("IsAnnotationsChanged() = %v, want %v", got, tt.change)
+			}
+		})
+	}
+}
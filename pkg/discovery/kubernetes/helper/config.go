@@ -23,6 +23,7 @@ import (
 	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
 	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/client/listers/loggie/v1beta1"
 	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/pkg/errors"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
@@ -33,7 +34,7 @@ func ToPipeline(lgc *logconfigv1beta1.LogConfig, sinkLister v1beta1.SinkLister,
 	pip := lgc.Spec.Pipeline
 
 	pipRaw := pipeline.ConfigRaw{}
-	pipRaw.Name = fmt.Sprintf("%s/%s/%s", lgc.Namespace, lgc.Name, pip.Name)
+	pipRaw.Name = fmt.Sprintf("%s/%s", MetaNamespaceKey(lgc.Namespace, lgc.Name), pip.Name)
 
 	src, err := ToPipelineSources(pip.Sources)
 	if err != nil {
@@ -69,6 +70,10 @@ func ToPipelineSources(sources string) ([]cfg.CommonCfg, error) {
 	return sourceCfg, nil
 }
 
+// ErrSinkNotFound is reported in the status of LogConfig when the sinkRef is absent
+var ErrSinkNotFound = errors.New("sink not found")
+
+// ToPipelineSink returns nil when the sink of sinkRef is not found, so that the default sink is used
 func ToPipelineSink(sinkRef string, sinkLister v1beta1.SinkLister) (cfg.CommonCfg, error) {
 	lgcSink, err := sinkLister.Get(sinkRef)
 	if err != nil {
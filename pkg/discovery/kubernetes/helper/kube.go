@@ -22,6 +22,7 @@ import (
 	"github.com/loggie-io/loggie/pkg/core/log"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"path/filepath"
@@ -32,6 +33,8 @@ import (
 	corev1listers "k8s.io/client-go/listers/core/v1"
 )
 
+var ErrVolumeNotFound = errors.New("cannot find volume")
+
 func IsPodReady(pod *corev1.Pod) bool {
 	if pod.Status.ContainerStatuses == nil || len(pod.Status.ContainerStatuses) <= 0 {
 		log.Debug("pod %s containerID is null, not ready", pod.Name)
@@ -53,30 +56,55 @@ func MetaNamespaceKey(namespace string, name string) string {
 	return name
 }
 
-func GetLogConfigRelatedPod(lgc *logconfigv1beta1.LogConfig, podsLister corev1listers.PodLister) ([]*corev1.Pod, error) {
+func GetLogConfigRelatedPod(lgc *logconfigv1beta1.LogConfig, podsLister corev1listers.PodLister, nsLister corev1listers.NamespaceLister) ([]*corev1.Pod, error) {
 
-	labelSelector := lgc.Spec.Selector.PodSelector.LabelSelector
+	podSelector := &lgc.Spec.Selector.PodSelector
 	namespace := lgc.Namespace
 
-	if len(labelSelector) == 0 {
+	if !podSelector.HasLabelSelector() && !(lgc.IsCluster() && lgc.Spec.Selector.HasNamespaceSelector()) {
 		return nil, errors.New("cannot find labelSelector pods, label is null")
 	}
 
-	ret, err := podsLister.Pods(namespace).List(labels.SelectorFromSet(labelSelector))
+	selector, err := metav1.LabelSelectorAsSelector(podSelector.LabelSelectorAsMeta())
+	if err != nil {
+		return nil, err
+	}
+
+	// namespace of ClusterLogConfig is empty, so pods in all namespaces are listed
+	pods, err := podsLister.Pods(namespace).List(selector)
 	if err != nil {
-		log.Info("%s/%s cannot find pod by labelSelector %#v: %s", namespace, lgc.Name, labelSelector, err.Error())
+		log.Info("%s/%s cannot find pod by labelSelector %s: %s", namespace, lgc.Name, selector.String(), err.Error())
 		return nil, nil
 	}
+	if !lgc.IsCluster() {
+		return pods, nil
+	}
 
+	ret := make([]*corev1.Pod, 0)
+	for _, pod := range pods {
+		if namespaceMatched(&lgc.Spec.Selector.NamespaceSelector, pod.Namespace, nsLister) {
+			ret = append(ret, pod)
+		}
+	}
 	return ret, nil
 }
 
 // TODO optimize the performance
-func GetPodRelatedLogConfigs(pod *corev1.Pod, lgcLister logconfigLister.LogConfigLister) ([]*logconfigv1beta1.LogConfig, error) {
-	lgcList, err := lgcLister.List(labels.Everything())
+func GetPodRelatedLogConfigs(pod *corev1.Pod, lgcLister logconfigLister.LogConfigLister,
+	clusterLgcLister logconfigLister.ClusterLogConfigLister, nsLister corev1listers.NamespaceLister) ([]*logconfigv1beta1.LogConfig, error) {
+	lgcList, err := lgcLister.LogConfigs(pod.Namespace).List(labels.Everything())
 	if err != nil {
 		return nil, err
 	}
+	if clusterLgcLister != nil {
+		clusterLgcList, err := clusterLgcLister.List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		for _, clgc := range clusterLgcList {
+			lgcList = append(lgcList, clgc.ToLogConfig())
+		}
+	}
 
 	ret := make([]*logconfigv1beta1.LogConfig, 0)
 	for _, lgc := range lgcList {
@@ -84,13 +112,52 @@ func GetPodRelatedLogConfigs(pod *corev1.Pod, lgcLister logconfigLister.LogConfi
 			continue
 		}
 
-		if LabelsSubset(lgc.Spec.Selector.LabelSelector, pod.Labels) {
+		if PodMatched(lgc, pod, nsLister) {
 			ret = append(ret, lgc)
 		}
 	}
 	return ret, nil
 }
 
+// PodMatched checks if the pod is selected by the labelSelector, matchExpressions and namespace selection of lgc
+func PodMatched(lgc *logconfigv1beta1.LogConfig, pod *corev1.Pod, nsLister corev1listers.NamespaceLister) bool {
+	podSelector := &lgc.Spec.Selector.PodSelector
+	if !lgc.IsCluster() {
+		if !podSelector.HasLabelSelector() || pod.Namespace != lgc.Namespace {
+			return false
+		}
+	} else if !podSelector.HasLabelSelector() && !lgc.Spec.Selector.HasNamespaceSelector() {
+		return false
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(podSelector.LabelSelectorAsMeta())
+	if err != nil || !selector.Matches(labels.Set(pod.Labels)) {
+		return false
+	}
+
+	if lgc.IsCluster() {
+		return namespaceMatched(&lgc.Spec.Selector.NamespaceSelector, pod.Namespace, nsLister)
+	}
+	return true
+}
+
+func namespaceMatched(selector *logconfigv1beta1.NamespaceSelector, namespace string, nsLister corev1listers.NamespaceLister) bool {
+	if len(selector.NamespaceSelector) == 0 {
+		return selector.MatchNamespace(namespace, nil)
+	}
+
+	if nsLister == nil {
+		log.Warn("namespace informer is disabled, cannot match namespace %s by labels", namespace)
+		return false
+	}
+	ns, err := nsLister.Get(namespace)
+	if err != nil {
+		log.Debug("cannot get namespace %s: %v", namespace, err)
+		return false
+	}
+	return selector.MatchNamespace(namespace, ns.Labels)
+}
+
 // LabelsSubset checks if i is subset of j
 func LabelsSubset(i map[string]string, j map[string]string) bool {
 	if i == nil || j == nil {
@@ -157,7 +224,7 @@ func findVolumeMountsByPaths(path string, pod *corev1.Pod, containerName string)
 			}
 		}
 	}
-	return "", "", "", errors.Errorf("cannot find volume mounts by path: %s", path)
+	return "", "", "", errors.WithMessagef(ErrVolumeNotFound, "cannot find volume mounts by path: %s", path)
 }
 
 func getEnvInPod(pod *corev1.Pod, containerName string) []corev1.EnvVar {
@@ -339,7 +406,7 @@ func nodePathByContainerPath(pathPattern string, pod *corev1.Pod, volumeName str
 		// unsupported volume type
 		return "", errors.Errorf("unsupported volume type of pod %s/%s", pod.Namespace, pod.Name)
 	}
-	return "", errors.Errorf("cannot find match log volume by path: %s", pathPattern)
+	return "", errors.WithMessagef(ErrVolumeNotFound, "cannot find match log volume by path: %s", pathPattern)
 }
 
 // eg: @pathPattern=/var/log/test/*.log;  @volumeMountPath=/var/log; @volume=/data/log/var/log
@@ -0,0 +1,119 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestPodMatched(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	_ = indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"team": "a"}}})
+	_ = indexer.Add(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}})
+	nsLister := corev1listers.NewNamespaceLister(indexer)
+
+	pod := func(namespace string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: namespace, Labels: labels}}
+	}
+	logConfig := func(namespace string, selector logconfigv1beta1.Selector) *logconfigv1beta1.LogConfig {
+		selector.Type = logconfigv1beta1.SelectorTypePod
+		return &logconfigv1beta1.LogConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "lgc", Namespace: namespace},
+			Spec:       logconfigv1beta1.LogConfigSpec{Selector: &selector},
+		}
+	}
+	clusterLogConfig := func(selector logconfigv1beta1.Selector) *logconfigv1beta1.LogConfig {
+		selector.Type = logconfigv1beta1.SelectorTypePod
+		clgc := &logconfigv1beta1.ClusterLogConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "clgc"},
+			Spec:       logconfigv1beta1.LogConfigSpec{Selector: &selector},
+		}
+		return clgc.ToLogConfig()
+	}
+
+	tests := []struct {
+		name string
+		lgc  *logconfigv1beta1.LogConfig
+		pod  *corev1.Pod
+		want bool
+	}{
+		{
+			name: "labelSelector",
+			lgc: logConfig("team-a", logconfigv1beta1.Selector{
+				PodSelector: logconfigv1beta1.PodSelector{LabelSelector: map[string]string{"app": "nginx"}},
+			}),
+			pod:  pod("team-a", map[string]string{"app": "nginx"}),
+			want: true,
+		},
+		{
+			name: "other namespace",
+			lgc: logConfig("team-a", logconfigv1beta1.Selector{
+				PodSelector: logconfigv1beta1.PodSelector{LabelSelector: map[string]string{"app": "nginx"}},
+			}),
+			pod:  pod("team-b", map[string]string{"app": "nginx"}),
+			want: false,
+		},
+		{
+			name: "matchExpressions",
+			lgc: logConfig("team-a", logconfigv1beta1.Selector{
+				PodSelector: logconfigv1beta1.PodSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "app", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"nginx"}},
+				}},
+			}),
+			pod:  pod("team-a", map[string]string{"app": "nginx"}),
+			want: false,
+		},
+		{
+			name: "cluster namespaceSelector",
+			lgc: clusterLogConfig(logconfigv1beta1.Selector{
+				NamespaceSelector: logconfigv1beta1.NamespaceSelector{NamespaceSelector: map[string]string{"team": "a"}},
+			}),
+			pod:  pod("team-a", nil),
+			want: true,
+		},
+		{
+			name: "cluster namespaceSelector not matched",
+			lgc: clusterLogConfig(logconfigv1beta1.Selector{
+				NamespaceSelector: logconfigv1beta1.NamespaceSelector{NamespaceSelector: map[string]string{"team": "a"}},
+			}),
+			pod:  pod("kube-system", nil),
+			want: false,
+		},
+		{
+			name: "cluster excludeNamespaces",
+			lgc: clusterLogConfig(logconfigv1beta1.Selector{
+				PodSelector:       logconfigv1beta1.PodSelector{LabelSelector: map[string]string{"app": "nginx"}},
+				NamespaceSelector: logconfigv1beta1.NamespaceSelector{ExcludeNamespaces: []string{"kube-system"}},
+			}),
+			pod:  pod("kube-system", map[string]string{"app": "nginx"}),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PodMatched(tt.lgc, tt.pod, nsLister); got != tt.want {
+				t.Errorf("PodMatched() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
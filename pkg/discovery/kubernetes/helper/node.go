@@ -0,0 +1,145 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+	"github.com/loggie-io/loggie/pkg/source/file"
+)
+
+const (
+	// SourcePreset is the key of sources to use a built-in preset when selector.type=node
+	SourcePreset = "preset"
+
+	PresetKubelet = "kubelet"
+	PresetRuntime = "runtime"
+	PresetAudit   = "audit"
+
+	varNodeName        = "node.name"
+	varNodeLabelPrefix = "node.labels."
+)
+
+// nodePresets are the system logs of the nodes writing logs to files instead of journald
+var nodePresets = map[string][]string{
+	PresetKubelet: {"/var/log/kubelet.log", "/var/log/kubelet/*.log"},
+	PresetRuntime: {"/var/log/docker.log", "/var/log/containerd.log", "/var/log/crio.log"},
+	PresetAudit:   {"/var/log/kubernetes/audit/*.log", "/var/log/kube-apiserver-audit.log"},
+}
+
+var nodeVarRegex = regexp.MustCompile(`\$\{(node\.[^}]+)\}`)
+
+// ApplyNodePreset fills the type, name and paths of the preset in the source when absent, and removes the preset key
+func ApplyNodePreset(src cfg.CommonCfg) (cfg.CommonCfg, error) {
+	preset, ok := src[SourcePreset]
+	if !ok {
+		return src, nil
+	}
+	delete(src, SourcePreset)
+
+	name, _ := preset.(string)
+	paths, ok := nodePresets[name]
+	if !ok {
+		return nil, errors.Errorf("preset %v is not supported", preset)
+	}
+
+	defaults := cfg.CommonCfg{
+		"type":  file.Type,
+		"name":  name,
+		"paths": append([]string(nil), paths...),
+	}
+	return cfg.MergeCommonCfg(src, defaults, false), nil
+}
+
+// RenderNodeVars replaces ${node.name} and ${node.labels.<key>} in the strings of value, the other variables are kept
+// as they may be rendered by the components at runtime
+func RenderNodeVars(value interface{}, nodeName string, nodeLabels map[string]string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return renderNodeVars(v, nodeName, nodeLabels)
+
+	case []string:
+		ret := make([]string, 0, len(v))
+		for _, s := range v {
+			r, err := renderNodeVars(s, nodeName, nodeLabels)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, r)
+		}
+		return ret, nil
+
+	case []interface{}:
+		ret := make([]interface{}, 0, len(v))
+		for _, e := range v {
+			r, err := RenderNodeVars(e, nodeName, nodeLabels)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, r)
+		}
+		return ret, nil
+
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			r, err := RenderNodeVars(e, nodeName, nodeLabels)
+			if err != nil {
+				return nil, err
+			}
+			ret[k] = r
+		}
+		return ret, nil
+
+	case map[interface{}]interface{}:
+		ret := make(map[interface{}]interface{}, len(v))
+		for k, e := range v {
+			r, err := RenderNodeVars(e, nodeName, nodeLabels)
+			if err != nil {
+				return nil, err
+			}
+			ret[k] = r
+		}
+		return ret, nil
+	}
+	return value, nil
+}
+
+func renderNodeVars(s string, nodeName string, nodeLabels map[string]string) (string, error) {
+	var err error
+	ret := nodeVarRegex.ReplaceAllStringFunc(s, func(match string) string {
+		name := nodeVarRegex.FindStringSubmatch(match)[1]
+		if name == varNodeName {
+			return nodeName
+		}
+		if strings.HasPrefix(name, varNodeLabelPrefix) {
+			key := strings.TrimPrefix(name, varNodeLabelPrefix)
+			if v, ok := nodeLabels[key]; ok {
+				return v
+			}
+			err = errors.Errorf("label %s of node %s is not found", key, nodeName)
+			return match
+		}
+		err = errors.Errorf("variable %s is not supported", match)
+		return match
+	})
+	return ret, err
+}
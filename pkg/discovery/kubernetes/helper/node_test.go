@@ -0,0 +1,89 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+)
+
+func TestApplyNodePreset(t *testing.T) {
+	src, err := ApplyNodePreset(cfg.CommonCfg{"preset": PresetKubelet, "name": "kubelet-log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := cfg.CommonCfg{
+		"type":  "file",
+		"name":  "kubelet-log",
+		"paths": []string{"/var/log/kubelet.log", "/var/log/kubelet/*.log"},
+	}
+	if !reflect.DeepEqual(src, want) {
+		t.Errorf("ApplyNodePreset() = %v, want %v", src, want)
+	}
+
+	if _, err := ApplyNodePreset(cfg.CommonCfg{"preset": "unknown"}); err == nil {
+		t.Errorf("ApplyNodePreset() with unknown preset, want error")
+	}
+}
+
+func TestRenderNodeVars(t *testing.T) {
+	labels := map[string]string{
+		"zone":                        "east",
+		"topology.kubernetes.io/zone": "east-1",
+	}
+
+	tests := []struct {
+		name    string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name:  "paths",
+			value: []interface{}{"/var/log/${node.name}/*.log", "/data/${node.labels.zone}/${node.labels.topology.kubernetes.io/zone}.log"},
+			want:  []interface{}{"/var/log/node-1/*.log", "/data/east/east-1.log"},
+		},
+		{
+			name:  "fields",
+			value: map[interface{}]interface{}{"zone": "${node.labels.zone}", "index": "${_env.INDEX}", "n": 1},
+			want:  map[interface{}]interface{}{"zone": "east", "index": "${_env.INDEX}", "n": 1},
+		},
+		{
+			name:    "label absent",
+			value:   "${node.labels.rack}",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported",
+			value:   "${node.ip}",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderNodeVars(tt.value, "node-1", labels)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RenderNodeVars() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RenderNodeVars() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseContainerID parses the containerID in pod status, eg: docker://<id>, containerd://<id>, cri-o://<id>
+func ParseContainerID(containerID string) (runtime string, id string, err error) {
+	res := strings.SplitN(containerID, "://", 2)
+	if len(res) < 2 || res[1] == "" {
+		return "", "", errors.Errorf("parse containerId in pod status err, status.ContainerID: %s", containerID)
+	}
+	return res[0], res[1], nil
+}
+
+// DockerRootfs returns the upperdir of the container with overlay2 storage driver, which contains the files written
+// by the container
+func DockerRootfs(dockerDataRoot string, containerId string) (string, error) {
+	mountIdFile := filepath.Join(dockerDataRoot, "image", "overlay2", "layerdb", "mounts", containerId, "mount-id")
+	mountId, err := ioutil.ReadFile(mountIdFile)
+	if err != nil {
+		return "", errors.WithMessagef(err, "read docker mount-id of container %s", containerId)
+	}
+	return filepath.Join(dockerDataRoot, "overlay2", strings.TrimSpace(string(mountId)), "diff"), nil
+}
+
+// CrioRootfs returns the upperdir of the container in containers/storage used by CRI-O
+func CrioRootfs(storageRoot string, containerId string) (string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(storageRoot, "overlay-containers", "containers.json"))
+	if err != nil {
+		return "", errors.WithMessage(err, "read containers of cri-o storage")
+	}
+
+	var containers []struct {
+		ID    string `json:"id"`
+		Layer string `json:"layer"`
+	}
+	if err := json.Unmarshal(content, &containers); err != nil {
+		return "", errors.WithMessage(err, "unmarshal containers of cri-o storage")
+	}
+	for _, c := range containers {
+		if c.ID == containerId {
+			return filepath.Join(storageRoot, "overlay", c.Layer, "diff"), nil
+		}
+	}
+	return "", errors.Errorf("container %s is not found in cri-o storage", containerId)
+}
+
+// ContainerdRootfs returns the rootfs mounted by containerd, the mount propagation of the state dir in loggie should
+// be HostToContainer to see it
+func ContainerdRootfs(containerdStateDir string, containerId string) string {
+	return filepath.Join(containerdStateDir, "io.containerd.runtime.v2.task", "k8s.io", containerId, "rootfs")
+}
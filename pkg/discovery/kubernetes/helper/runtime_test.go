@@ -0,0 +1,87 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseContainerID(t *testing.T) {
+	runtime, id, err := ParseContainerID("cri-o://6d0b6b2d4a6b")
+	if err != nil || runtime != "cri-o" || id != "6d0b6b2d4a6b" {
+		t.Errorf("unexpected result: %s, %s, %v", runtime, id, err)
+	}
+
+	if _, _, err := ParseContainerID("6d0b6b2d4a6b"); err == nil {
+		t.Errorf("want error when runtime prefix is absent")
+	}
+}
+
+func TestDockerRootfs(t *testing.T) {
+	root, err := ioutil.TempDir("", "docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	mountDir := filepath.Join(root, "image", "overlay2", "layerdb", "mounts", "abc")
+	if err := os.MkdirAll(mountDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(mountDir, "mount-id"), []byte("f1e2d3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootfs, err := DockerRootfs(root, "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(root, "overlay2", "f1e2d3", "diff"); rootfs != want {
+		t.Errorf("DockerRootfs() = %s, want %s", rootfs, want)
+	}
+}
+
+func TestCrioRootfs(t *testing.T) {
+	root, err := ioutil.TempDir("", "crio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	if err := os.MkdirAll(filepath.Join(root, "overlay-containers"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	containers := `[{"id":"abc","names":["k8s_app"],"layer":"l1"},{"id":"def","layer":"l2"}]`
+	if err := ioutil.WriteFile(filepath.Join(root, "overlay-containers", "containers.json"), []byte(containers), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rootfs, err := CrioRootfs(root, "def")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(root, "overlay", "l2", "diff"); rootfs != want {
+		t.Errorf("CrioRootfs() = %s, want %s", rootfs, want)
+	}
+
+	if _, err := CrioRootfs(root, "xyz"); err == nil {
+		t.Errorf("want error when container not found")
+	}
+}
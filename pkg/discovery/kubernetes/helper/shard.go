@@ -0,0 +1,110 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"hash/fnv"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/source/grpc"
+	"github.com/loggie-io/loggie/pkg/source/kafka"
+	"github.com/loggie-io/loggie/pkg/source/tcp"
+	"github.com/loggie-io/loggie/pkg/source/udp"
+)
+
+// unshardableSources run on every aggregator: kafka is partitioned by the consumer group, and the push based
+// sources receive the data sent to any replica behind the Service, so running them on one replica drops the rest.
+var unshardableSources = map[string]struct{}{
+	kafka.Type: {},
+	grpc.Type:  {},
+	tcp.Type:   {},
+	udp.Type:   {},
+}
+
+// GetShardMembers returns the sorted names of the ready pods in the endpoints of aggregator Service
+func GetShardMembers(endpoints *corev1.Endpoints) []string {
+	if endpoints == nil {
+		return nil
+	}
+
+	var members []string
+	for _, subset := range endpoints.Subsets {
+		for _, addr := range subset.Addresses {
+			name := addr.Hostname
+			if addr.TargetRef != nil && addr.TargetRef.Kind == "Pod" {
+				name = addr.TargetRef.Name
+			}
+			if name == "" {
+				name = addr.IP
+			}
+			members = append(members, name)
+		}
+	}
+	sort.Strings(members)
+
+	// the same pod may be in several subsets with different ports
+	uniq := members[:0]
+	for i, m := range members {
+		if i > 0 && m == members[i-1] {
+			continue
+		}
+		uniq = append(uniq, m)
+	}
+	return uniq
+}
+
+// GetShardOwner assigns the key to one of the members by rendezvous hashing, so only the keys of the member joined
+// or left are moved. Empty is returned when there are no members.
+func GetShardOwner(members []string, key string) string {
+	var owner string
+	var max uint64
+	for _, m := range members {
+		h := fnv.New64a()
+		h.Write([]byte(m))
+		h.Write([]byte{0})
+		h.Write([]byte(key))
+		if sum := mix64(h.Sum64()); owner == "" || sum > max {
+			owner, max = m, sum
+		}
+	}
+	return owner
+}
+
+// IsShardable returns true if any of the sources of pipeline pulls the data by itself, otherwise the pipeline is
+// supposed to run on every aggregator.
+func IsShardable(p pipeline.ConfigRaw) bool {
+	for _, src := range p.Sources {
+		typ, _ := src["type"].(string)
+		if _, ok := unshardableSources[typ]; !ok {
+			return true
+		}
+	}
+	return len(p.Sources) == 0
+}
+
+// mix64 is the finalizer of murmur3, the higher bits of fnv are not mixed well enough to be compared
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
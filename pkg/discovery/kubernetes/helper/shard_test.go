@@ -0,0 +1,119 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+func TestGetShardMembers(t *testing.T) {
+	podAddr := func(name string) corev1.EndpointAddress {
+		return corev1.EndpointAddress{IP: "10.0.0.1", TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: name}}
+	}
+	endpoints := &corev1.Endpoints{
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses:         []corev1.EndpointAddress{podAddr("aggregator-1"), podAddr("aggregator-0")},
+				NotReadyAddresses: []corev1.EndpointAddress{podAddr("aggregator-2")},
+			},
+			{
+				Addresses: []corev1.EndpointAddress{podAddr("aggregator-0"), {IP: "10.0.0.9"}},
+			},
+		},
+	}
+
+	want := []string{"10.0.0.9", "aggregator-0", "aggregator-1"}
+	if got := GetShardMembers(endpoints); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetShardMembers() = %v, want %v", got, want)
+	}
+}
+
+func TestGetShardOwner(t *testing.T) {
+	if got := GetShardOwner(nil, "p"); got != "" {
+		t.Errorf("GetShardOwner() with no members = %s, want empty", got)
+	}
+
+	members := []string{"a-0", "a-1", "a-2"}
+	owners := make(map[string]string)
+	count := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		key := fmt.Sprintf("pipeline-%d", i)
+		owners[key] = GetShardOwner(members, key)
+		count[owners[key]]++
+	}
+	for _, m := range members {
+		if count[m] < 50 {
+			t.Errorf("member %s owns %d of 300 keys, which are not balanced", m, count[m])
+		}
+	}
+
+	// only the keys of the member left are moved
+	for key, owner := range owners {
+		got := GetShardOwner(members[:2], key)
+		if owner != "a-2" && got != owner {
+			t.Errorf("key %s is moved from %s to %s", key, owner, got)
+		}
+	}
+}
+
+func TestIsShardable(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []cfg.CommonCfg
+		want    bool
+	}{
+		{
+			name:    "file",
+			sources: []cfg.CommonCfg{{"type": "file"}},
+			want:    true,
+		},
+		{
+			name:    "kafka",
+			sources: []cfg.CommonCfg{{"type": "kafka"}, {"type": "kafka"}},
+			want:    false,
+		},
+		{
+			name:    "push",
+			sources: []cfg.CommonCfg{{"type": "grpc"}, {"type": "tcp"}, {"type": "udp"}},
+			want:    false,
+		},
+		{
+			name:    "mixed",
+			sources: []cfg.CommonCfg{{"type": "kafka"}, {"type": "grpc"}},
+			want:    false,
+		},
+		{
+			name:    "with pull source",
+			sources: []cfg.CommonCfg{{"type": "grpc"}, {"type": "redis"}},
+			want:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsShardable(pipeline.ConfigRaw{Sources: tt.sources}); got != tt.want {
+				t.Errorf("IsShardable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batchv1listers "k8s.io/client-go/listers/batch/v1"
+)
+
+const (
+	KindReplicaSet = "ReplicaSet"
+	KindDeployment = "Deployment"
+	KindJob        = "Job"
+	KindCronJob    = "CronJob"
+)
+
+// Workload is the top level controller owning the pod, eg: Deployment, StatefulSet, DaemonSet, CronJob
+type Workload struct {
+	Kind string
+	Name string
+}
+
+// GetPodWorkload resolves the workload of the pod through the owner references. The Deployment is resolved from the
+// name of ReplicaSet, so the ReplicaSets of the cluster are not watched, and Jobs are got from the lister to find
+// their CronJobs. The lister may be nil, then the Job is returned.
+func GetPodWorkload(pod *corev1.Pod, jobLister batchv1listers.JobLister) Workload {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return Workload{}
+	}
+
+	switch owner.Kind {
+	case KindReplicaSet:
+		// the name of ReplicaSet created by Deployment is <deployment>-<pod-template-hash>
+		if hash, ok := pod.Labels["pod-template-hash"]; ok && strings.HasSuffix(owner.Name, "-"+hash) {
+			return Workload{Kind: KindDeployment, Name: strings.TrimSuffix(owner.Name, "-"+hash)}
+		}
+
+	case KindJob:
+		if jobLister != nil {
+			if job, err := jobLister.Jobs(pod.Namespace).Get(owner.Name); err == nil {
+				return ownerOf(job, owner)
+			}
+		}
+	}
+
+	return Workload{Kind: owner.Kind, Name: owner.Name}
+}
+
+func ownerOf(obj metav1.Object, ref *metav1.OwnerReference) Workload {
+	if owner := metav1.GetControllerOf(obj); owner != nil {
+		return Workload{Kind: owner.Kind, Name: owner.Name}
+	}
+	return Workload{Kind: ref.Kind, Name: ref.Name}
+}
+
+// SplitImage splits the image to repository and tag, tag is empty when absent, eg: nginx -> (nginx, "")
+func SplitImage(image string) (repository string, tag string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		image = image[:i]
+	}
+	i := strings.LastIndex(image, ":")
+	// the colon may belong to the port of registry, eg: registry:5000/nginx
+	if i < 0 || strings.Contains(image[i+1:], "/") {
+		return image, ""
+	}
+	return image[:i], image[i+1:]
+}
+
+func GetContainerImage(pod *corev1.Pod, containerName string) string {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == containerName {
+			return c.Image
+		}
+	}
+	return ""
+}
+
+func GetMatchedNodeLabel(labelKeys []string, nodeLabels map[string]string) map[string]string {
+	matchedLabelMap := map[string]string{}
+
+	for _, key := range labelKeys {
+		matchedLabelMap[key] = nodeLabels[key]
+	}
+	return matchedLabelMap
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package helper
+
+import (
+	"testing"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	batchv1listers "k8s.io/client-go/listers/batch/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func controllerRef(kind string, name string) []metav1.OwnerReference {
+	isController := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &isController}}
+}
+
+func TestGetPodWorkload(t *testing.T) {
+	jobIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	_ = jobIndexer.Add(&batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+		Name: "backup-27311040", Namespace: "default", OwnerReferences: controllerRef(KindCronJob, "backup"),
+	}})
+	jobLister := batchv1listers.NewJobLister(jobIndexer)
+
+	pod := func(owners []metav1.OwnerReference, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default", OwnerReferences: owners, Labels: labels}}
+	}
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want Workload
+	}{
+		{
+			name: "deployment",
+			pod:  pod(controllerRef(KindReplicaSet, "tomcat-7b5b7d8f9c"), map[string]string{"pod-template-hash": "7b5b7d8f9c"}),
+			want: Workload{Kind: KindDeployment, Name: "tomcat"},
+		},
+		{
+			name: "replicaSet",
+			pod:  pod(controllerRef(KindReplicaSet, "nginx"), nil),
+			want: Workload{Kind: KindReplicaSet, Name: "nginx"},
+		},
+		{
+			name: "job not in cache",
+			pod:  pod(controllerRef(KindJob, "backup-27311041"), nil),
+			want: Workload{Kind: KindJob, Name: "backup-27311041"},
+		},
+		{
+			name: "cronJob",
+			pod:  pod(controllerRef(KindJob, "backup-27311040"), nil),
+			want: Workload{Kind: KindCronJob, Name: "backup"},
+		},
+		{
+			name: "statefulSet",
+			pod:  pod(controllerRef("StatefulSet", "mysql"), nil),
+			want: Workload{Kind: "StatefulSet", Name: "mysql"},
+		},
+		{
+			name: "no owner",
+			pod:  pod(nil, nil),
+			want: Workload{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetPodWorkload(tt.pod, jobLister); got != tt.want {
+				t.Errorf("GetPodWorkload() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitImage(t *testing.T) {
+	tests := []struct {
+		image      string
+		repository string
+		tag        string
+	}{
+		{"nginx", "nginx", ""},
+		{"nginx:1.21", "nginx", "1.21"},
+		{"registry:5000/app/nginx", "registry:5000/app/nginx", ""},
+		{"registry:5000/app/nginx:v1@sha256:abc", "registry:5000/app/nginx", "v1"},
+	}
+	for _, tt := range tests {
+		repository, tag := SplitImage(tt.image)
+		if repository != tt.repository || tag != tt.tag {
+			t.Errorf("SplitImage(%s) = (%s, %s), want (%s, %s)", tt.image, repository, tag, tt.repository, tt.tag)
+		}
+	}
+}
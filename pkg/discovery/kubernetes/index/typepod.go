@@ -40,9 +40,8 @@ func NewLogConfigTypePodIndex() *LogConfigTypePodIndex {
 	}
 }
 
-func (p *LogConfigTypePodIndex) GetPipeConfigs(namespace string, podName string, lgcName string) *pipeline.ConfigRaw {
+func (p *LogConfigTypePodIndex) GetPipeConfigs(namespace string, podName string, lgcKey string) *pipeline.ConfigRaw {
 	podKey := helper.MetaNamespaceKey(namespace, podName)
-	lgcKey := helper.MetaNamespaceKey(namespace, lgcName)
 	podAndLgc := helper.MetaNamespaceKey(podKey, lgcKey)
 	cfgs, ok := p.pipeConfigs[podAndLgc]
 	if !ok {
@@ -62,6 +61,24 @@ func (p *LogConfigTypePodIndex) IsPodExist(namespace string, podName string) boo
 	return true
 }
 
+// GetLogConfigKeysByPod returns the keys(namespace/lgcName) of logConfigs related to the pod
+func (p *LogConfigTypePodIndex) GetLogConfigKeysByPod(podKey string) []string {
+	lgcSets, ok := p.podToLgcSets[podKey]
+	if !ok {
+		return nil
+	}
+	return lgcSets.List()
+}
+
+// GetPodKeysByLogConfig returns the keys(namespace/podName) of pods related to the logConfig
+func (p *LogConfigTypePodIndex) GetPodKeysByLogConfig(lgcKey string) []string {
+	podSets, ok := p.lgcToPodSets[lgcKey]
+	if !ok {
+		return nil
+	}
+	return podSets.List()
+}
+
 func (p *LogConfigTypePodIndex) GetPipeConfigsByPod(namespace string, podName string) []pipeline.ConfigRaw {
 	podKey := helper.MetaNamespaceKey(namespace, podName)
 
@@ -77,9 +94,9 @@ func (p *LogConfigTypePodIndex) GetPipeConfigsByPod(namespace string, podName st
 	return pipcfgs
 }
 
-func (p *LogConfigTypePodIndex) SetConfigs(namespace string, podName string, lgcName string, cfg *pipeline.ConfigRaw) {
+// SetConfigs sets the pipeline configs of the pod and logConfig, lgcKey is the name for ClusterLogConfig
+func (p *LogConfigTypePodIndex) SetConfigs(namespace string, podName string, lgcKey string, cfg *pipeline.ConfigRaw) {
 	podKey := helper.MetaNamespaceKey(namespace, podName)
-	lgcKey := helper.MetaNamespaceKey(namespace, lgcName)
 	podAndLgc := helper.MetaNamespaceKey(podKey, lgcKey)
 
 	p.pipeConfigs[podAndLgc] = cfg
@@ -96,10 +113,9 @@ func (p *LogConfigTypePodIndex) SetConfigs(namespace string, podName string, lgc
 	}
 }
 
-func (p *LogConfigTypePodIndex) ValidateAndSetConfigs(namespace string, podName string, lgcName string, cfg *pipeline.ConfigRaw) error {
-	p.SetConfigs(namespace, podName, lgcName, cfg)
+func (p *LogConfigTypePodIndex) ValidateAndSetConfigs(namespace string, podName string, lgcKey string, cfg *pipeline.ConfigRaw) error {
+	p.SetConfigs(namespace, podName, lgcKey, cfg)
 	if err := p.GetAllGroupByLogConfig().Validate(); err != nil {
-		lgcKey := helper.MetaNamespaceKey(namespace, lgcName)
 		p.DeletePipeConfigsByLogConfigKey(lgcKey)
 		return err
 	}
@@ -132,6 +148,29 @@ func (p *LogConfigTypePodIndex) DeletePipeConfigsByLogConfigKey(lgcKey string) b
 	return true
 }
 
+// DeletePipeConfigs deletes the pipeline configs of the pod related to the logConfig only
+func (p *LogConfigTypePodIndex) DeletePipeConfigs(podKey string, lgcKey string) bool {
+	podAndLgc := helper.MetaNamespaceKey(podKey, lgcKey)
+	if _, ok := p.pipeConfigs[podAndLgc]; !ok {
+		return false
+	}
+	delete(p.pipeConfigs, podAndLgc)
+
+	if podSets, ok := p.lgcToPodSets[lgcKey]; ok {
+		podSets.Delete(podKey)
+		if podSets.Len() == 0 {
+			delete(p.lgcToPodSets, lgcKey)
+		}
+	}
+	if lgcSets, ok := p.podToLgcSets[podKey]; ok {
+		lgcSets.Delete(lgcKey)
+		if lgcSets.Len() == 0 {
+			delete(p.podToLgcSets, podKey)
+		}
+	}
+	return true
+}
+
 func (p *LogConfigTypePodIndex) DeletePipeConfigsByPodKey(podKey string) bool {
 	// find pod related lgc sets
 	lgcSets, ok := p.podToLgcSets[podKey]
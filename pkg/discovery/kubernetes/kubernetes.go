@@ -17,21 +17,34 @@ limitations under the License.
 package kubernetes
 
 import (
+	"context"
+	"os"
+
+	"github.com/loggie-io/loggie/pkg/control"
 	"github.com/loggie-io/loggie/pkg/core/log"
+	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
 	logconfigclientset "github.com/loggie-io/loggie/pkg/discovery/kubernetes/client/clientset/versioned"
 	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/controller"
+	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/external"
+	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/webhook"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	kubeclientset "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeinformers "k8s.io/client-go/informers"
+	batchv1informers "k8s.io/client-go/informers/batch/v1"
+	corev1informers "k8s.io/client-go/informers/core/v1"
 
 	logconfigInformer "github.com/loggie-io/loggie/pkg/discovery/kubernetes/client/informers/externalversions"
+	logconfigInformers "github.com/loggie-io/loggie/pkg/discovery/kubernetes/client/informers/externalversions/loggie/v1beta1"
 )
 
 type Discovery struct {
-	config *controller.Config
+	config  *controller.Config
+	handler control.ConfigHandler
 }
 
 func NewDiscovery(config *controller.Config) *Discovery {
@@ -40,6 +53,11 @@ func NewDiscovery(config *controller.Config) *Discovery {
 	}
 }
 
+// SetConfigHandler implements control.ConfigProvider, the pipelines generated are pushed to the handler
+func (d *Discovery) SetConfigHandler(handler control.ConfigHandler) {
+	d.handler = handler
+}
+
 func (d *Discovery) Start(stopCh <-chan struct{}) {
 	cfg, err := clientcmd.BuildConfigFromFlags(d.config.Master, d.config.Kubeconfig)
 	if err != nil {
@@ -66,15 +84,128 @@ func (d *Discovery) Start(stopCh <-chan struct{}) {
 		lo.FieldSelector = fields.OneTermEqualSelector("metadata.name", d.config.NodeName).String()
 	}))
 
+	// namespaces are selected by ClusterLogConfig, and the CronJobs of pods are resolved through Jobs
+	clusterInformerFactory := kubeinformers.NewSharedInformerFactory(kubeClient, 0)
+	var jobInformer batchv1informers.JobInformer
+	if d.config.Fields.WorkloadEnabled() {
+		jobInformer = clusterInformerFactory.Batch().V1().Jobs()
+	}
+
+	// the pipelines of selector type loggie are sharded by the endpoints of aggregator Service
+	var endpointsInformer corev1informers.EndpointsInformer
+	var endpointsInformerFactory kubeinformers.SharedInformerFactory
+	if d.config.Aggregator.ShardingEnabled() {
+		if d.config.Aggregator.PodName == "" {
+			d.config.Aggregator.PodName, _ = os.Hostname()
+		}
+		endpointsInformerFactory = kubeinformers.NewSharedInformerFactoryWithOptions(kubeClient, 0,
+			kubeinformers.WithNamespace(d.config.Aggregator.Namespace),
+			kubeinformers.WithTweakListOptions(func(lo *metav1.ListOptions) {
+				lo.FieldSelector = fields.OneTermEqualSelector("metadata.name", d.config.Aggregator.Service).String()
+			}))
+		endpointsInformer = endpointsInformerFactory.Core().V1().Endpoints()
+	}
+
+	// the informers of cluster scope are optional, the caches would never be synced without the CRD or RBAC
+	var clusterLogConfigInformer logconfigInformers.ClusterLogConfigInformer
+	if clusterLogConfigServed(kubeClient) {
+		clusterLogConfigInformer = logConfInformerFactory.Loggie().V1beta1().ClusterLogConfigs()
+	} else {
+		log.Warn("ClusterLogConfig is disabled, the CRD clusterlogconfigs.loggie.io is not installed or loggie is not allowed to list and watch it")
+	}
+	var namespaceInformer corev1informers.NamespaceInformer
+	if canListAndWatch(kubeClient, "", "namespaces") {
+		namespaceInformer = clusterInformerFactory.Core().V1().Namespaces()
+	} else {
+		log.Warn("namespaceSelector of ClusterLogConfig is disabled, loggie is not allowed to list and watch namespaces")
+	}
+
 	ctrl := controller.NewController(d.config, kubeClient, logConfigClient, kubeInformerFactory.Core().V1().Pods(),
-		logConfInformerFactory.Loggie().V1beta1().LogConfigs(), logConfInformerFactory.Loggie().V1beta1().Sinks(),
-		logConfInformerFactory.Loggie().V1beta1().Interceptors(), nodeInformerFactory.Core().V1().Nodes())
+		logConfInformerFactory.Loggie().V1beta1().LogConfigs(), clusterLogConfigInformer,
+		logConfInformerFactory.Loggie().V1beta1().Sinks(), logConfInformerFactory.Loggie().V1beta1().Interceptors(),
+		nodeInformerFactory.Core().V1().Nodes(), namespaceInformer, jobInformer,
+		endpointsInformer)
+	if d.handler != nil {
+		ctrl.SetConfigHandler(d.handler)
+	}
+
+	external.SetPodLister(kubeInformerFactory.Core().V1().Pods().Lister())
 
 	logConfInformerFactory.Start(stopCh)
 	kubeInformerFactory.Start(stopCh)
 	nodeInformerFactory.Start(stopCh)
+	clusterInformerFactory.Start(stopCh)
+	if endpointsInformerFactory != nil {
+		endpointsInformerFactory.Start(stopCh)
+	}
 
 	if err := ctrl.Run(stopCh); err != nil {
 		log.Panic("Error running controller: %s", err.Error())
 	}
 }
+
+// clusterLogConfigServed checks if the CRD of ClusterLogConfig is installed, and loggie is allowed to watch it
+func clusterLogConfigServed(kubeClient kubeclientset.Interface) bool {
+	resources, err := kubeClient.Discovery().ServerResourcesForGroupVersion(logconfigv1beta1.SchemeGroupVersion.String())
+	if err != nil {
+		log.Warn("get resources of %s failed: %v", logconfigv1beta1.SchemeGroupVersion.String(), err)
+		return false
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == "clusterlogconfigs" {
+			return canListAndWatch(kubeClient, logconfigv1beta1.SchemeGroupVersion.Group, r.Name)
+		}
+	}
+	return false
+}
+
+// canListAndWatch reviews the access of loggie itself to the resource
+func canListAndWatch(kubeClient kubeclientset.Interface, group string, resource string) bool {
+	for _, verb := range []string{"list", "watch"} {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Group:    group,
+					Resource: resource,
+					Verb:     verb,
+				},
+			},
+		}
+		ret, err := kubeClient.AuthorizationV1().SelfSubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+		if err != nil {
+			log.Warn("review access of %s %s failed: %v", verb, resource, err)
+			return false
+		}
+		if !ret.Status.Allowed {
+			return false
+		}
+	}
+	return true
+}
+
+// StartWebhook runs the admission webhook server of the CRDs, which is independent of the discovery on nodes
+func StartWebhook(config *controller.Config, webhookConfig *webhook.Config, stopCh <-chan struct{}) {
+	cfg, err := clientcmd.BuildConfigFromFlags(config.Master, config.Kubeconfig)
+	if err != nil {
+		log.Panic("Error building kubeconfig: %s, cfg: %+v", err.Error(), cfg)
+	}
+
+	logConfigClient, err := logconfigclientset.NewForConfig(cfg)
+	if err != nil {
+		log.Panic("Error building logConf clientset: %s", err.Error())
+	}
+
+	logConfInformerFactory := logconfigInformer.NewSharedInformerFactory(logConfigClient, 0)
+	sinkInformer := logConfInformerFactory.Loggie().V1beta1().Sinks()
+	interceptorInformer := logConfInformerFactory.Loggie().V1beta1().Interceptors()
+	server := webhook.NewServer(webhookConfig, sinkInformer.Lister(), interceptorInformer.Lister())
+
+	logConfInformerFactory.Start(stopCh)
+	if ok := cache.WaitForCacheSync(stopCh, sinkInformer.Informer().HasSynced, interceptorInformer.Informer().HasSynced); !ok {
+		log.Panic("failed to wait for caches to sync")
+	}
+
+	if err := server.Run(stopCh); err != nil {
+		log.Panic("Error running webhook server: %s", err.Error())
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/loggie-io/loggie/pkg/control"
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
+	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/helper"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/source/file"
+)
+
+// podSourceFields are the fields of sources only used by discovery when selector.type=pod
+var podSourceFields = []string{"containerName", "matchFields"}
+
+// nodeSourceField is the field of sources only used by discovery when selector.type=node
+const nodeSourceField = "matchFields"
+
+// validateLogConfig validates the spec, resolves the sinkRef and interceptorRef, and dry-runs the pipeline generated
+func (s *Server) validateLogConfig(lgc *logconfigv1beta1.LogConfig) error {
+	if err := lgc.Validate(); err != nil {
+		return err
+	}
+
+	pip := lgc.Spec.Pipeline
+	sources, err := helper.ToPipelineSources(pip.Sources)
+	if err != nil {
+		return errors.WithMessage(err, "spec.pipeline.sources is invalid")
+	}
+	if lgc.Spec.Selector.Type == logconfigv1beta1.SelectorTypePod {
+		for i, src := range sources {
+			if src.GetType() != file.Type {
+				return errors.Errorf("spec.pipeline.sources[%d]: only source type=file is supported when selector.type=pod", i)
+			}
+			for _, f := range podSourceFields {
+				delete(src, f)
+			}
+		}
+	}
+	if lgc.Spec.Selector.Type == logconfigv1beta1.SelectorTypeNode {
+		for i, src := range sources {
+			delete(src, nodeSourceField)
+			if sources[i], err = helper.ApplyNodePreset(src); err != nil {
+				return errors.WithMessagef(err, "spec.pipeline.sources[%d]", i)
+			}
+		}
+	}
+
+	var interceptors []cfg.CommonCfg
+	if pip.InterceptorRef != "" {
+		if _, err := s.interceptorLister.Get(pip.InterceptorRef); kerrors.IsNotFound(err) {
+			return errors.Errorf("spec.pipeline.interceptorRef: interceptor %s not found", pip.InterceptorRef)
+		}
+		if interceptors, err = helper.ToPipelineInterceptor(pip.InterceptorRef, s.interceptorLister); err != nil {
+			return errors.WithMessagef(err, "spec.pipeline.interceptorRef: interceptor %s is invalid", pip.InterceptorRef)
+		}
+	}
+
+	if pip.SinkRef != "" {
+		if _, err := s.sinkLister.Get(pip.SinkRef); kerrors.IsNotFound(err) {
+			return errors.Errorf("spec.pipeline.sinkRef: sink %s not found", pip.SinkRef)
+		}
+	}
+	sink, err := helper.ToPipelineSink(pip.SinkRef, s.sinkLister)
+	if err != nil {
+		return errors.WithMessage(err, "spec.pipeline.sinkRef")
+	}
+
+	pipelines := &control.PipelineRawConfig{
+		Pipelines: []pipeline.ConfigRaw{{
+			Name:         fmt.Sprintf("%s/%s", helper.MetaNamespaceKey(lgc.Namespace, lgc.Name), pip.Name),
+			Sources:      sources,
+			Interceptors: interceptors,
+			Sink:         sink,
+		}},
+	}
+	pipelines.SetDefaults()
+	if err := pipelines.ValidateDive(); err != nil {
+		return errors.WithMessage(err, "pipeline generated is invalid")
+	}
+	return nil
+}
+
+func validateSink(sink *logconfigv1beta1.Sink) error {
+	sinkCfg := cfg.NewCommonCfg()
+	if err := cfg.UnpackRaw([]byte(sink.Spec.Sink), &sinkCfg); err != nil {
+		return errors.WithMessage(err, "spec.sink is invalid")
+	}
+
+	// the sink is merged with the defaults in pipelines
+	defaults, err := pipeline.GetDefaultConfigRaw()
+	if err != nil {
+		return err
+	}
+	sinkCfg = cfg.MergeCommonCfg(sinkCfg, defaults.Sink, false)
+	if err := pipeline.ValidateComponent(api.SINK, sinkCfg); err != nil {
+		return errors.WithMessage(err, "spec.sink is invalid")
+	}
+	return nil
+}
+
+func validateInterceptor(icp *logconfigv1beta1.Interceptor) error {
+	icpCfgs := make([]cfg.CommonCfg, 0)
+	if err := cfg.UnpackRaw([]byte(icp.Spec.Interceptors), &icpCfgs); err != nil {
+		return errors.WithMessage(err, "spec.interceptors is invalid")
+	}
+
+	for i, c := range icpCfgs {
+		if err := pipeline.ValidateComponent(api.INTERCEPTOR, c); err != nil {
+			return errors.WithMessagef(err, "spec.interceptors[%d] is invalid", i)
+		}
+	}
+	return nil
+}
+
+type patchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// defaultLogConfig sets selector.type by the selectors when absent
+func defaultLogConfig(lgc *logconfigv1beta1.LogConfig) []patchOperation {
+	selector := lgc.Spec.Selector
+	if selector == nil || selector.Type != "" {
+		return nil
+	}
+
+	var tp string
+	switch {
+	case selector.HasLabelSelector() || selector.HasNamespaceSelector():
+		tp = logconfigv1beta1.SelectorTypePod
+	case len(selector.NodeSelector.NodeSelector) > 0:
+		tp = logconfigv1beta1.SelectorTypeNode
+	default:
+		return nil
+	}
+	return []patchOperation{{Op: "add", Path: "/spec/selector/type", Value: tp}}
+}
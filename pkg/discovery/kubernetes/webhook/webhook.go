@@ -0,0 +1,198 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/loggie-io/loggie/pkg/core/log"
+	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
+	logconfigLister "github.com/loggie-io/loggie/pkg/discovery/kubernetes/client/listers/loggie/v1beta1"
+)
+
+const (
+	PathValidate = "/validate"
+	PathMutate   = "/mutate"
+)
+
+// Server is the validating and defaulting admission webhook of LogConfig, ClusterLogConfig, Sink and Interceptor,
+// so the invalid objects are rejected when applied instead of failing on every node
+type Server struct {
+	config *Config
+
+	sinkLister        logconfigLister.SinkLister
+	interceptorLister logconfigLister.InterceptorLister
+}
+
+func NewServer(config *Config, sinkLister logconfigLister.SinkLister, interceptorLister logconfigLister.InterceptorLister) *Server {
+	return &Server{
+		config:            config,
+		sinkLister:        sinkLister,
+		interceptorLister: interceptorLister,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(PathValidate, s.serve(s.validate))
+	mux.HandleFunc(PathMutate, s.serve(s.mutate))
+	return mux
+}
+
+func (s *Server) Run(stopCh <-chan struct{}) error {
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", s.config.Port),
+		Handler: s.Handler(),
+	}
+	go func() {
+		<-stopCh
+		if err := server.Shutdown(context.Background()); err != nil {
+			log.Warn("shutdown webhook server failed: %v", err)
+		}
+	}()
+
+	log.Info("webhook server listening on %s", server.Addr)
+	if err := server.ListenAndServeTLS(s.config.CertFile, s.config.KeyFile); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+type admitFunc func(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse
+
+func (s *Server) serve(admit admitFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		review := &admissionv1.AdmissionReview{}
+		if err := json.Unmarshal(body, review); err != nil || review.Request == nil {
+			http.Error(w, "invalid AdmissionReview", http.StatusBadRequest)
+			return
+		}
+
+		resp := admit(review.Request)
+		resp.UID = review.Request.UID
+		review.Response = resp
+		review.Request = nil
+
+		out, err := json.Marshal(review)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(out); err != nil {
+			log.Warn("write admission response failed: %v", err)
+		}
+	}
+}
+
+func (s *Server) validate(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Operation == admissionv1.Delete {
+		return allowed()
+	}
+
+	var err error
+	switch req.Kind.Kind {
+	case "LogConfig":
+		lgc := &logconfigv1beta1.LogConfig{}
+		if err = json.Unmarshal(req.Object.Raw, lgc); err == nil {
+			err = s.validateLogConfig(lgc)
+		}
+
+	case logconfigv1beta1.KindClusterLogConfig:
+		clgc := &logconfigv1beta1.ClusterLogConfig{}
+		if err = json.Unmarshal(req.Object.Raw, clgc); err == nil {
+			err = s.validateLogConfig(clgc.ToLogConfig())
+		}
+
+	case "Sink":
+		sink := &logconfigv1beta1.Sink{}
+		if err = json.Unmarshal(req.Object.Raw, sink); err == nil {
+			err = validateSink(sink)
+		}
+
+	case "Interceptor":
+		icp := &logconfigv1beta1.Interceptor{}
+		if err = json.Unmarshal(req.Object.Raw, icp); err == nil {
+			err = validateInterceptor(icp)
+		}
+
+	default:
+		return allowed()
+	}
+
+	if err != nil {
+		log.Info("%s %s/%s is rejected: %v", req.Kind.Kind, req.Namespace, req.Name, err)
+		return denied(errors.WithMessagef(err, "%s %s is invalid", req.Kind.Kind, req.Name))
+	}
+	return allowed()
+}
+
+func (s *Server) mutate(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	if req.Kind.Kind != "LogConfig" && req.Kind.Kind != logconfigv1beta1.KindClusterLogConfig {
+		return allowed()
+	}
+
+	// LogConfig and ClusterLogConfig have the same spec
+	lgc := &logconfigv1beta1.LogConfig{}
+	if err := json.Unmarshal(req.Object.Raw, lgc); err != nil {
+		return denied(err)
+	}
+
+	patches := defaultLogConfig(lgc)
+	if len(patches) == 0 {
+		return allowed()
+	}
+	patch, err := json.Marshal(patches)
+	if err != nil {
+		return denied(err)
+	}
+	resp := allowed()
+	patchType := admissionv1.PatchTypeJSONPatch
+	resp.PatchType = &patchType
+	resp.Patch = patch
+	return resp
+}
+
+func allowed() *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{Allowed: true}
+}
+
+func denied(err error) *admissionv1.AdmissionResponse {
+	return &admissionv1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+			Message: err.Error(),
+		},
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	logconfigv1beta1 "github.com/loggie-io/loggie/pkg/discovery/kubernetes/apis/loggie/v1beta1"
+	logconfigLister "github.com/loggie-io/loggie/pkg/discovery/kubernetes/client/listers/loggie/v1beta1"
+	_ "github.com/loggie-io/loggie/pkg/interceptor/maxbytes"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/queue/channel"
+	_ "github.com/loggie-io/loggie/pkg/sink/dev"
+	_ "github.com/loggie-io/loggie/pkg/source/file"
+)
+
+func newTestServer(t *testing.T) *Server {
+	log.InitDefaultLogger()
+	pipeline.SetDefaultConfigRaw(pipeline.ConfigRaw{
+		Queue: cfg.CommonCfg{"type": channel.Type, "name": "default"},
+	})
+
+	sinkIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := sinkIndexer.Add(&logconfigv1beta1.Sink{
+		ObjectMeta: metav1.ObjectMeta{Name: "dev"},
+		Spec:       logconfigv1beta1.SinkSpec{Sink: "type: dev"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	interceptorIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	return NewServer(&Config{}, logconfigLister.NewSinkLister(sinkIndexer), logconfigLister.NewInterceptorLister(interceptorIndexer))
+}
+
+func review(t *testing.T, handler http.Handler, path string, kind string, obj interface{}) *admissionv1.AdmissionResponse {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := json.Marshal(&admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "uid",
+			Kind:      metav1.GroupVersionKind{Group: "loggie.io", Version: "v1beta1", Kind: kind},
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body)))
+	out := &admissionv1.AdmissionReview{}
+	if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+		t.Fatalf("unmarshal response %s: %v", rec.Body.String(), err)
+	}
+	if out.Response.UID != "uid" {
+		t.Fatalf("uid is not returned")
+	}
+	return out.Response
+}
+
+func TestValidate(t *testing.T) {
+	handler := newTestServer(t).Handler()
+
+	logConfig := func(sources string, sinkRef string) *logconfigv1beta1.LogConfig {
+		return &logconfigv1beta1.LogConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+			Spec: logconfigv1beta1.LogConfigSpec{
+				Selector: &logconfigv1beta1.Selector{
+					Type:        logconfigv1beta1.SelectorTypePod,
+					PodSelector: logconfigv1beta1.PodSelector{LabelSelector: map[string]string{"app": "nginx"}},
+				},
+				Pipeline: &logconfigv1beta1.Pipeline{Sources: sources, SinkRef: sinkRef},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		kind    string
+		obj     interface{}
+		message string
+	}{
+		{
+			name: "valid",
+			kind: "LogConfig",
+			obj:  logConfig("[{type: file, name: access, containerName: nginx, paths: [stdout]}]", "dev"),
+		},
+		{
+			name:    "malformed sources",
+			kind:    "LogConfig",
+			obj:     logConfig("[{type: file, name: access", "dev"),
+			message: "spec.pipeline.sources is invalid",
+		},
+		{
+			name:    "source type",
+			kind:    "LogConfig",
+			obj:     logConfig("[{type: kafka, name: access}]", "dev"),
+			message: "spec.pipeline.sources[0]: only source type=file is supported",
+		},
+		{
+			name:    "sink not found",
+			kind:    "LogConfig",
+			obj:     logConfig("[{type: file, name: access, paths: [stdout]}]", "kafka"),
+			message: "spec.pipeline.sinkRef: sink kafka not found",
+		},
+		{
+			name:    "dry-run",
+			kind:    "LogConfig",
+			obj:     logConfig("[{type: file, name: access}]", "dev"),
+			message: "pipeline generated is invalid",
+		},
+		{
+			name: "sink",
+			kind: "Sink",
+			obj: &logconfigv1beta1.Sink{
+				ObjectMeta: metav1.ObjectMeta{Name: "dev"},
+				Spec:       logconfigv1beta1.SinkSpec{Sink: "type: dev\nparallelism: 101"},
+			},
+			message: "spec.sink is invalid",
+		},
+		{
+			name: "interceptor",
+			kind: "Interceptor",
+			obj: &logconfigv1beta1.Interceptor{
+				ObjectMeta: metav1.ObjectMeta{Name: "default"},
+				Spec:       logconfigv1beta1.InterceptorSpec{Interceptors: "- type: maxbytes\n- type: unknown"},
+			},
+			message: "spec.interceptors[1] is invalid",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := review(t, handler, PathValidate, tt.kind, tt.obj)
+			if tt.message == "" {
+				if !resp.Allowed {
+					t.Fatalf("want allowed, got %+v", resp.Result)
+				}
+				return
+			}
+			if resp.Allowed || !strings.Contains(resp.Result.Message, tt.message) {
+				t.Fatalf("want denied with %q, got %+v", tt.message, resp.Result)
+			}
+		})
+	}
+}
+
+func TestMutate(t *testing.T) {
+	handler := newTestServer(t).Handler()
+
+	lgc := &logconfigv1beta1.LogConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+		Spec: logconfigv1beta1.LogConfigSpec{
+			Selector: &logconfigv1beta1.Selector{
+				PodSelector: logconfigv1beta1.PodSelector{LabelSelector: map[string]string{"app": "nginx"}},
+			},
+		},
+	}
+	resp := review(t, handler, PathMutate, "LogConfig", lgc)
+	if !resp.Allowed || string(resp.Patch) != `[{"op":"add","path":"/spec/selector/type","value":"pod"}]` {
+		t.Fatalf("unexpected response: %+v, patch: %s", resp, resp.Patch)
+	}
+}
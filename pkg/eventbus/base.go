@@ -41,6 +41,7 @@ var (
 	QueueMetricTopic      = "queue"
 	PipelineTopic         = "pipeline"
 	ComponentBaseTopic    = "component"
+	LeaderElectionTopic   = "leaderelection"
 )
 
 type BaseMetric struct {
@@ -78,6 +79,19 @@ type QueueMetricData struct {
 
 type ReloadMetricData struct {
 	Tick int
+	// RestartedPipelines are stopped and started entirely, eg. the queue changed
+	RestartedPipelines []string
+	// ReloadedComponents are applied in place without restarting the pipeline, key: pipeline name
+	ReloadedComponents map[string][]ComponentBaseConfig
+}
+
+type LeaderElectionMetricData struct {
+	BaseMetric
+	LeaseName string
+	Identity  string
+	IsLeader  bool
+	// Stopped is true when the source stopped competing for the lease
+	Stopped bool
 }
 
 type ComponentBaseMetricData struct {
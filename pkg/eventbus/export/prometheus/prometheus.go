@@ -28,6 +28,7 @@ const (
 	PipelineNameKey = "pipeline"
 	SourceNameKey   = "source"
 	QueueTypeKey    = "type"
+	LeaseNameKey    = "lease"
 )
 
 var collector *Collector
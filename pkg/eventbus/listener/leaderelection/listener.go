@@ -0,0 +1,154 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/eventbus"
+	"github.com/loggie-io/loggie/pkg/eventbus/export/logger"
+	promeExporter "github.com/loggie-io/loggie/pkg/eventbus/export/prometheus"
+)
+
+func init() {
+	eventbus.Registry(makeListener(), eventbus.WithTopic(eventbus.LeaderElectionTopic))
+}
+
+func makeListener() *Listener {
+	l := &Listener{
+		eventChan: make(chan eventbus.LeaderElectionMetricData),
+		data:      make(map[string]data),
+		done:      make(chan struct{}),
+		config:    &Config{},
+	}
+	return l
+}
+
+type Config struct {
+	Period time.Duration `yaml:"period" default:"10s"`
+}
+
+type Listener struct {
+	config    *Config
+	eventChan chan eventbus.LeaderElectionMetricData
+	data      map[string]data // key=pipelineName+sourceName
+	done      chan struct{}
+}
+
+type data struct {
+	PipelineName string `json:"pipeline"`
+	SourceName   string `json:"source"`
+	LeaseName    string `json:"lease"`
+	Identity     string `json:"identity"`
+	IsLeader     bool   `json:"isLeader"`
+}
+
+func (l *Listener) Name() string {
+	return "leaderelection"
+}
+
+func (l *Listener) Init(ctx api.Context) {
+}
+
+func (l *Listener) Start() {
+	go l.run()
+}
+
+func (l *Listener) Stop() {
+	close(l.done)
+}
+
+func (l *Listener) Config() interface{} {
+	return l.config
+}
+
+func (l *Listener) Subscribe(event eventbus.Event) {
+	e, ok := event.Data.(eventbus.LeaderElectionMetricData)
+	if !ok {
+		log.Panic("type assert eventbus.LeaderElectionMetricData failed: %v", ok)
+	}
+	l.eventChan <- e
+}
+
+func (l *Listener) run() {
+	tick := time.Tick(l.config.Period)
+	for {
+		select {
+		case <-l.done:
+			return
+
+		case e := <-l.eventChan:
+			l.consumer(e)
+			// the role changes rarely, export at once
+			l.exportPrometheus()
+
+		case <-tick:
+			m, _ := json.Marshal(l.data)
+			logger.Export(eventbus.LeaderElectionTopic, m)
+		}
+	}
+}
+
+func (l *Listener) consumer(e eventbus.LeaderElectionMetricData) {
+	key := e.PipelineName + "-" + e.SourceName
+	if e.Stopped {
+		delete(l.data, key)
+		return
+	}
+
+	// the role is kept until it changes, it is not a metric accumulated in a period
+	l.data[key] = data{
+		PipelineName: e.PipelineName,
+		SourceName:   e.SourceName,
+		LeaseName:    e.LeaseName,
+		Identity:     e.Identity,
+		IsLeader:     e.IsLeader,
+	}
+}
+
+func (l *Listener) exportPrometheus() {
+	metrics := promeExporter.ExportedMetrics{}
+	for _, d := range l.data {
+		role := 0.0
+		if d.IsLeader {
+			role = 1
+		}
+		m := promeExporter.ExportedMetrics{
+			{
+				Desc: prometheus.NewDesc(
+					prometheus.BuildFQName(promeExporter.Loggie, eventbus.LeaderElectionTopic, "is_leader"),
+					"whether this loggie is the leader running the source, 1 for leader and 0 for standby",
+					nil,
+					prometheus.Labels{
+						promeExporter.PipelineNameKey: d.PipelineName,
+						promeExporter.SourceNameKey:   d.SourceName,
+						promeExporter.LeaseNameKey:    d.LeaseName,
+					},
+				),
+				Eval:    role,
+				ValType: prometheus.GaugeValue,
+			},
+		}
+		metrics = append(metrics, m...)
+	}
+	promeExporter.Export(eventbus.LeaderElectionTopic, metrics)
+}
@@ -53,7 +53,8 @@ type Listener struct {
 }
 
 type data struct {
-	ReloadTotal float64 `yaml:"total"`
+	ReloadTotal          float64 `yaml:"total"`
+	ComponentReloadTotal float64 `yaml:"componentTotal"`
 }
 
 func (l *Listener) Name() string {
@@ -78,6 +79,9 @@ func (l *Listener) Subscribe(event eventbus.Event) {
 	}
 
 	l.data.ReloadTotal = l.data.ReloadTotal + float64(d.Tick)
+	for _, components := range d.ReloadedComponents {
+		l.data.ComponentReloadTotal = l.data.ComponentReloadTotal + float64(len(components))
+	}
 }
 
 func (l *Listener) Config() interface{} {
@@ -108,6 +112,15 @@ func (l *Listener) exportPrometheus() {
 			Eval:    l.data.ReloadTotal,
 			ValType: prometheus.CounterValue,
 		},
+		{
+			Desc: prometheus.NewDesc(
+				prometheus.BuildFQName(promeExporter.Loggie, eventbus.ReloadTopic, "component_total"),
+				"Loggie components reloaded in place total count",
+				nil, nil,
+			),
+			Eval:    l.data.ComponentReloadTotal,
+			ValType: prometheus.CounterValue,
+		},
 	}
 	promeExporter.Export(eventbus.ReloadTopic, metric)
 }
@@ -20,6 +20,7 @@ import (
 	_ "github.com/loggie-io/loggie/pkg/eventbus/export/prometheus"
 	_ "github.com/loggie-io/loggie/pkg/eventbus/listener/filesource"
 	_ "github.com/loggie-io/loggie/pkg/eventbus/listener/filewatcher"
+	_ "github.com/loggie-io/loggie/pkg/eventbus/listener/leaderelection"
 	_ "github.com/loggie-io/loggie/pkg/eventbus/listener/logalerting"
 	_ "github.com/loggie-io/loggie/pkg/eventbus/listener/pipeline"
 	_ "github.com/loggie-io/loggie/pkg/eventbus/listener/queue"
@@ -35,16 +36,26 @@ import (
 	_ "github.com/loggie-io/loggie/pkg/interceptor/retry"
 	_ "github.com/loggie-io/loggie/pkg/queue/channel"
 	_ "github.com/loggie-io/loggie/pkg/queue/memory"
+	_ "github.com/loggie-io/loggie/pkg/sink/clickhouse"
 	_ "github.com/loggie-io/loggie/pkg/sink/codec/json"
 	_ "github.com/loggie-io/loggie/pkg/sink/dev"
 	_ "github.com/loggie-io/loggie/pkg/sink/elasticsearch"
 	_ "github.com/loggie-io/loggie/pkg/sink/grpc"
 	_ "github.com/loggie-io/loggie/pkg/sink/kafka"
+	_ "github.com/loggie-io/loggie/pkg/sink/redis"
+	_ "github.com/loggie-io/loggie/pkg/sink/s3"
+	_ "github.com/loggie-io/loggie/pkg/sink/splunk"
 	_ "github.com/loggie-io/loggie/pkg/source/dev"
+	_ "github.com/loggie-io/loggie/pkg/source/exec"
 	_ "github.com/loggie-io/loggie/pkg/source/file"
+	_ "github.com/loggie-io/loggie/pkg/source/generator"
 	_ "github.com/loggie-io/loggie/pkg/source/grpc"
 	_ "github.com/loggie-io/loggie/pkg/source/kafka"
 	_ "github.com/loggie-io/loggie/pkg/source/kubernetes_event"
+	_ "github.com/loggie-io/loggie/pkg/source/loggie"
 	_ "github.com/loggie-io/loggie/pkg/source/prometheus_exporter"
+	_ "github.com/loggie-io/loggie/pkg/source/redis"
+	_ "github.com/loggie-io/loggie/pkg/source/tcp"
+	_ "github.com/loggie-io/loggie/pkg/source/udp"
 	_ "github.com/loggie-io/loggie/pkg/source/unix"
 )
@@ -0,0 +1,174 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/cfg"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/core/source"
+	"github.com/loggie-io/loggie/pkg/eventbus"
+	"github.com/loggie-io/loggie/pkg/util/leaderelection"
+)
+
+// leaderSource wraps the source with leader election, the real source is created and started only when
+// this loggie holds the lease, and stopped once the leadership is lost.
+type leaderSource struct {
+	api.Source // only used as the prototype for Config/Type/Category
+
+	ctx          api.Context
+	config       source.Config
+	info         Info
+	pipelineName string
+	leaseName    string
+
+	lock        sync.Mutex
+	running     api.Source
+	productFunc api.ProductFunc
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newLeaderSource(prototype api.Source, ctx api.Context, config source.Config, info Info) *leaderSource {
+	leaseName := config.LeaderElection.LeaseName
+	if leaseName == "" {
+		leaseName = leaderelection.DefaultLeaseName(info.PipelineName, config.Name)
+	}
+	return &leaderSource{
+		Source:       prototype,
+		ctx:          ctx,
+		config:       config,
+		info:         info,
+		pipelineName: info.PipelineName,
+		leaseName:    leaseName,
+		done:         make(chan struct{}),
+	}
+}
+
+func (l *leaderSource) String() string {
+	return l.Source.String() + "(leader election)"
+}
+
+func (l *leaderSource) Init(ctx api.Context) {
+}
+
+func (l *leaderSource) Start() {
+	electCtx, cancel := context.WithCancel(context.Background())
+	l.cancel = cancel
+	l.publishRole(false, false)
+
+	go func() {
+		defer close(l.done)
+		err := leaderelection.Run(electCtx, &l.config.LeaderElection, l.leaseName, leaderelection.Callbacks{
+			OnStartedLeading: l.startLeading,
+			OnStoppedLeading: l.stopLeading,
+		})
+		if err != nil {
+			log.Error("%s run leader election failed: %+v", l.String(), err)
+		}
+	}()
+}
+
+func (l *leaderSource) Stop() {
+	if l.cancel == nil {
+		return
+	}
+	l.cancel()
+	<-l.done
+	// stopLeading has been called when the elector returned, call again in case it failed to run
+	l.stopLeading()
+	l.publishRole(false, true)
+}
+
+func (l *leaderSource) startLeading() {
+	component, err := GetWithType(api.SOURCE, l.Source.Type(), l.info)
+	if err != nil {
+		log.Error("create source %s failed: %+v", l.config.Name, err)
+		return
+	}
+	if err := cfg.UnpackAndDefaults(l.ctx.Properties(), component.Config()); err != nil {
+		log.Error("unpack source %s config failed: %+v", l.config.Name, err)
+		return
+	}
+	src := component.(api.Source)
+	src.Init(l.ctx)
+	src.Start()
+
+	l.lock.Lock()
+	l.running = src
+	productFunc := l.productFunc
+	l.lock.Unlock()
+
+	if productFunc != nil {
+		go src.ProductLoop(productFunc)
+	}
+	l.publishRole(true, false)
+}
+
+func (l *leaderSource) stopLeading() {
+	l.lock.Lock()
+	src := l.running
+	l.running = nil
+	l.lock.Unlock()
+
+	if src == nil {
+		return
+	}
+	src.Stop()
+	l.publishRole(false, false)
+}
+
+func (l *leaderSource) ProductLoop(productFunc api.ProductFunc) {
+	l.lock.Lock()
+	l.productFunc = productFunc
+	src := l.running
+	l.lock.Unlock()
+
+	if src != nil {
+		src.ProductLoop(productFunc)
+	}
+}
+
+func (l *leaderSource) Commit(events []api.Event) {
+	l.lock.Lock()
+	src := l.running
+	l.lock.Unlock()
+
+	if src != nil {
+		src.Commit(events)
+		return
+	}
+	// the source producing the events has been stopped
+	l.info.EventPool.PutAll(events)
+}
+
+func (l *leaderSource) publishRole(isLeader bool, stopped bool) {
+	eventbus.Publish(eventbus.LeaderElectionTopic, eventbus.LeaderElectionMetricData{
+		BaseMetric: eventbus.BaseMetric{
+			PipelineName: l.pipelineName,
+			SourceName:   l.config.Name,
+		},
+		LeaseName: l.leaseName,
+		Identity:  leaderelection.Identity(),
+		IsLeader:  isLeader,
+		Stopped:   stopped,
+	})
+}
@@ -33,6 +33,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -54,6 +55,12 @@ type Pipeline struct {
 	retryOutFuncs []api.OutFunc
 	index         uint32
 	epoch         Epoch
+
+	// nsLock guards ns and sourceChains, which are changed by reloading
+	nsLock       sync.RWMutex
+	sourceChains map[string]*atomic.Value // key:source name|value:sourceInvokerHolder
+	// queueListeners are the names of listeners known by the queue when it was created
+	queueListeners map[string]struct{}
 }
 
 func NewPipeline() *Pipeline {
@@ -94,6 +101,8 @@ func (p *Pipeline) stopSinkConsumer() {
 }
 
 func (p *Pipeline) stopSourceProduct() {
+	p.nsLock.RLock()
+	defer p.nsLock.RUnlock()
 	for name, s := range p.ns {
 		localSource := s
 		localSource.Stop()
@@ -142,7 +151,11 @@ func (p *Pipeline) cleanData() {
 	// clean registry center
 	p.r.cleanData()
 	// clean pipeline
+	p.nsLock.Lock()
 	p.ns = nil
+	p.sourceChains = nil
+	p.nsLock.Unlock()
+	p.queueListeners = nil
 	p.nq = nil
 	p.outChans = nil
 	p.r = nil
@@ -198,6 +211,7 @@ func (p *Pipeline) Start(pipelineConfig Config) {
 	// 6. start source product
 	p.startSourceProduct(pipelineConfig.Sources)
 
+	p.countDown.Add(1)
 	go p.survive()
 	log.Info("pipeline start with epoch: %+v", p.epoch)
 }
@@ -220,6 +234,7 @@ func (p *Pipeline) init(pipelineConfig Config) {
 	p.info.Stop = false
 	p.ns = make(map[string]api.Source)
 	p.nq = make(map[string]api.Queue)
+	p.sourceChains = make(map[string]*atomic.Value)
 
 	// init event pool
 	p.info.EventPool = event.NewDefaultPool(pipelineConfig.Queue.BatchSize * (p.info.SinkCount + 1))
@@ -238,6 +253,11 @@ func (p *Pipeline) startQueue(queueConfig queue.Config) {
 	q := p.r.LoadQueue(api.Type(queueConfig.Type), queueConfig.Name)
 	p.nq[queueConfig.Name] = q
 	p.outChans = append(p.outChans, q.OutChan())
+
+	p.queueListeners = make(map[string]struct{})
+	for _, l := range p.r.LoadQueueListeners() {
+		p.queueListeners[l.Name()] = struct{}{}
+	}
 }
 
 func (p *Pipeline) startComponent(ctx api.Context) {
@@ -264,7 +284,7 @@ func (p *Pipeline) afterSinkConsumer(b api.Batch, result api.Result) {
 		p.finalizeBatch(b)
 	}
 	if result.Status() == api.FAIL {
-		log.Error("consumer batch fail,err: %s", result.Error())
+		log.ForPipeline(p.name).Error("consumer batch fail,err: %s", result.Error())
 	}
 }
 
@@ -284,8 +304,16 @@ func (p *Pipeline) finalizeBatch(batch api.Batch) {
 		es = append(es, e)
 		nes[sourceName] = es
 	}
+	p.nsLock.RLock()
+	defer p.nsLock.RUnlock()
 	for sn, es := range nes {
-		p.ns[sn].Commit(es)
+		// source may have been removed by reloading, the events are returned to the pool instead
+		s, ok := p.ns[sn]
+		if !ok {
+			p.info.EventPool.PutAll(es)
+			continue
+		}
+		s.Commit(es)
 	}
 
 	batch.Release()
@@ -333,6 +361,24 @@ func (p *Pipeline) validate(pipelineConfig *Config) error {
 	return nil
 }
 
+// ValidateComponent validates the config of a single component apart from pipelines, eg: the sink defined in
+// kubernetes CRD
+func ValidateComponent(category api.Category, properties cfg.CommonCfg) error {
+	base := &cfg.ComponentBaseConfig{}
+	if category == api.SINK {
+		sinkConfig := &sink.Config{}
+		if err := cfg.UnpackDefaultsAndValidate(properties, sinkConfig); err != nil {
+			return err
+		}
+		base = &sinkConfig.ComponentBaseConfig
+	} else if err := cfg.UnpackDefaultsAndValidate(properties, base); err != nil {
+		return err
+	}
+
+	ctx := context.NewContext(base.Name, api.Type(base.Type), category, properties)
+	return NewPipeline().validateComponent(ctx)
+}
+
 func (p *Pipeline) startSink(sinkConfigs *sink.Config) {
 	p.retryOutFuncs = make([]api.OutFunc, 0)
 	ctx := context.NewContext(sinkConfigs.Name, api.Type(sinkConfigs.Type), api.SINK, sinkConfigs.Properties)
@@ -401,13 +447,13 @@ func (p *Pipeline) startSinkConsumer(sinkConfig *sink.Config) {
 	for i := 0; i < sinkConfig.Parallelism; i++ {
 		index := i
 		p.retryOutFuncs = append(p.retryOutFuncs, retryOutFunc)
+		p.countDown.Add(1)
 		go p.sinkInvokeLoop(index, si, outFunc)
 	}
 }
 
 // outfunc may have been combined, but batch has been released in advance
 func (p *Pipeline) sinkInvokeLoop(index int, info sink.Info, outFunc api.OutFunc) {
-	p.countDown.Add(1)
 	s := info.Sink
 	log.Info("pipeline sink(%s)-%d invoke loop start", s.String(), index)
 	defer func() {
@@ -468,35 +514,55 @@ func buildSinkInvokerChain(invoker sink.Invoker, interceptors []sink.Interceptor
 func (p *Pipeline) startSource(sourceConfigs []source.Config) {
 	for _, sourceConfig := range sourceConfigs {
 		ctx := context.NewContext(sourceConfig.Name, api.Type(sourceConfig.Type), api.SOURCE, sourceConfig.Properties)
+		if sourceConfig.LeaderElection.Enabled {
+			p.startLeaderSource(ctx, sourceConfig)
+			continue
+		}
 		p.startComponent(ctx)
 	}
 }
 
+func (p *Pipeline) startLeaderSource(ctx api.Context, sourceConfig source.Config) {
+	component, _ := GetWithType(ctx.Category(), ctx.Type(), p.info)
+	err := cfg.UnpackAndDefaults(ctx.Properties(), component.Config())
+	if err != nil {
+		log.Panic("unpack component %s/%s error: %v", component.Category(), component.Type(), err)
+	}
+
+	ls := newLeaderSource(component.(api.Source), ctx, sourceConfig, p.info)
+	ls.Start()
+	p.r.Register(ls, ctx.Name())
+	p.reportMetric(ctx.Name(), ls, eventbus.ComponentStart)
+}
+
+// sourceInvokerHolder keeps the concrete type stored in atomic.Value consistent
+type sourceInvokerHolder struct {
+	invoker source.Invoker
+}
+
 func (p *Pipeline) startSourceProduct(sourceConfigs []source.Config) {
 	for _, sc := range sourceConfigs {
 		sourceConfig := sc
-		interceptors := make([]source.Interceptor, 0)
-		for _, inter := range p.r.LoadInterceptors() {
-			i, ok := inter.(source.Interceptor)
-			if !ok {
-				continue
-			}
-			interceptors = append(interceptors, i)
-		}
-
 		q := p.r.LoadDefaultQueue()
 		si := source.Info{
 			Source:       p.r.LoadSource(api.Type(sourceConfig.Type), sourceConfig.Name),
 			Queue:        q,
-			Interceptors: interceptors,
+			Interceptors: p.sourceInterceptors(),
 		}
-		p.ns[sourceConfig.Name] = si.Source
+		// the chain could be replaced when interceptors reloaded, without restarting the source
+		sourceInvokerChain := &atomic.Value{}
+		sourceInvokerChain.Store(sourceInvokerHolder{
+			invoker: buildSourceInvokerChain(sourceConfig.Name, &source.PublishInvoker{}, si.Interceptors),
+		})
 
-		sourceInvokerChain := buildSourceInvokerChain(sourceConfig.Name, &source.PublishInvoker{}, si.Interceptors)
+		p.nsLock.Lock()
+		p.ns[sourceConfig.Name] = si.Source
+		p.sourceChains[sourceConfig.Name] = sourceInvokerChain
+		p.nsLock.Unlock()
 		productFunc := func(e api.Event) api.Result {
 			p.fillEventMetaAndHeader(e, sourceConfig)
 
-			result := sourceInvokerChain.Invoke(source.Invocation{
+			result := sourceInvokerChain.Load().(sourceInvokerHolder).invoker.Invoke(source.Invocation{
 				Event: e,
 				Queue: q,
 			})
@@ -507,6 +573,18 @@ func (p *Pipeline) startSourceProduct(sourceConfigs []source.Config) {
 	//go p.sourceInvokeLoop(si)
 }
 
+func (p *Pipeline) sourceInterceptors() []source.Interceptor {
+	interceptors := make([]source.Interceptor, 0)
+	for _, inter := range p.r.LoadInterceptors() {
+		i, ok := inter.(source.Interceptor)
+		if !ok {
+			continue
+		}
+		interceptors = append(interceptors, i)
+	}
+	return interceptors
+}
+
 func (p *Pipeline) fillEventMetaAndHeader(e api.Event, config source.Config) {
 	// add meta fields
 	e.Meta().Set(event.SystemProductTimeKey, time.Now())
@@ -640,6 +718,7 @@ func collectComponentDependencyInterceptors(component api.Component) []api.Inter
 }
 
 func (p *Pipeline) survive() {
+	defer p.countDown.Done()
 	for {
 		select {
 		case <-p.done:
@@ -0,0 +1,210 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pipeline
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/context"
+	"github.com/loggie-io/loggie/pkg/core/interceptor"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/core/source"
+	"github.com/loggie-io/loggie/pkg/eventbus"
+	"github.com/loggie-io/loggie/pkg/util"
+)
+
+// Reload applies the changes of interceptors, sink and sources to the running pipeline, and returns the components
+// reloaded. The queue and the events in it are kept, so the queue config must be unchanged. An error is returned when
+// the changes cannot be applied in place, the pipeline should be restarted entirely then.
+func (p *Pipeline) Reload(newConfig Config) ([]eventbus.ComponentBaseConfig, error) {
+	if !reflect.DeepEqual(p.config.Queue, newConfig.Queue) {
+		return nil, errors.New("queue changed")
+	}
+
+	oldConfig := p.config
+	reloaded := make([]eventbus.ComponentBaseConfig, 0)
+	var staleInterceptors []api.Component
+
+	sinkChanged := !reflect.DeepEqual(oldConfig.Sink, newConfig.Sink)
+	interceptorsChanged := !reflect.DeepEqual(oldConfig.Interceptors, newConfig.Interceptors)
+	if sinkChanged || interceptorsChanged {
+		// batches not consumed are kept in the queue while sink consumers stopped
+		p.stopSinkConsumer()
+
+		if interceptorsChanged {
+			var changed []eventbus.ComponentBaseConfig
+			staleInterceptors, changed = p.reloadInterceptors(oldConfig.Interceptors, newConfig.Interceptors)
+			reloaded = append(reloaded, changed...)
+		}
+		if sinkChanged {
+			p.reloadSink(oldConfig, newConfig)
+			reloaded = append(reloaded, componentConfig(api.SINK, newConfig.Sink.ComponentBaseConfig.Name, newConfig.Sink.Type))
+		}
+
+		p.done = make(chan struct{})
+		p.retryOutFuncs = nil
+		p.info.SinkCount = newConfig.Sink.Parallelism
+		// more events are held by the sink consumers added
+		p.info.EventPool.Grow(newConfig.Queue.BatchSize * (p.info.SinkCount + 1))
+		p.startSinkConsumer(newConfig.Sink)
+		p.countDown.Add(1)
+		go p.survive()
+	}
+
+	p.config = newConfig
+	changed, err := p.reloadSources(oldConfig.Sources, newConfig.Sources, interceptorsChanged)
+	reloaded = append(reloaded, changed...)
+
+	// stop interceptors replaced after source invoker chains rebuilt
+	for _, c := range staleInterceptors {
+		p.stopComponent(c)
+	}
+
+	if err != nil {
+		return reloaded, err
+	}
+	log.Info("pipeline %s reloaded %d components with epoch: %+v", p.name, len(reloaded), p.epoch)
+	return reloaded, nil
+}
+
+// reloadInterceptors starts the interceptors added or changed, and returns the interceptors removed or changed, which
+// should be stopped when they are no longer referenced
+func (p *Pipeline) reloadInterceptors(oldConfigs, newConfigs []interceptor.Config) ([]api.Component, []eventbus.ComponentBaseConfig) {
+	oldIndex := make(map[string]interceptor.Config)
+	for _, c := range oldConfigs {
+		oldIndex[code(api.INTERCEPTOR, api.Type(c.Type), c.Name)] = c
+	}
+
+	stale := make([]api.Component, 0)
+	reloaded := make([]eventbus.ComponentBaseConfig, 0)
+	for _, newConfig := range newConfigs {
+		key := code(api.INTERCEPTOR, api.Type(newConfig.Type), newConfig.Name)
+		oldConfig, ok := oldIndex[key]
+		if ok {
+			delete(oldIndex, key)
+			if reflect.DeepEqual(oldConfig, newConfig) {
+				continue
+			}
+			stale = append(stale, p.removeComponent(api.INTERCEPTOR, api.Type(oldConfig.Type), oldConfig.Name))
+		}
+
+		ctx := context.NewContext(newConfig.Name, api.Type(newConfig.Type), api.INTERCEPTOR, newConfig.Properties)
+		p.startComponent(ctx)
+		reloaded = append(reloaded, componentConfig(api.INTERCEPTOR, newConfig.Name, newConfig.Type))
+	}
+
+	for _, oldConfig := range oldIndex {
+		stale = append(stale, p.removeComponent(api.INTERCEPTOR, api.Type(oldConfig.Type), oldConfig.Name))
+		reloaded = append(reloaded, componentConfig(api.INTERCEPTOR, oldConfig.Name, oldConfig.Type))
+	}
+	return stale, reloaded
+}
+
+func (p *Pipeline) reloadSink(oldConfig, newConfig Config) {
+	old := p.removeComponent(api.SINK, api.Type(oldConfig.Sink.Type), oldConfig.Sink.Name)
+	p.stopComponent(old)
+	p.startSink(newConfig.Sink)
+}
+
+// reloadSources restarts the sources changed, and rebuilds the invoker chains of the others when interceptors changed
+func (p *Pipeline) reloadSources(oldConfigs, newConfigs []source.Config, interceptorsChanged bool) ([]eventbus.ComponentBaseConfig, error) {
+	oldIndex := make(map[string]source.Config)
+	for _, c := range oldConfigs {
+		oldIndex[c.Name] = c
+	}
+
+	reloaded := make([]eventbus.ComponentBaseConfig, 0)
+	starts := make([]source.Config, 0)
+	for _, newConfig := range newConfigs {
+		oldConfig, ok := oldIndex[newConfig.Name]
+		if ok {
+			delete(oldIndex, newConfig.Name)
+			if reflect.DeepEqual(oldConfig, newConfig) {
+				if interceptorsChanged {
+					p.nsLock.RLock()
+					chain, ok := p.sourceChains[newConfig.Name]
+					p.nsLock.RUnlock()
+					if ok {
+						chain.Store(sourceInvokerHolder{
+							invoker: buildSourceInvokerChain(newConfig.Name, &source.PublishInvoker{}, p.sourceInterceptors()),
+						})
+					}
+				}
+				continue
+			}
+			p.stopSource(oldConfig)
+		}
+		starts = append(starts, newConfig)
+		reloaded = append(reloaded, componentConfig(api.SOURCE, newConfig.Name, newConfig.Type))
+	}
+
+	for _, oldConfig := range oldIndex {
+		p.stopSource(oldConfig)
+		reloaded = append(reloaded, componentConfig(api.SOURCE, oldConfig.Name, oldConfig.Type))
+	}
+
+	if len(starts) == 0 {
+		return reloaded, nil
+	}
+	p.startSource(starts)
+	// the listeners of queue are loaded when queue created, so sources depending on new listeners(eg. ack of file
+	// source) would not work properly without restarting the queue
+	for _, l := range p.r.LoadQueueListeners() {
+		if _, ok := p.queueListeners[l.Name()]; !ok {
+			return reloaded, errors.Errorf("queue listener %s added by sources", l.Name())
+		}
+	}
+	p.startSourceProduct(starts)
+	return reloaded, nil
+}
+
+func (p *Pipeline) stopSource(config source.Config) {
+	p.nsLock.Lock()
+	s, ok := p.ns[config.Name]
+	delete(p.ns, config.Name)
+	delete(p.sourceChains, config.Name)
+	p.nsLock.Unlock()
+	if !ok {
+		return
+	}
+
+	s.Stop()
+	p.r.removeComponent(s.Type(), s.Category(), config.Name)
+	p.reportMetric(config.Name, s, eventbus.ComponentStop)
+}
+
+func (p *Pipeline) removeComponent(category api.Category, typename api.Type, name string) api.Component {
+	c := p.r.LoadWithType(typename, name, category)
+	p.r.removeComponent(typename, category, name)
+	p.reportMetric(name, c, eventbus.ComponentStop)
+	return c
+}
+
+func (p *Pipeline) stopComponent(c api.Component) {
+	util.AsyncRunWithTimeout(c.Stop, p.config.CleanDataTimeout)
+}
+
+func componentConfig(category api.Category, name string, typename string) eventbus.ComponentBaseConfig {
+	return eventbus.ComponentBaseConfig{
+		Name:     name,
+		Type:     api.Type(typename),
+		Category: category,
+	}
+}
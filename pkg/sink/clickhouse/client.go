@@ -0,0 +1,143 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clickhouse
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const exceptionCodeHeader = "X-ClickHouse-Exception-Code"
+
+// error codes of ClickHouse, see https://github.com/ClickHouse/ClickHouse/blob/master/src/Common/ErrorCodes.cpp
+const (
+	codeCannotParseText           = 6
+	codeNoSuchColumnInTable       = 16
+	codeCannotParseQuotedString   = 26
+	codeCannotParseInputAssertion = 27
+	codeCannotParseDate           = 38
+	codeCannotParseDateTime       = 41
+	codeTypeMismatch              = 53
+	codeUnknownTable              = 60
+	codeCannotConvertType         = 70
+	codeCannotParseNumber         = 72
+	codeIncorrectData             = 117
+	codeCannotInsertNullInColumn  = 349
+	codeCannotParseBool           = 467
+)
+
+// ServerError is the exception returned by ClickHouse server
+type ServerError struct {
+	Code    int
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("clickhouse exception code %d: %s", e.Code, e.Message)
+}
+
+// RowMismatch reports whether some rows in the request do not match the table schema
+func (e *ServerError) RowMismatch() bool {
+	switch e.Code {
+	case codeCannotParseText, codeCannotParseQuotedString, codeCannotParseInputAssertion, codeCannotParseDate,
+		codeCannotParseDateTime, codeTypeMismatch, codeCannotConvertType, codeCannotParseNumber, codeIncorrectData,
+		codeCannotInsertNullInColumn, codeCannotParseBool:
+		return true
+	}
+	return false
+}
+
+// TableMismatch reports whether none of the rows could be inserted into the table
+func (e *ServerError) TableMismatch() bool {
+	return e.Code == codeUnknownTable || e.Code == codeNoSuchColumnInTable
+}
+
+type Client struct {
+	config *Config
+	hc     *http.Client
+}
+
+func NewClient(config *Config) *Client {
+	return &Client{
+		config: config,
+		hc: &http.Client{
+			Timeout: config.Timeout,
+		},
+	}
+}
+
+// Insert writes the rows in JSONEachRow format with one request
+func (c *Client) Insert(table string, columns []string, rows [][]byte) error {
+	quoted := make([]string, 0, len(columns))
+	for _, col := range columns {
+		quoted = append(quoted, quoteIdentifier(col))
+	}
+	query := fmt.Sprintf("INSERT INTO %s.%s (%s) FORMAT JSONEachRow",
+		quoteIdentifier(c.config.Database), quoteIdentifier(table), strings.Join(quoted, ", "))
+
+	endpoint := c.config.Endpoint
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = fmt.Sprintf("http://%s", endpoint)
+	}
+	u := strings.TrimSuffix(endpoint, "/") + "/?" + url.Values{
+		"query": []string{query},
+		"input_format_defaults_for_omitted_fields": []string{"1"},
+	}.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(bytes.Join(rows, []byte("\n"))))
+	if err != nil {
+		return err
+	}
+	if c.config.UserName != "" {
+		req.Header.Set("X-ClickHouse-User", c.config.UserName)
+		req.Header.Set("X-ClickHouse-Key", c.config.Password)
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	code, convErr := strconv.Atoi(resp.Header.Get(exceptionCodeHeader))
+	if convErr != nil {
+		return errors.Errorf("insert into clickhouse table %s response status %d: %s", table, resp.StatusCode, body)
+	}
+	return &ServerError{
+		Code:    code,
+		Message: strings.TrimSpace(string(body)),
+	}
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "\\`") + "`"
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clickhouse
+
+import (
+	"fmt"
+	"time"
+)
+
+type Config struct {
+	Endpoint string        `yaml:"endpoint,omitempty" validate:"required"`
+	Database string        `yaml:"database,omitempty" default:"default"`
+	Table    string        `yaml:"table,omitempty" validate:"required"`
+	UserName string        `yaml:"username,omitempty"`
+	Password string        `yaml:"password,omitempty"`
+	Timeout  time.Duration `yaml:"timeout,omitempty" default:"30s"`
+	Columns  []Column      `yaml:"columns,omitempty" validate:"required,dive"`
+	// RejectTable receives the rows rejected because of schema mismatch, they are dropped with a warning log when not set
+	RejectTable string `yaml:"rejectTable,omitempty"`
+}
+
+// Column maps a field in the event header to a table column, the value would be converted to the column type
+type Column struct {
+	Name  string `yaml:"name,omitempty" validate:"required"`
+	Field string `yaml:"field,omitempty"`
+	Type  string `yaml:"type,omitempty" default:"String"`
+}
+
+func (c *Config) Validate() error {
+	names := make(map[string]struct{})
+	for _, col := range c.Columns {
+		if _, ok := names[col.Name]; ok {
+			return fmt.Errorf("clickhouse sink column %s is duplicated", col.Name)
+		}
+		names[col.Name] = struct{}{}
+
+		if _, err := newConverter(col.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Column) field() string {
+	if c.Field == "" {
+		return c.Name
+	}
+	return c.Field
+}
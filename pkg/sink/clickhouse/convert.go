@@ -0,0 +1,356 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clickhouse
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04:05"
+)
+
+// converter converts the value in event header to the value accepted by the column type in JSONEachRow format
+type converter struct {
+	nullable bool
+	convert  func(v interface{}) (interface{}, error)
+}
+
+func newConverter(typ string) (*converter, error) {
+	t := strings.TrimSpace(typ)
+
+	if inner, ok := unwrapType(t, "Nullable"); ok {
+		c, err := newConverter(inner)
+		if err != nil {
+			return nil, err
+		}
+		c.nullable = true
+		return c, nil
+	}
+	if inner, ok := unwrapType(t, "LowCardinality"); ok {
+		return newConverter(inner)
+	}
+	if inner, ok := unwrapType(t, "Array"); ok {
+		elem, err := newConverter(inner)
+		if err != nil {
+			return nil, err
+		}
+		return &converter{convert: arrayOf(elem)}, nil
+	}
+
+	switch {
+	case t == "String", t == "UUID", t == "IPv4", t == "IPv6",
+		strings.HasPrefix(t, "FixedString("), strings.HasPrefix(t, "Enum8("), strings.HasPrefix(t, "Enum16("):
+		return &converter{convert: toString}, nil
+
+	case strings.HasPrefix(t, "UInt"):
+		bits, err := strconv.Atoi(strings.TrimPrefix(t, "UInt"))
+		if err != nil || bits > 64 {
+			return nil, errors.Errorf("clickhouse column type %s is not supported", typ)
+		}
+		return &converter{convert: toUint(bits)}, nil
+
+	case strings.HasPrefix(t, "Int"):
+		bits, err := strconv.Atoi(strings.TrimPrefix(t, "Int"))
+		if err != nil || bits > 64 {
+			return nil, errors.Errorf("clickhouse column type %s is not supported", typ)
+		}
+		return &converter{convert: toInt(bits)}, nil
+
+	case t == "Float32", t == "Float64", strings.HasPrefix(t, "Decimal"):
+		return &converter{convert: toFloat}, nil
+
+	case t == "Bool", t == "Boolean":
+		return &converter{convert: toBool}, nil
+
+	case t == "Date", t == "Date32":
+		return &converter{convert: toDate}, nil
+
+	case strings.HasPrefix(t, "DateTime64"):
+		precision := 3
+		if args, ok := unwrapType(t, "DateTime64"); ok {
+			p, err := strconv.Atoi(strings.TrimSpace(strings.Split(args, ",")[0]))
+			if err != nil || p < 0 || p > 9 {
+				return nil, errors.Errorf("clickhouse column type %s is not supported", typ)
+			}
+			precision = p
+		}
+		return &converter{convert: toDateTime64(precision)}, nil
+
+	case strings.HasPrefix(t, "DateTime"):
+		return &converter{convert: toDateTime}, nil
+	}
+
+	return nil, errors.Errorf("clickhouse column type %s is not supported", typ)
+}
+
+// unwrapType returns the arguments of a type like Nullable(String)
+func unwrapType(t string, wrapper string) (string, bool) {
+	if strings.HasPrefix(t, wrapper+"(") && strings.HasSuffix(t, ")") {
+		return t[len(wrapper)+1 : len(t)-1], true
+	}
+	return "", false
+}
+
+func arrayOf(elem *converter) func(v interface{}) (interface{}, error) {
+	return func(v interface{}) (interface{}, error) {
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, errors.Errorf("%v(%T) is not an array", v, v)
+		}
+		out := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			item := rv.Index(i).Interface()
+			if item == nil {
+				if !elem.nullable {
+					return nil, errors.Errorf("array element %d is null", i)
+				}
+				out = append(out, nil)
+				continue
+			}
+			c, err := elem.convert(item)
+			if err != nil {
+				return nil, errors.WithMessagef(err, "array element %d", i)
+			}
+			out = append(out, c)
+		}
+		return out, nil
+	}
+}
+
+func toString(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case []byte:
+		return string(val), nil
+	case time.Time:
+		return val.Format(time.RFC3339Nano), nil
+	case fmt.Stringer:
+		return val.String(), nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, json.Number:
+		return fmt.Sprint(val), nil
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "marshal %T to string", v)
+	}
+	return string(out), nil
+}
+
+// toNumber returns the value as int64 if it is integral, otherwise float64
+func toNumber(v interface{}) (int64, float64, bool, error) {
+	switch val := v.(type) {
+	case int:
+		return int64(val), 0, true, nil
+	case int8:
+		return int64(val), 0, true, nil
+	case int16:
+		return int64(val), 0, true, nil
+	case int32:
+		return int64(val), 0, true, nil
+	case int64:
+		return val, 0, true, nil
+	case uint:
+		return int64(val), 0, true, nil
+	case uint8:
+		return int64(val), 0, true, nil
+	case uint16:
+		return int64(val), 0, true, nil
+	case uint32:
+		return int64(val), 0, true, nil
+	case uint64:
+		if val > math.MaxInt64 {
+			return 0, float64(val), false, nil
+		}
+		return int64(val), 0, true, nil
+	case float32:
+		return 0, float64(val), false, nil
+	case float64:
+		return 0, val, false, nil
+	case bool:
+		if val {
+			return 1, 0, true, nil
+		}
+		return 0, 0, true, nil
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i, 0, true, nil
+		}
+		f, err := val.Float64()
+		return 0, f, false, err
+	case string:
+		s := strings.TrimSpace(val)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, 0, true, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, 0, false, errors.Errorf("%q is not a number", val)
+		}
+		return 0, f, false, nil
+	}
+	return 0, 0, false, errors.Errorf("%v(%T) is not a number", v, v)
+}
+
+func toInt(bits int) func(v interface{}) (interface{}, error) {
+	max := int64(math.MaxInt64)
+	if bits < 64 {
+		max = int64(1)<<(uint(bits)-1) - 1
+	}
+	min := -max - 1
+
+	return func(v interface{}) (interface{}, error) {
+		i, f, integral, err := toNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		if !integral {
+			if f != math.Trunc(f) || f > float64(max) || f < float64(min) {
+				return nil, errors.Errorf("%v is not a valid Int%d", v, bits)
+			}
+			i = int64(f)
+		}
+		if i > max || i < min {
+			return nil, errors.Errorf("%v is out of Int%d range", v, bits)
+		}
+		return i, nil
+	}
+}
+
+func toUint(bits int) func(v interface{}) (interface{}, error) {
+	return func(v interface{}) (interface{}, error) {
+		if u, ok := v.(uint64); ok {
+			if bits < 64 && u >= uint64(1)<<uint(bits) {
+				return nil, errors.Errorf("%v is out of UInt%d range", v, bits)
+			}
+			return u, nil
+		}
+
+		i, f, integral, err := toNumber(v)
+		if err != nil {
+			return nil, err
+		}
+		if !integral {
+			if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+				return nil, errors.Errorf("%v is not a valid UInt%d", v, bits)
+			}
+			i = int64(f)
+		}
+		if i < 0 || (bits < 64 && uint64(i) >= uint64(1)<<uint(bits)) {
+			return nil, errors.Errorf("%v is out of UInt%d range", v, bits)
+		}
+		return uint64(i), nil
+	}
+}
+
+func toFloat(v interface{}) (interface{}, error) {
+	i, f, integral, err := toNumber(v)
+	if err != nil {
+		return nil, err
+	}
+	if integral {
+		return float64(i), nil
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, errors.Errorf("%v could not be encoded in json", v)
+	}
+	return f, nil
+}
+
+func toBool(v interface{}) (interface{}, error) {
+	if s, ok := v.(string); ok {
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return nil, errors.Errorf("%q is not a bool", s)
+		}
+		return b, nil
+	}
+	i, f, integral, err := toNumber(v)
+	if err != nil {
+		return nil, err
+	}
+	if integral {
+		return i != 0, nil
+	}
+	return f != 0, nil
+}
+
+// toTime accepts time.Time, unix timestamp in seconds and strings in RFC3339 or ClickHouse layout
+func toTime(v interface{}) (time.Time, error) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, nil
+	case string:
+		s := strings.TrimSpace(val)
+		for _, layout := range []string{time.RFC3339Nano, dateTimeLayout, "2006-01-02 15:04:05.999999999", dateLayout} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+	}
+
+	i, f, integral, err := toNumber(v)
+	if err != nil {
+		return time.Time{}, errors.Errorf("%v(%T) is not a valid time", v, v)
+	}
+	if integral {
+		return time.Unix(i, 0), nil
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), nil
+}
+
+func toDate(v interface{}) (interface{}, error) {
+	t, err := toTime(v)
+	if err != nil {
+		return nil, err
+	}
+	return t.UTC().Format(dateLayout), nil
+}
+
+func toDateTime(v interface{}) (interface{}, error) {
+	t, err := toTime(v)
+	if err != nil {
+		return nil, err
+	}
+	return t.Unix(), nil
+}
+
+func toDateTime64(precision int) func(v interface{}) (interface{}, error) {
+	return func(v interface{}) (interface{}, error) {
+		t, err := toTime(v)
+		if err != nil {
+			return nil, err
+		}
+		if precision == 0 {
+			return strconv.FormatInt(t.Unix(), 10), nil
+		}
+		frac := fmt.Sprintf("%09d", t.Nanosecond())[:precision]
+		return fmt.Sprintf("%d.%s", t.Unix(), frac), nil
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clickhouse
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConverter(t *testing.T) {
+	ts := time.Date(2021, 7, 4, 10, 20, 30, 123456789, time.UTC)
+	tests := []struct {
+		name    string
+		typ     string
+		value   interface{}
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "string", typ: "String", value: "a", want: "a"},
+		{name: "numberToString", typ: "LowCardinality(String)", value: 12, want: "12"},
+		{name: "mapToString", typ: "String", value: map[string]interface{}{"a": "b"}, want: `{"a":"b"}`},
+		{name: "stringToInt", typ: "Int32", value: "42", want: int64(42)},
+		{name: "floatToInt", typ: "Int64", value: float64(3), want: int64(3)},
+		{name: "fractionToInt", typ: "Int64", value: 3.5, wantErr: true},
+		{name: "intOverflow", typ: "Int8", value: 128, wantErr: true},
+		{name: "negativeUint", typ: "UInt16", value: -1, wantErr: true},
+		{name: "uint", typ: "UInt8", value: "255", want: uint64(255)},
+		{name: "float", typ: "Float64", value: "1.5", want: 1.5},
+		{name: "notNumber", typ: "Float32", value: "abc", wantErr: true},
+		{name: "bool", typ: "Bool", value: "true", want: true},
+		{name: "dateTime", typ: "DateTime", value: ts, want: ts.Unix()},
+		{name: "dateTimeString", typ: "DateTime('UTC')", value: "2021-07-04T10:20:30Z", want: ts.Unix()},
+		{name: "dateTime64", typ: "DateTime64(3, 'UTC')", value: ts, want: "1625394030.123"},
+		{name: "date", typ: "Date", value: ts, want: "2021-07-04"},
+		{name: "array", typ: "Array(Int64)", value: []interface{}{1, "2"}, want: []interface{}{int64(1), int64(2)}},
+		{name: "arrayElementMismatch", typ: "Array(Int64)", value: []interface{}{"a"}, wantErr: true},
+		{name: "notArray", typ: "Array(String)", value: "a", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newConverter(tt.typ)
+			if err != nil {
+				t.Fatalf("newConverter(%s) error: %v", tt.typ, err)
+			}
+			got, err := c.convert(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("convert() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convert() got = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewConverter(t *testing.T) {
+	c, err := newConverter("Nullable(Int64)")
+	if err != nil || !c.nullable {
+		t.Errorf("Nullable(Int64) should be nullable, err: %v", err)
+	}
+	if _, err := newConverter("Map(String, String)"); err == nil {
+		t.Errorf("Map(String, String) should not be supported")
+	}
+}
@@ -0,0 +1,353 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clickhouse
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/core/result"
+	"github.com/loggie-io/loggie/pkg/eventbus"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/util"
+	"github.com/loggie-io/loggie/pkg/util/runtime"
+)
+
+const Type = "clickhouse"
+
+var rejectColumns = []string{"time", "table", "reason", "row"}
+
+// progressKey is the batch meta key of the insert progress, which is kept while the batch is retried
+const progressKey = "clickhouse.progress"
+
+func init() {
+	pipeline.Register(api.SINK, Type, makeSink)
+}
+
+func makeSink(info pipeline.Info) api.Component {
+	return NewSink()
+}
+
+type column struct {
+	name  string
+	paths []string
+	conv  *converter
+}
+
+type row struct {
+	e    api.Event
+	data []byte
+}
+
+type tableRows struct {
+	rows []row
+	// rejected are the rows failed to be built, they are reported after the table is inserted
+	rejected []rejectedRow
+}
+
+// batchProgress records the tables and rows of a batch already inserted, so that the batch retried after a partial
+// failure only inserts the rest of them, otherwise the inserted rows would be duplicated
+type batchProgress struct {
+	tables map[string]struct{}
+	// rows is the count of the leading rows handled one by one before the table failed
+	rows map[string]int
+}
+
+type rejectedRow struct {
+	e      api.Event
+	table  string
+	reason string
+	data   []byte
+}
+
+type Sink struct {
+	name   string
+	config *Config
+	cli    *Client
+
+	columns      []column
+	columnNames  []string
+	tableMatcher [][]string
+}
+
+func NewSink() *Sink {
+	return &Sink{
+		config: &Config{},
+	}
+}
+
+func (s *Sink) Config() interface{} {
+	return s.config
+}
+
+func (s *Sink) Category() api.Category {
+	return api.SINK
+}
+
+func (s *Sink) Type() api.Type {
+	return Type
+}
+
+func (s *Sink) String() string {
+	return fmt.Sprintf("%s/%s", api.SINK, Type)
+}
+
+func (s *Sink) Init(context api.Context) {
+	s.name = context.Name()
+	s.tableMatcher = util.InitMatcher(s.config.Table)
+
+	s.columns = make([]column, 0, len(s.config.Columns))
+	s.columnNames = make([]string, 0, len(s.config.Columns))
+	for _, c := range s.config.Columns {
+		conv, err := newConverter(c.Type)
+		if err != nil {
+			log.Error("init clickhouse column %s error: %v", c.Name, err)
+			continue
+		}
+		s.columns = append(s.columns, column{
+			name:  c.Name,
+			paths: runtime.GetQueryPaths(c.field()),
+			conv:  conv,
+		})
+		s.columnNames = append(s.columnNames, c.Name)
+	}
+}
+
+func (s *Sink) Start() {
+	s.cli = NewClient(s.config)
+	log.Info("%s start, endpoint: %s, table: %s.%s", s.String(), s.config.Endpoint, s.config.Database, s.config.Table)
+}
+
+func (s *Sink) Stop() {
+}
+
+// Consume inserts the rows of each table in one request. Rows which do not match the table schema are rejected
+// and reported separately, only the errors unrelated to the rows fail the batch. The tables inserted before the
+// failure are skipped when the batch is retried.
+func (s *Sink) Consume(batch api.Batch) api.Result {
+	events := batch.Events()
+	if len(events) == 0 {
+		return result.Success()
+	}
+	progress := progressOf(batch)
+
+	tables := make(map[string]*tableRows)
+	for _, e := range events {
+		table, err := runtime.PatternSelect(runtime.NewObject(e.Header()), s.config.Table, s.tableMatcher)
+		if err != nil {
+			log.Error("select clickhouse table error: %+v", err)
+			return result.Fail(err)
+		}
+		if _, ok := progress.tables[table]; ok {
+			continue
+		}
+
+		t, ok := tables[table]
+		if !ok {
+			t = &tableRows{}
+			tables[table] = t
+		}
+		data, err := s.buildRow(e)
+		if err != nil {
+			t.rejected = append(t.rejected, rejectedRow{e: e, table: table, reason: err.Error()})
+			continue
+		}
+		t.rows = append(t.rows, row{e: e, data: data})
+	}
+
+	var rejected []rejectedRow
+	for table, t := range tables {
+		if len(t.rows) > 0 {
+			r, err := s.insert(table, t.rows, progress)
+			rejected = append(rejected, r...)
+			if err != nil {
+				log.Error("insert into clickhouse table %s error: %v", table, err)
+				if len(rejected) > 0 {
+					s.reportRejected(rejected)
+				}
+				return result.Fail(err)
+			}
+		}
+		progress.tables[table] = struct{}{}
+		rejected = append(rejected, t.rejected...)
+	}
+
+	if len(rejected) > 0 {
+		s.reportRejected(rejected)
+	}
+	return result.Success()
+}
+
+func progressOf(batch api.Batch) *batchProgress {
+	meta := batch.Meta()
+	if p, ok := meta[progressKey].(*batchProgress); ok {
+		return p
+	}
+	p := &batchProgress{
+		tables: make(map[string]struct{}),
+		rows:   make(map[string]int),
+	}
+	if meta != nil {
+		meta[progressKey] = p
+	}
+	return p
+}
+
+func (s *Sink) buildRow(e api.Event) ([]byte, error) {
+	obj := runtime.NewObject(e.Header())
+	values := make(map[string]interface{}, len(s.columns))
+	for _, c := range s.columns {
+		var val interface{}
+		if len(c.paths) == 1 && c.paths[0] == event.Body {
+			val = string(e.Body())
+		} else {
+			val = obj.GetPaths(c.paths).Value()
+		}
+
+		if val == nil {
+			if c.conv.nullable {
+				values[c.name] = nil
+			}
+			// omitted fields are filled with the column default
+			continue
+		}
+
+		converted, err := c.conv.convert(val)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "convert column %s", c.name)
+		}
+		values[c.name] = converted
+	}
+	return json.Marshal(values)
+}
+
+// insert returns the rows rejected by the server, it falls back to insert row by row to find them
+// when the server refuses the whole request because of some of the rows. The rows rejected before an error
+// are returned along with it, and the rows handled one by one are skipped by the next insert of the table.
+func (s *Sink) insert(table string, rows []row, progress *batchProgress) ([]rejectedRow, error) {
+	if offset := progress.rows[table]; offset > 0 {
+		return s.insertOneByOne(table, rows, offset, progress)
+	}
+
+	data := make([][]byte, 0, len(rows))
+	for _, r := range rows {
+		data = append(data, r.data)
+	}
+
+	err := s.cli.Insert(table, s.columnNames, data)
+	if err == nil {
+		return nil, nil
+	}
+	serverErr, ok := err.(*ServerError)
+	if !ok {
+		return nil, err
+	}
+
+	var rejected []rejectedRow
+	if serverErr.TableMismatch() {
+		for _, r := range rows {
+			rejected = append(rejected, rejectedRow{e: r.e, table: table, reason: serverErr.Error(), data: r.data})
+		}
+		return rejected, nil
+	}
+	if !serverErr.RowMismatch() {
+		return nil, err
+	}
+
+	log.Warn("clickhouse table %s rejected the batch: %v, insert row by row", table, serverErr)
+	return s.insertOneByOne(table, rows, 0, progress)
+}
+
+func (s *Sink) insertOneByOne(table string, rows []row, offset int, progress *batchProgress) ([]rejectedRow, error) {
+	var rejected []rejectedRow
+	for i := offset; i < len(rows); i++ {
+		r := rows[i]
+		err := s.cli.Insert(table, s.columnNames, [][]byte{r.data})
+		if err == nil {
+			continue
+		}
+		if rowErr, ok := err.(*ServerError); ok && (rowErr.RowMismatch() || rowErr.TableMismatch()) {
+			rejected = append(rejected, rejectedRow{e: r.e, table: table, reason: rowErr.Error(), data: r.data})
+			continue
+		}
+		progress.rows[table] = i
+		return rejected, err
+	}
+	return rejected, nil
+}
+
+// reportRejected writes the rejected rows to the reject table and reports them as failed events of the sink metric,
+// the batch is not failed when reporting failed, otherwise the inserted rows would be duplicated by retry.
+func (s *Sink) reportRejected(rejected []rejectedRow) {
+	failed := make(map[eventbus.BaseMetric]int)
+	rows := make([][]byte, 0, len(rejected))
+	now := time.Now().Unix()
+	for _, r := range rejected {
+		raw := r.data
+		if raw == nil {
+			raw, _ = json.Marshal(r.e.Header())
+		}
+		log.Warn("clickhouse table %s rejected row %s: %s", r.table, raw, r.reason)
+
+		if s.config.RejectTable != "" {
+			out, err := json.Marshal(map[string]interface{}{
+				"time":   now,
+				"table":  r.table,
+				"reason": r.reason,
+				"row":    string(raw),
+			})
+			if err == nil {
+				rows = append(rows, out)
+			}
+		}
+
+		failed[metricOf(r.e)]++
+	}
+
+	if len(rows) > 0 {
+		if err := s.cli.Insert(s.config.RejectTable, rejectColumns, rows); err != nil {
+			log.Error("insert rejected rows into clickhouse table %s error: %v", s.config.RejectTable, err)
+		}
+	}
+
+	for m, count := range failed {
+		eventbus.PublishOrDrop(eventbus.SinkMetricTopic, eventbus.SinkMetricData{
+			BaseMetric:     m,
+			FailEventCount: count,
+		})
+	}
+}
+
+func metricOf(e api.Event) eventbus.BaseMetric {
+	m := eventbus.BaseMetric{}
+	if e.Meta() == nil {
+		return m
+	}
+	if name, ok := e.Meta().Get(event.SystemPipelineKey); ok {
+		m.PipelineName, _ = name.(string)
+	}
+	if name, ok := e.Meta().Get(event.SystemSourceKey); ok {
+		m.SourceName, _ = name.(string)
+	}
+	return m
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clickhouse
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/batch"
+	"github.com/loggie-io/loggie/pkg/core/context"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+)
+
+// fakeClickHouse refuses the whole request if any row has a status over 999, like a UInt16 column overflow would do,
+// and fails the requests of the tables in unavailable until their counts are used up
+type fakeClickHouse struct {
+	lock        sync.Mutex
+	tables      map[string][]map[string]interface{}
+	unavailable map[string]int
+}
+
+func (f *fakeClickHouse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	query := r.URL.Query().Get("query")
+	table := strings.Split(strings.TrimPrefix(query, "INSERT INTO `default`.`"), "`")[0]
+	if table == "missing" {
+		w.Header().Set(exceptionCodeHeader, "60")
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "Code: 60. DB::Exception: Table default.missing doesn't exist")
+		return
+	}
+	if f.unavailable[table] > 0 {
+		f.unavailable[table]--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	var rows []map[string]interface{}
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+		row := make(map[string]interface{})
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if status, ok := row["status"].(float64); ok && status > 999 {
+			w.Header().Set(exceptionCodeHeader, "27")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, "Code: 27. DB::Exception: Cannot parse input")
+			return
+		}
+		rows = append(rows, row)
+	}
+	f.tables[table] = append(f.tables[table], rows...)
+}
+
+func TestSink_ConsumeRejectRows(t *testing.T) {
+	log.InitDefaultLogger()
+	fake := &fakeClickHouse{tables: make(map[string][]map[string]interface{})}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s := NewSink()
+	s.config = &Config{
+		Endpoint:    server.URL,
+		Database:    "default",
+		Table:       "${table}",
+		Timeout:     5 * time.Second,
+		RejectTable: "rejected",
+		Columns: []Column{
+			{Name: "status", Field: "fields.status", Type: "UInt16"},
+			{Name: "message", Field: "body", Type: "String"},
+		},
+	}
+	s.Init(context.NewContext("test", Type, api.SINK, nil))
+	s.Start()
+
+	newEvent := func(table string, status interface{}, body string) api.Event {
+		e := event.NewEvent(map[string]interface{}{
+			"table": table,
+			"fields": map[string]interface{}{
+				"status": status,
+			},
+		}, []byte(body))
+		e.M = event.NewDefaultMeta()
+		return e
+	}
+	b := batch.NewBatchWithEvents([]api.Event{
+		newEvent("access", 200, "ok"),
+		newEvent("access", "abc", "not a number"),
+		newEvent("access", 1000, "rejected by server"),
+		newEvent("access", "404", "not found"),
+		newEvent("missing", 200, "unknown table"),
+	})
+
+	r := s.Consume(b)
+	if r.Status() != api.SUCCESS {
+		t.Fatalf("consume result status %v, err: %v", r.Status(), r.Error())
+	}
+
+	var messages []string
+	for _, row := range fake.tables["access"] {
+		messages = append(messages, row["message"].(string))
+	}
+	if strings.Join(messages, ",") != "ok,not found" {
+		t.Errorf("inserted rows %v, want ok and not found", messages)
+	}
+	if len(fake.tables["rejected"]) != 3 {
+		t.Errorf("expect 3 rejected rows, got %v", fake.tables["rejected"])
+	}
+}
+
+func TestSink_ConsumeRetryPartialFailure(t *testing.T) {
+	log.InitDefaultLogger()
+	fake := &fakeClickHouse{
+		tables:      make(map[string][]map[string]interface{}),
+		unavailable: map[string]int{"flaky": 1},
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s := NewSink()
+	s.config = &Config{
+		Endpoint: server.URL,
+		Database: "default",
+		Table:    "${table}",
+		Timeout:  5 * time.Second,
+		Columns: []Column{
+			{Name: "message", Field: "body", Type: "String"},
+		},
+	}
+	s.Init(context.NewContext("test", Type, api.SINK, nil))
+	s.Start()
+
+	newEvent := func(table string, body string) api.Event {
+		e := event.NewEvent(map[string]interface{}{"table": table}, []byte(body))
+		e.M = event.NewDefaultMeta()
+		return e
+	}
+	b := batch.NewBatchWithEvents([]api.Event{
+		newEvent("access", "a"),
+		newEvent("access", "b"),
+		newEvent("flaky", "c"),
+	})
+
+	if r := s.Consume(b); r.Status() == api.SUCCESS {
+		t.Fatalf("expect the first consume failed by the unavailable table")
+	}
+	if r := s.Consume(b); r.Status() != api.SUCCESS {
+		t.Fatalf("consume result status %v, err: %v", r.Status(), r.Error())
+	}
+
+	if len(fake.tables["access"]) != 2 {
+		t.Errorf("expect 2 rows inserted into access once, got %v", fake.tables["access"])
+	}
+	if len(fake.tables["flaky"]) != 1 {
+		t.Errorf("expect 1 row inserted into flaky, got %v", fake.tables["flaky"])
+	}
+}
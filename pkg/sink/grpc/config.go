@@ -19,8 +19,21 @@ package grpc
 import "time"
 
 type Config struct {
-	Host          string        `yaml:"host,omitempty" validate:"required"`
+	Host          string        `yaml:"host,omitempty" validate:"required_without=Service"`
 	LoadBalance   string        `yaml:"loadBalance,omitempty" default:"round_robin"`
 	Timeout       time.Duration `yaml:"timeout,omitempty" default:"30s"`
 	GrpcHeaderKey string        `yaml:"grpcHeaderKey,omitempty"`
+	// Version v2 sends structured events and pipelines batches in a bidirectional stream for each sink goroutine,
+	// the grpc source must be upgraded before the sink.
+	Version     string `yaml:"version,omitempty" default:"v1" validate:"oneof=v1 v2"`
+	Compression string `yaml:"compression,omitempty" default:"none" validate:"oneof=none gzip zstd"`
+	// StreamRotateInterval is the max lifetime of a v2 stream, the new streams are balanced to the backends again
+	StreamRotateInterval time.Duration `yaml:"streamRotateInterval,omitempty" default:"1m"`
+
+	// Service is the headless Service of aggregators with port, eg: loggie-aggregator.loggie.svc:6066, which is
+	// resolved every ResolveInterval instead of the static Host
+	Service         string        `yaml:"service,omitempty"`
+	ResolveInterval time.Duration `yaml:"resolveInterval,omitempty" default:"30s"`
+	// HealthCheck skips the aggregators not serving by the grpc health checking protocol
+	HealthCheck bool `yaml:"healthCheck,omitempty" default:"true"`
 }
@@ -0,0 +1,163 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v2
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// NewValues converts a map to values, the value of unsupported type like struct is skipped.
+func NewValues(m map[string]interface{}) map[string]*Value {
+	if len(m) == 0 {
+		return nil
+	}
+	values := make(map[string]*Value, len(m))
+	for k, v := range m {
+		if value := NewValue(v); value != nil {
+			values[k] = value
+		}
+	}
+	return values
+}
+
+// NewValue converts a go value to Value, nil is returned when the type is not supported.
+func NewValue(v interface{}) *Value {
+	switch x := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return &Value{Kind: &Value_StringValue{StringValue: x}}
+	case []byte:
+		return &Value{Kind: &Value_BytesValue{BytesValue: x}}
+	case bool:
+		return &Value{Kind: &Value_BoolValue{BoolValue: x}}
+	case int:
+		return &Value{Kind: &Value_IntValue{IntValue: int64(x)}}
+	case int32:
+		return &Value{Kind: &Value_IntValue{IntValue: int64(x)}}
+	case int64:
+		return &Value{Kind: &Value_IntValue{IntValue: x}}
+	case uint:
+		return &Value{Kind: &Value_UintValue{UintValue: uint64(x)}}
+	case uint32:
+		return &Value{Kind: &Value_UintValue{UintValue: uint64(x)}}
+	case uint64:
+		return &Value{Kind: &Value_UintValue{UintValue: x}}
+	case float32:
+		return &Value{Kind: &Value_DoubleValue{DoubleValue: float64(x)}}
+	case float64:
+		return &Value{Kind: &Value_DoubleValue{DoubleValue: x}}
+	case time.Time:
+		return &Value{Kind: &Value_StringValue{StringValue: x.Format(time.RFC3339Nano)}}
+	case time.Duration:
+		return &Value{Kind: &Value_StringValue{StringValue: x.String()}}
+	case map[string]interface{}:
+		return &Value{Kind: &Value_MapValue{MapValue: &MapValue{Fields: NewValues(x)}}}
+	case []interface{}:
+		list := make([]*Value, 0, len(x))
+		for _, item := range x {
+			if value := NewValue(item); value != nil {
+				list = append(list, value)
+			}
+		}
+		return &Value{Kind: &Value_ListValue{ListValue: &ListValue{Values: list}}}
+	}
+
+	// typed maps and slices, eg: map[string]string, []string
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		fields := make(map[string]*Value, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			if value := NewValue(iter.Value().Interface()); value != nil {
+				fields[iter.Key().String()] = value
+			}
+		}
+		return &Value{Kind: &Value_MapValue{MapValue: &MapValue{Fields: fields}}}
+
+	case reflect.Slice, reflect.Array:
+		list := make([]*Value, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			if value := NewValue(rv.Index(i).Interface()); value != nil {
+				list = append(list, value)
+			}
+		}
+		return &Value{Kind: &Value_ListValue{ListValue: &ListValue{Values: list}}}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Value{Kind: &Value_IntValue{IntValue: rv.Int()}}
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Value{Kind: &Value_UintValue{UintValue: rv.Uint()}}
+
+	case reflect.Float32, reflect.Float64:
+		return &Value{Kind: &Value_DoubleValue{DoubleValue: rv.Float()}}
+
+	case reflect.Bool:
+		return &Value{Kind: &Value_BoolValue{BoolValue: rv.Bool()}}
+
+	case reflect.String:
+		return &Value{Kind: &Value_StringValue{StringValue: rv.String()}}
+	}
+
+	if s, ok := v.(fmt.Stringer); ok {
+		return &Value{Kind: &Value_StringValue{StringValue: s.String()}}
+	}
+	return nil
+}
+
+// ToMap converts values back to a map
+func ToMap(values map[string]*Value) map[string]interface{} {
+	m := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		m[k] = v.Interface()
+	}
+	return m
+}
+
+// Interface returns the go value, maps are map[string]interface{} and lists are []interface{}
+func (x *Value) Interface() interface{} {
+	switch k := x.GetKind().(type) {
+	case *Value_StringValue:
+		return k.StringValue
+	case *Value_IntValue:
+		return k.IntValue
+	case *Value_UintValue:
+		return k.UintValue
+	case *Value_DoubleValue:
+		return k.DoubleValue
+	case *Value_BoolValue:
+		return k.BoolValue
+	case *Value_BytesValue:
+		return k.BytesValue
+	case *Value_MapValue:
+		return ToMap(k.MapValue.GetFields())
+	case *Value_ListValue:
+		list := make([]interface{}, 0, len(k.ListValue.GetValues()))
+		for _, v := range k.ListValue.GetValues() {
+			list = append(list, v.Interface())
+		}
+		return list
+	}
+	return nil
+}
@@ -17,7 +17,14 @@ limitations under the License.
 package grpc
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/loggie-io/loggie/pkg/core/log"
 	"google.golang.org/grpc/resolver"
 )
 
@@ -69,3 +76,106 @@ func (r *collectorResolver) start() {
 }
 func (*collectorResolver) ResolveNow(o resolver.ResolveNowOptions) {}
 func (*collectorResolver) Close()                                  {}
+
+// NewServiceBuilder resolves the pods of a headless Service periodically, eg: loggie-aggregator.loggie.svc:6066.
+// The A records of a headless Service are the ready pods, so the aggregators scaled or not ready are picked up.
+func NewServiceBuilder(service string, interval time.Duration) resolver.Builder {
+	return &serviceBuilder{service: service, interval: interval}
+}
+
+type serviceBuilder struct {
+	service  string
+	interval time.Duration
+}
+
+func (b *serviceBuilder) Build(target resolver.Target, cc resolver.ClientConn, opts resolver.BuildOptions) (resolver.Resolver, error) {
+	host, port, err := net.SplitHostPort(b.service)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &serviceResolver{
+		host:     host,
+		port:     port,
+		interval: b.interval,
+		lookup:   net.DefaultResolver.LookupHost,
+		cc:       cc,
+		ctx:      ctx,
+		cancel:   cancel,
+		rn:       make(chan struct{}, 1),
+	}
+	go r.watch()
+	return r, nil
+}
+
+func (*serviceBuilder) Scheme() string { return collectorScheme }
+
+type serviceResolver struct {
+	host     string
+	port     string
+	interval time.Duration
+	lookup   func(ctx context.Context, host string) ([]string, error)
+	cc       resolver.ClientConn
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	// rn is notified when the ClientConn asks for resolving, eg: an aggregator is disconnected
+	rn    chan struct{}
+	addrs []string
+}
+
+func (r *serviceResolver) watch() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.resolve()
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.rn:
+		}
+	}
+}
+
+func (r *serviceResolver) resolve() {
+	ips, err := r.lookup(r.ctx, r.host)
+	if err != nil {
+		if r.ctx.Err() == nil {
+			log.Warn("resolve aggregator service %s failed: %v", r.host, err)
+			r.cc.ReportError(err)
+		}
+		return
+	}
+
+	addrs := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.JoinHostPort(ip, r.port))
+	}
+	sort.Strings(addrs)
+	if reflect.DeepEqual(addrs, r.addrs) {
+		return
+	}
+	log.Info("aggregator service %s is resolved to %v", r.host, addrs)
+	r.addrs = addrs
+
+	state := resolver.State{Addresses: make([]resolver.Address, len(addrs))}
+	for i, addr := range addrs {
+		state.Addresses[i] = resolver.Address{Addr: addr}
+	}
+	r.cc.UpdateState(state)
+}
+
+func (r *serviceResolver) ResolveNow(o resolver.ResolveNowOptions) {
+	select {
+	case r.rn <- struct{}{}:
+	default:
+	}
+}
+
+func (r *serviceResolver) Close() {
+	r.cancel()
+}
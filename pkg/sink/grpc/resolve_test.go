@@ -0,0 +1,105 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc/resolver"
+
+	"github.com/loggie-io/loggie/pkg/core/log"
+)
+
+type fakeClientConn struct {
+	resolver.ClientConn
+
+	mu     sync.Mutex
+	states []resolver.State
+	errs   []error
+}
+
+func (f *fakeClientConn) UpdateState(state resolver.State) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.states = append(f.states, state)
+}
+
+func (f *fakeClientConn) ReportError(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.errs = append(f.errs, err)
+}
+
+func TestServiceResolver(t *testing.T) {
+	log.InitDefaultLogger()
+
+	var ips []string
+	var lookupErr error
+	cc := &fakeClientConn{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &serviceResolver{
+		host: "loggie-aggregator.loggie.svc",
+		port: "6066",
+		lookup: func(ctx context.Context, host string) ([]string, error) {
+			return ips, lookupErr
+		},
+		cc:     cc,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	ips = []string{"10.0.0.2", "10.0.0.1"}
+	r.resolve()
+	// the state is not updated if the pods are not changed
+	ips = []string{"10.0.0.1", "10.0.0.2"}
+	r.resolve()
+	ips = []string{"10.0.0.1"}
+	r.resolve()
+	lookupErr = errors.New("no such host")
+	r.resolve()
+
+	var got [][]string
+	for _, state := range cc.states {
+		var addrs []string
+		for _, addr := range state.Addresses {
+			addrs = append(addrs, addr.Addr)
+		}
+		got = append(got, addrs)
+	}
+	want := [][]string{{"10.0.0.1:6066", "10.0.0.2:6066"}, {"10.0.0.1:6066"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("states = %v, want %v", got, want)
+	}
+	if len(cc.errs) != 1 {
+		t.Errorf("got %d errors reported, want 1", len(cc.errs))
+	}
+}
+
+func TestServiceConfig(t *testing.T) {
+	if got := serviceConfig("round_robin", false); got != `{"loadBalancingPolicy":"round_robin"}` {
+		t.Errorf("serviceConfig() = %s", got)
+	}
+	want := `{"loadBalancingPolicy":"round_robin","healthCheckConfig":{"serviceName":""}}`
+	if got := serviceConfig("round_robin", true); got != want {
+		t.Errorf("serviceConfig() = %s, want %s", got, want)
+	}
+}
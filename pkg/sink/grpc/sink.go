@@ -21,18 +21,27 @@ import (
 	"fmt"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
 	"github.com/loggie-io/loggie/pkg/core/log"
 	"github.com/loggie-io/loggie/pkg/core/result"
 	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/sink/grpc/compressor"
 	pb "github.com/loggie-io/loggie/pkg/sink/grpc/pb"
+	pbv2 "github.com/loggie-io/loggie/pkg/sink/grpc/pb/v2"
+	"github.com/pkg/errors"
 	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/health"
 	"google.golang.org/grpc/resolver"
 	"io"
 	"strings"
 	"time"
 )
 
-const Type = "grpc"
+const (
+	Type = "grpc"
+
+	versionV2 = "v2"
+)
 
 var (
 	json = jsoniter.ConfigFastest
@@ -48,6 +57,7 @@ func makeSink(info pipeline.Info) api.Component {
 
 type Sink struct {
 	stop        bool
+	sinkCount   int
 	name        string
 	config      *Config
 	setting     map[string]interface{}
@@ -57,12 +67,15 @@ type Sink struct {
 	epoch       int
 	logClient   pb.LogServiceClient
 	conn        *grpc.ClientConn
+	callOpts    []grpc.CallOption
+	batchStream *batchStream
 }
 
 func NewSink(info pipeline.Info) *Sink {
 	return &Sink{
-		stop:   info.Stop,
-		config: &Config{},
+		stop:      info.Stop,
+		sinkCount: info.SinkCount,
+		config:    &Config{},
 	}
 }
 
@@ -79,6 +92,9 @@ func (s *Sink) Config() interface{} {
 }
 
 func (s *Sink) String() string {
+	if s.config.Service != "" {
+		return fmt.Sprintf("%s/%s: target service(%s)", api.SINK, Type, s.config.Service)
+	}
 	return fmt.Sprintf("%s/%s: target host(%s)", api.SINK, Type, s.config.Host)
 }
 
@@ -93,24 +109,49 @@ func (s *Sink) Init(context api.Context) {
 }
 
 func (s *Sink) Start() {
-	// register grpc name resolver
-	resolver.Register(NewBuilder(s.hosts))
+	// the resolver is scoped to this connection, so the sinks of pipelines do not override each other
+	var builder resolver.Builder
+	if s.config.Service != "" {
+		builder = NewServiceBuilder(s.config.Service, s.config.ResolveInterval)
+	} else {
+		builder = NewBuilder(s.hosts)
+	}
 	// init grpc client
 	conn, err := grpc.Dial(
 		fmt.Sprintf("%s:///%s", collectorScheme, collectorServiceName),
 		grpc.WithInsecure(),
-		grpc.WithBalancerName(s.loadBalance),
+		grpc.WithResolvers(builder),
+		grpc.WithDefaultServiceConfig(serviceConfig(s.loadBalance, s.config.HealthCheck)),
 		grpc.WithInitialWindowSize(256),
 	)
 	if err != nil {
 		log.Panic("grpc client connect error. err: %v. server hosts: %s", err, s.hosts)
 	}
 	s.conn = conn
-	s.logClient = pb.NewLogServiceClient(conn)
-	log.Info("%s start, hosts: %v, load balance: %s", s.String(), s.hosts, s.loadBalance)
+	if s.config.Compression != compressor.None {
+		s.callOpts = append(s.callOpts, grpc.UseCompressor(s.config.Compression))
+	}
+	if s.config.Version == versionV2 {
+		s.batchStream = newBatchStream(pbv2.NewLogServiceClient(conn), s.sinkCount, s.config.StreamRotateInterval,
+			append(s.callOpts, grpc.WaitForReady(true))...)
+	} else {
+		s.logClient = pb.NewLogServiceClient(conn)
+	}
+	log.Info("%s start, hosts: %v, load balance: %s, version: %s", s.String(), s.hosts, s.loadBalance, s.config.Version)
+}
+
+// serviceConfig of the connection, the health checking takes effect only with a balancer like round_robin
+func serviceConfig(loadBalance string, healthCheck bool) string {
+	if healthCheck {
+		return fmt.Sprintf(`{"loadBalancingPolicy":%q,"healthCheckConfig":{"serviceName":""}}`, loadBalance)
+	}
+	return fmt.Sprintf(`{"loadBalancingPolicy":%q}`, loadBalance)
 }
 
 func (s *Sink) Stop() {
+	if s.batchStream != nil {
+		s.batchStream.close()
+	}
 	if s.conn != nil {
 		_ = s.conn.Close()
 	}
@@ -120,7 +161,11 @@ func (s *Sink) Consume(batch api.Batch) api.Result {
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	stream, err := s.logClient.LogStream(ctx, grpc.WaitForReady(true))
+	if s.batchStream != nil {
+		return s.consumeV2(ctx, batch)
+	}
+
+	stream, err := s.logClient.LogStream(ctx, append(s.callOpts, grpc.WaitForReady(true))...)
 	if err != nil {
 		return result.Fail(err)
 	}
@@ -186,3 +231,44 @@ func (s *Sink) Consume(batch api.Batch) api.Result {
 	}
 	return result.Success()
 }
+
+func (s *Sink) consumeV2(ctx context.Context, batch api.Batch) api.Result {
+	events := batch.Events()
+	eventBatch := &pbv2.EventBatch{
+		Events: make([]*pbv2.Event, 0, len(events)),
+	}
+	for _, e := range events {
+		eventBatch.Events = append(eventBatch.Events, &pbv2.Event{
+			Meta:   pbv2.NewValues(publicMeta(e.Meta())),
+			Header: pbv2.NewValues(e.Header()),
+			Body:   e.Body(),
+		})
+	}
+
+	ack, err := s.batchStream.send(ctx, eventBatch)
+	if err != nil {
+		log.Error("%s => send batch error: %v", s.String(), err)
+		return result.Fail(err)
+	}
+	if !ack.Success {
+		log.Error("%s => get grpc batch ack error: %v", s.String(), ack.ErrorMsg)
+		return result.Fail(errors.New(ack.ErrorMsg))
+	}
+	return result.Success()
+}
+
+// publicMeta excludes the private meta, which is only meaningful to the source of this loggie
+func publicMeta(meta api.Meta) map[string]interface{} {
+	if meta == nil {
+		return nil
+	}
+	all := meta.GetAll()
+	m := make(map[string]interface{}, len(all))
+	for k, v := range all {
+		if strings.HasPrefix(k, event.PrivateKeyPrefix) {
+			continue
+		}
+		m[k] = v
+	}
+	return m
+}
@@ -0,0 +1,233 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/loggie-io/loggie/pkg/core/log"
+	pbv2 "github.com/loggie-io/loggie/pkg/sink/grpc/pb/v2"
+)
+
+var errStreamBroken = errors.New("grpc batch stream broken before ack received")
+
+// batchStream sends the batches of the sink goroutines in bidirectional streams, the acks are dispatched to the
+// senders by batch id, so a sender waiting for ack would not block the others. The batches are spread over a stream
+// for each sink goroutine, and the streams are rotated every rotateInterval, so that the batches are balanced to
+// the backends picked by the balancer for the new streams instead of being pinned to the first ones.
+type batchStream struct {
+	client         pbv2.LogServiceClient
+	callOpts       []grpc.CallOption
+	rotateInterval time.Duration
+
+	// ctx is the parent of all the streams, it is canceled when closed
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	lock    sync.Mutex
+	streams []*ackStream
+	nextID  uint64
+}
+
+// ackStream is a stream with the batches waiting for acks, it is guarded by the lock of batchStream
+type ackStream struct {
+	stream  pbv2.LogService_BatchStreamClient
+	cancel  context.CancelFunc
+	created time.Time
+	pending map[uint64]chan *pbv2.BatchAck
+	// retired stream receives no more batches, it is closed when all the acks received
+	retired bool
+	closed  bool
+
+	// Send of grpc stream is not safe to be called concurrently
+	sendLock sync.Mutex
+}
+
+func newBatchStream(client pbv2.LogServiceClient, size int, rotateInterval time.Duration, callOpts ...grpc.CallOption) *batchStream {
+	if size < 1 {
+		size = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &batchStream{
+		client:         client,
+		callOpts:       callOpts,
+		rotateInterval: rotateInterval,
+		ctx:            ctx,
+		cancel:         cancel,
+		streams:        make([]*ackStream, size),
+	}
+}
+
+// send sends the batch and waits for its ack until ctx done
+func (b *batchStream) send(ctx context.Context, batch *pbv2.EventBatch) (*pbv2.BatchAck, error) {
+	s, id, ackChan, err := b.register(ctx)
+	if err != nil {
+		return nil, err
+	}
+	batch.Id = id
+
+	s.sendLock.Lock()
+	err = s.stream.Send(batch)
+	s.sendLock.Unlock()
+	if err != nil {
+		b.reset(s, err)
+		return nil, err
+	}
+
+	select {
+	case ack, ok := <-ackChan:
+		if !ok {
+			return nil, errStreamBroken
+		}
+		return ack, nil
+
+	case <-ctx.Done():
+		b.lock.Lock()
+		delete(s.pending, id)
+		b.closeIfDrained(s)
+		b.lock.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// register picks the streams in turn, creates the stream if not exist or rotated, and returns an id with the channel
+// to receive its ack
+func (b *batchStream) register(ctx context.Context) (*ackStream, uint64, chan *pbv2.BatchAck, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	slot := int(b.nextID % uint64(len(b.streams)))
+	s := b.streams[slot]
+	if s != nil && b.rotateInterval > 0 && time.Since(s.created) >= b.rotateInterval {
+		s.retired = true
+		b.closeIfDrained(s)
+		s = nil
+	}
+
+	if s == nil {
+		var err error
+		if s, err = b.newStream(ctx); err != nil {
+			b.streams[slot] = nil
+			return nil, 0, nil, err
+		}
+		b.streams[slot] = s
+		go b.receive(s)
+	}
+
+	b.nextID++
+	ackChan := make(chan *pbv2.BatchAck, 1)
+	s.pending[b.nextID] = ackChan
+	return s, b.nextID, ackChan, nil
+}
+
+// newStream waits for the connection ready until ctx of the sender done, the stream lives longer than any of
+// the batches, so it is not bound to the ctx
+func (b *batchStream) newStream(ctx context.Context) (*ackStream, error) {
+	streamCtx, cancel := context.WithCancel(b.ctx)
+	created := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			cancel()
+		case <-created:
+		}
+	}()
+	stream, err := b.client.BatchStream(streamCtx, b.callOpts...)
+	close(created)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &ackStream{
+		stream:  stream,
+		cancel:  cancel,
+		created: time.Now(),
+		pending: make(map[uint64]chan *pbv2.BatchAck),
+	}, nil
+}
+
+func (b *batchStream) receive(s *ackStream) {
+	for {
+		ack, err := s.stream.Recv()
+		if err != nil {
+			b.reset(s, err)
+			return
+		}
+
+		b.lock.Lock()
+		ackChan, ok := s.pending[ack.Id]
+		delete(s.pending, ack.Id)
+		b.closeIfDrained(s)
+		b.lock.Unlock()
+		if ok {
+			ackChan <- ack
+		}
+	}
+}
+
+// closeIfDrained closes the retired stream without batches waiting for acks, it is called with lock held
+func (b *batchStream) closeIfDrained(s *ackStream) {
+	if s.retired && !s.closed && len(s.pending) == 0 {
+		s.closed = true
+		s.cancel()
+	}
+}
+
+// reset drops the broken stream and fails all the batches waiting for acks, a new stream would be created by the
+// next sending.
+func (b *batchStream) reset(s *ackStream, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for i, cur := range b.streams {
+		if cur == s {
+			b.streams[i] = nil
+		}
+	}
+	if s.closed {
+		return
+	}
+
+	log.Warn("grpc batch stream reset: %v", err)
+	s.closed = true
+	s.cancel()
+	for id, ackChan := range s.pending {
+		close(ackChan)
+		delete(s.pending, id)
+	}
+}
+
+func (b *batchStream) close() {
+	// the senders waiting for connection ready are canceled before the lock acquired
+	b.cancel()
+
+	b.lock.Lock()
+	streams := make([]*ackStream, 0, len(b.streams))
+	for _, s := range b.streams {
+		if s != nil {
+			streams = append(streams, s)
+		}
+	}
+	b.lock.Unlock()
+	for _, s := range streams {
+		b.reset(s, errors.New("sink stopped"))
+	}
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/sink/grpc/compressor"
+	pbv2 "github.com/loggie-io/loggie/pkg/sink/grpc/pb/v2"
+)
+
+// reverseServer holds batches until two received, then acks them in reverse order, or breaks the stream when
+// a batch has no event.
+type reverseServer struct {
+	pbv2.UnimplementedLogServiceServer
+}
+
+func (r *reverseServer) BatchStream(stream pbv2.LogService_BatchStreamServer) error {
+	var held []*pbv2.EventBatch
+	for {
+		b, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if len(b.Events) == 0 {
+			return errors.New("broken")
+		}
+		held = append(held, b)
+		if len(held) < 2 {
+			continue
+		}
+		for i := len(held) - 1; i >= 0; i-- {
+			ok := string(held[i].Events[0].Body) != "fail"
+			if err := stream.Send(&pbv2.BatchAck{Id: held[i].Id, Success: ok, Count: int32(len(held[i].Events))}); err != nil {
+				return err
+			}
+		}
+		held = nil
+	}
+}
+
+func startReverseServer(t *testing.T) (*batchStream, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	pbv2.RegisterLogServiceServer(server, &reverseServer{})
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs := newBatchStream(pbv2.NewLogServiceClient(conn), 1, 0, grpc.UseCompressor(compressor.Gzip))
+	return bs, func() {
+		bs.close()
+		conn.Close()
+		server.Stop()
+	}
+}
+
+func TestBatchStream_Pipelined(t *testing.T) {
+	log.InitDefaultLogger()
+	bs, stop := startReverseServer(t)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// the acks are received only if both batches are in flight
+	var wg sync.WaitGroup
+	acks := make([]*pbv2.BatchAck, 2)
+	for i, body := range []string{"ok", "fail"} {
+		wg.Add(1)
+		go func(i int, body string) {
+			defer wg.Done()
+			ack, err := bs.send(ctx, &pbv2.EventBatch{Events: []*pbv2.Event{{Body: []byte(body)}}})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			acks[i] = ack
+		}(i, body)
+	}
+	wg.Wait()
+
+	if acks[0] == nil || !acks[0].Success || acks[1] == nil || acks[1].Success {
+		t.Errorf("acks are not dispatched to the senders: %v", acks)
+	}
+}
+
+func TestBatchStream_Reset(t *testing.T) {
+	log.InitDefaultLogger()
+	bs, stop := startReverseServer(t)
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := bs.send(ctx, &pbv2.EventBatch{}); err != errStreamBroken {
+		t.Fatalf("expect stream broken, got %v", err)
+	}
+
+	// a new stream is created for the next batches
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := bs.send(ctx, &pbv2.EventBatch{Events: []*pbv2.Event{{Body: []byte("ok")}}}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// countingServer acks the batches at once, and counts the streams opened
+type countingServer struct {
+	pbv2.UnimplementedLogServiceServer
+	lock    sync.Mutex
+	streams int
+}
+
+func (c *countingServer) BatchStream(stream pbv2.LogService_BatchStreamServer) error {
+	c.lock.Lock()
+	c.streams++
+	c.lock.Unlock()
+	for {
+		b, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(&pbv2.BatchAck{Id: b.Id, Success: true, Count: int32(len(b.Events))}); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *countingServer) opened() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	n := c.streams
+	c.streams = 0
+	return n
+}
+
+func TestBatchStream_Rotate(t *testing.T) {
+	log.InitDefaultLogger()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := &countingServer{}
+	server := grpc.NewServer()
+	pbv2.RegisterLogServiceServer(server, counter)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	send := func(bs *batchStream, count int) {
+		for i := 0; i < count; i++ {
+			ack, err := bs.send(ctx, &pbv2.EventBatch{Events: []*pbv2.Event{{Body: []byte("ok")}}})
+			if err != nil || !ack.Success {
+				t.Fatalf("send batch failed: %v, ack: %v", err, ack)
+			}
+		}
+	}
+
+	// the batches are spread over a stream for each sink goroutine
+	bs := newBatchStream(pbv2.NewLogServiceClient(conn), 2, 0, grpc.WaitForReady(true))
+	send(bs, 4)
+	bs.close()
+	if n := counter.opened(); n != 2 {
+		t.Errorf("expect 2 streams, got %d", n)
+	}
+
+	// a new stream is opened for every batch after the stream expired
+	bs = newBatchStream(pbv2.NewLogServiceClient(conn), 1, time.Nanosecond, grpc.WaitForReady(true))
+	send(bs, 3)
+	bs.close()
+	if n := counter.opened(); n != 3 {
+		t.Errorf("expect 3 streams rotated, got %d", n)
+	}
+}
+
+func TestValues(t *testing.T) {
+	values := pbv2.NewValues(map[string]interface{}{
+		"s":      "a",
+		"i":      int32(1),
+		"f":      1.5,
+		"nested": map[string]interface{}{"list": []string{"x", "y"}},
+		"skip":   struct{}{},
+	})
+	m := pbv2.ToMap(values)
+	if m["s"] != "a" || m["i"] != int64(1) || m["f"] != 1.5 {
+		t.Errorf("unexpected values %v", m)
+	}
+	list := m["nested"].(map[string]interface{})["list"].([]interface{})
+	if len(list) != 2 || list[1] != "y" {
+		t.Errorf("unexpected nested values %v", m["nested"])
+	}
+	if _, ok := m["skip"]; ok {
+		t.Error("unsupported value should be skipped")
+	}
+}
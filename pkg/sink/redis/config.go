@@ -0,0 +1,35 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"github.com/loggie-io/loggie/pkg/util/redis"
+)
+
+const (
+	ModeList   = "list"
+	ModeStream = "stream"
+)
+
+type Config struct {
+	redis.Options `yaml:",inline"`
+	Mode          string `yaml:"mode,omitempty" default:"list" validate:"oneof=list stream"`
+	Key           string `yaml:"key,omitempty" validate:"required"`
+	// MaxLen trims the stream approximately with XADD MAXLEN ~, 0 means no trimming
+	MaxLen    int64  `yaml:"maxLen,omitempty"`
+	BodyField string `yaml:"bodyField,omitempty" default:"body"`
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/core/result"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/sink/codec"
+	"github.com/loggie-io/loggie/pkg/util"
+	"github.com/loggie-io/loggie/pkg/util/redis"
+	"github.com/loggie-io/loggie/pkg/util/runtime"
+)
+
+const Type = "redis"
+
+func init() {
+	pipeline.Register(api.SINK, Type, makeSink)
+}
+
+func makeSink(info pipeline.Info) api.Component {
+	return NewSink(info)
+}
+
+type Sink struct {
+	config      *Config
+	cod         codec.Codec
+	pool        *redis.Pool
+	parallelism int
+
+	keyMatcher [][]string
+}
+
+func NewSink(info pipeline.Info) *Sink {
+	parallelism := info.SinkCount
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+	return &Sink{
+		config:      &Config{},
+		parallelism: parallelism,
+	}
+}
+
+func (s *Sink) Config() interface{} {
+	return s.config
+}
+
+func (s *Sink) SetCodec(c codec.Codec) {
+	s.cod = c
+}
+
+func (s *Sink) Category() api.Category {
+	return api.SINK
+}
+
+func (s *Sink) Type() api.Type {
+	return Type
+}
+
+func (s *Sink) String() string {
+	return fmt.Sprintf("%s/%s", api.SINK, Type)
+}
+
+func (s *Sink) Init(context api.Context) {
+	s.keyMatcher = util.InitMatcher(s.config.Key)
+}
+
+func (s *Sink) Start() {
+	// each sink consumer holds one connection at a time
+	s.pool = redis.NewPool(&s.config.Options, s.parallelism)
+	log.Info("%s start, addr: %s, mode: %s, key: %s", s.String(), s.config.Addr, s.config.Mode, s.config.Key)
+}
+
+func (s *Sink) Stop() {
+	if s.pool != nil {
+		s.pool.Close()
+	}
+}
+
+// Consume pipelines RPUSH or XADD commands of the whole batch in one round trip
+func (s *Sink) Consume(batch api.Batch) api.Result {
+	events := batch.Events()
+	if len(events) == 0 {
+		return result.Success()
+	}
+	if s.pool == nil {
+		return result.Fail(errors.New("redis sink not initialized"))
+	}
+
+	commands := make([][]interface{}, 0, len(events))
+	for _, e := range events {
+		key, err := runtime.PatternSelect(runtime.NewObject(e.Header()), s.config.Key, s.keyMatcher)
+		if err != nil {
+			log.Error("select redis key error: %+v", err)
+			return result.Fail(err)
+		}
+
+		value, err := s.cod.Encode(e)
+		if err != nil {
+			log.Warn("encode event error: %+v", err)
+			return result.Fail(err)
+		}
+
+		commands = append(commands, s.command(key, value))
+	}
+
+	if err := s.send(commands); err != nil {
+		log.Error("write to redis error: %+v", err)
+		return result.Fail(err)
+	}
+	return result.Success()
+}
+
+func (s *Sink) command(key string, value []byte) []interface{} {
+	if s.config.Mode == ModeStream {
+		args := []interface{}{"XADD", key}
+		if s.config.MaxLen > 0 {
+			args = append(args, "MAXLEN", "~", s.config.MaxLen)
+		}
+		return append(args, "*", s.config.BodyField, value)
+	}
+	return []interface{}{"RPUSH", key, value}
+}
+
+func (s *Sink) send(commands [][]interface{}) error {
+	conn, err := s.pool.Get()
+	if err != nil {
+		return err
+	}
+
+	for _, cmd := range commands {
+		if err := conn.Send(cmd...); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		conn.Close()
+		return err
+	}
+
+	// all the replies must be read out before the connection could be reused
+	var replyErr error
+	for range commands {
+		if _, err := conn.Receive(s.config.ReadTimeout); err != nil {
+			if _, ok := err.(redis.Error); !ok {
+				conn.Close()
+				return err
+			}
+			replyErr = err
+		}
+	}
+	s.pool.Put(conn)
+	return replyErr
+}
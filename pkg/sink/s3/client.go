@@ -0,0 +1,311 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	signAlgorithm = "AWS4-HMAC-SHA256"
+	amzDateLayout = "20060102T150405Z"
+	amzDayLayout  = "20060102"
+	serviceName   = "s3"
+)
+
+// Client is a minimal S3 REST client which supports the operations the sink needs,
+// it works against AWS S3 and S3-compatible object storage such as MinIO or Ceph RGW.
+type Client struct {
+	endpoint *url.URL
+	config   *Config
+	hc       *http.Client
+}
+
+func NewClient(config *Config) (*Client, error) {
+	endpoint := config.Endpoint
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = fmt.Sprintf("https://%s", endpoint)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "parse s3 endpoint %s failed", config.Endpoint)
+	}
+
+	return &Client{
+		endpoint: u,
+		config:   config,
+		hc: &http.Client{
+			Timeout: config.Timeout,
+		},
+	}, nil
+}
+
+// Upload writes the object with a single PutObject request, or with multipart upload when the content
+// is larger than the part size.
+func (c *Client) Upload(ctx context.Context, key string, content []byte) error {
+	if len(content) <= c.config.PartSize {
+		return c.PutObject(ctx, key, content)
+	}
+	return c.MultipartUpload(ctx, key, content)
+}
+
+func (c *Client) PutObject(ctx context.Context, key string, content []byte) error {
+	_, err := c.do(ctx, http.MethodPut, key, nil, content)
+	return err
+}
+
+type initiateMultipartUploadResult struct {
+	UploadId string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+func (c *Client) MultipartUpload(ctx context.Context, key string, content []byte) error {
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": []string{""}}, nil)
+	if err != nil {
+		return errors.WithMessage(err, "create multipart upload failed")
+	}
+	initResult := &initiateMultipartUploadResult{}
+	if err := xml.Unmarshal(resp.body, initResult); err != nil {
+		return errors.WithMessage(err, "unmarshal create multipart upload response failed")
+	}
+	uploadId := initResult.UploadId
+
+	var parts []completedPart
+	for i, start := 1, 0; start < len(content); i, start = i+1, start+c.config.PartSize {
+		end := start + c.config.PartSize
+		if end > len(content) {
+			end = len(content)
+		}
+
+		query := url.Values{
+			"partNumber": []string{strconv.Itoa(i)},
+			"uploadId":   []string{uploadId},
+		}
+		partResp, err := c.do(ctx, http.MethodPut, key, query, content[start:end])
+		if err != nil {
+			c.abortMultipartUpload(key, uploadId)
+			return errors.WithMessagef(err, "upload part %d failed", i)
+		}
+		parts = append(parts, completedPart{
+			PartNumber: i,
+			ETag:       partResp.header.Get("ETag"),
+		})
+	}
+
+	completeBody, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		c.abortMultipartUpload(key, uploadId)
+		return err
+	}
+	if _, err := c.do(ctx, http.MethodPost, key, url.Values{"uploadId": []string{uploadId}}, completeBody); err != nil {
+		c.abortMultipartUpload(key, uploadId)
+		return errors.WithMessage(err, "complete multipart upload failed")
+	}
+	return nil
+}
+
+func (c *Client) abortMultipartUpload(key string, uploadId string) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Timeout)
+	defer cancel()
+	// parts which are not aborted would be billed, best effort here
+	_, _ = c.do(ctx, http.MethodDelete, key, url.Values{"uploadId": []string{uploadId}}, nil)
+}
+
+type response struct {
+	header http.Header
+	body   []byte
+}
+
+func (c *Client) do(ctx context.Context, method string, key string, query url.Values, content []byte) (*response, error) {
+	u := c.objectURL(key, query)
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = int64(len(content))
+	req.URL = u
+	req.Host = u.Host
+
+	c.sign(req, content, time.Now().UTC())
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.Errorf("request %s %s response status %d: %s", method, u.Path, resp.StatusCode, body)
+	}
+	return &response{
+		header: resp.Header,
+		body:   body,
+	}, nil
+}
+
+func (c *Client) objectURL(key string, query url.Values) *url.URL {
+	u := *c.endpoint
+	path := "/" + strings.TrimPrefix(key, "/")
+	if c.config.PathStyle {
+		path = "/" + c.config.Bucket + path
+	} else {
+		u.Host = c.config.Bucket + "." + u.Host
+	}
+	u.Path = strings.TrimSuffix(c.endpoint.Path, "/") + path
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// sign signs the request with AWS Signature Version 4, anonymous requests are sent when no credentials configured
+func (c *Client) sign(req *http.Request, content []byte, now time.Time) {
+	payloadHash := sha256Hex(content)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.config.AccessKeyId == "" {
+		return
+	}
+
+	amzDate := now.Format(amzDateLayout)
+	req.Header.Set("X-Amz-Date", amzDate)
+	if c.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.config.SessionToken)
+	}
+
+	headers := map[string]string{
+		"host": req.Host,
+	}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k)
+		canonicalHeaders.WriteString(":")
+		canonicalHeaders.WriteString(headers[k])
+		canonicalHeaders.WriteString("\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{now.Format(amzDayLayout), c.config.Region, serviceName, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		signAlgorithm,
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+c.config.SecretAccessKey), now.Format(amzDayLayout))
+	signingKey = hmacSHA256(signingKey, c.config.Region)
+	signingKey = hmacSHA256(signingKey, serviceName)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, c.config.AccessKeyId, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		for _, v := range query[k] {
+			pairs = append(pairs, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode encodes everything except the unreserved characters as RFC 3986 required by SigV4
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || (ch == '/' && !encodeSlash) {
+			b.WriteByte(ch)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", ch)
+	}
+	return b.String()
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, content string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(content))
+	return h.Sum(nil)
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+
+	// minPartSize is the smallest part size accepted by S3 multipart upload, except for the last part
+	minPartSize = 5 * 1024 * 1024
+)
+
+type Config struct {
+	Endpoint        string        `yaml:"endpoint,omitempty" validate:"required"`
+	Region          string        `yaml:"region,omitempty" default:"us-east-1"`
+	Bucket          string        `yaml:"bucket,omitempty" validate:"required"`
+	AccessKeyId     string        `yaml:"accessKeyId,omitempty"`
+	SecretAccessKey string        `yaml:"secretAccessKey,omitempty"`
+	SessionToken    string        `yaml:"sessionToken,omitempty"`
+	PathStyle       bool          `yaml:"pathStyle,omitempty"`
+	Prefix          string        `yaml:"prefix,omitempty" default:"${+YYYY/MM/DD/hh}"`
+	Compression     string        `yaml:"compression,omitempty" default:"gzip"`
+	FlushSize       int           `yaml:"flushSize,omitempty" default:"16777216"`
+	FlushInterval   time.Duration `yaml:"flushInterval,omitempty" default:"30s"`
+	PartSize        int           `yaml:"partSize,omitempty" default:"8388608"`
+	Timeout         time.Duration `yaml:"timeout,omitempty" default:"60s"`
+}
+
+func (c *Config) Validate() error {
+	if c.Compression != CompressionNone && c.Compression != CompressionGzip && c.Compression != CompressionZstd {
+		return fmt.Errorf("s3 sink compression %s is not supported", c.Compression)
+	}
+
+	if c.PartSize < minPartSize {
+		return fmt.Errorf("s3 sink partSize %d is less than the minimum %d", c.PartSize, minPartSize)
+	}
+
+	if c.FlushInterval <= 0 {
+		return fmt.Errorf("s3 sink flushInterval must be positive")
+	}
+
+	if (c.AccessKeyId == "") != (c.SecretAccessKey == "") {
+		return fmt.Errorf("s3 sink accessKeyId and secretAccessKey must be set together")
+	}
+
+	return nil
+}
+
+func (c *Config) extension() string {
+	switch c.Compression {
+	case CompressionGzip:
+		return ".log.gz"
+	case CompressionZstd:
+		return ".log.zst"
+	default:
+		return ".log"
+	}
+}
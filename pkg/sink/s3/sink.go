@@ -0,0 +1,343 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/core/result"
+	"github.com/loggie-io/loggie/pkg/core/sysconfig"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/sink/codec"
+	"github.com/loggie-io/loggie/pkg/util"
+	"github.com/loggie-io/loggie/pkg/util/runtime"
+)
+
+const Type = "s3"
+
+func init() {
+	pipeline.Register(api.SINK, Type, makeSink)
+}
+
+func makeSink(info pipeline.Info) api.Component {
+	return NewSink(info)
+}
+
+// object buffers the encoded events which share the same key prefix,
+// all the batches written into the object are acked only after the object is uploaded.
+type object struct {
+	prefix  string
+	buf     bytes.Buffer
+	created time.Time
+	done    chan struct{}
+	err     error
+}
+
+type Sink struct {
+	name        string
+	config      *Config
+	codec       codec.Codec
+	cli         *Client
+	parallelism int
+
+	prefixMatcher [][]string
+
+	lock    sync.Mutex
+	objects map[string]*object
+	// waiting is the count of batches waiting for their objects uploaded
+	waiting int
+	seq     uint64
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewSink(info pipeline.Info) *Sink {
+	parallelism := info.SinkCount
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+	return &Sink{
+		config:      &Config{},
+		parallelism: parallelism,
+		objects:     make(map[string]*object),
+		done:        make(chan struct{}),
+	}
+}
+
+func (s *Sink) Config() interface{} {
+	return s.config
+}
+
+func (s *Sink) SetCodec(c codec.Codec) {
+	s.codec = c
+}
+
+func (s *Sink) Category() api.Category {
+	return api.SINK
+}
+
+func (s *Sink) Type() api.Type {
+	return Type
+}
+
+func (s *Sink) String() string {
+	return fmt.Sprintf("%s/%s", api.SINK, Type)
+}
+
+func (s *Sink) Init(context api.Context) {
+	s.name = context.Name()
+	s.prefixMatcher = util.InitMatcher(s.config.Prefix)
+}
+
+func (s *Sink) Start() {
+	cli, err := NewClient(s.config)
+	if err != nil {
+		log.Error("start s3 sink failed: %+v", err)
+		return
+	}
+	s.cli = cli
+
+	go s.flushLoop()
+	log.Info("%s start, endpoint: %s, bucket: %s", s.String(), s.config.Endpoint, s.config.Bucket)
+}
+
+func (s *Sink) Stop() {
+	s.closeOnce.Do(func() {
+		// the batches consumed after done closed are failed in append, so no object is left behind
+		close(s.done)
+		// upload what we have, so the consumers blocked in Consume could get their results
+		s.flush(s.takeObjects(func(o *object) bool { return true }))
+	})
+}
+
+// Consume appends the batch to the buffered objects and blocks until all of them are uploaded.
+// Batches from parallel sink consumers are gathered into the same object, an object is flushed when it
+// exceeds flushSize, is older than flushInterval, or when all the sink consumers are waiting on it.
+func (s *Sink) Consume(batch api.Batch) api.Result {
+	events := batch.Events()
+	if len(events) == 0 {
+		return result.Success()
+	}
+	if s.cli == nil {
+		return result.Fail(errors.New("s3 sink client not initialized"))
+	}
+
+	groups := make(map[string]*bytes.Buffer)
+	for _, e := range events {
+		prefix, err := runtime.PatternSelect(runtime.NewObject(e.Header()), s.config.Prefix, s.prefixMatcher)
+		if err != nil {
+			log.Error("select s3 object prefix error: %+v", err)
+			return result.Fail(err)
+		}
+
+		data, err := s.codec.Encode(e)
+		if err != nil {
+			log.Warn("encode event error: %+v", err)
+			return result.Fail(err)
+		}
+
+		buf, ok := groups[prefix]
+		if !ok {
+			buf = &bytes.Buffer{}
+			groups[prefix] = buf
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	waits, flushes, err := s.append(groups)
+	if err != nil {
+		return result.Fail(err)
+	}
+	s.flush(flushes)
+
+	defer func() {
+		s.lock.Lock()
+		s.waiting--
+		s.lock.Unlock()
+	}()
+	for _, o := range waits {
+		<-o.done
+		if o.err != nil {
+			return result.Fail(o.err)
+		}
+	}
+	return result.Success()
+}
+
+// append fails when the sink is stopped, because the objects appended after stopping would never be flushed
+func (s *Sink) append(groups map[string]*bytes.Buffer) (waits []*object, flushes []*object, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	select {
+	case <-s.done:
+		return nil, nil, errors.New("s3 sink is stopped")
+	default:
+	}
+
+	for prefix, buf := range groups {
+		o, ok := s.objects[prefix]
+		if !ok {
+			o = &object{
+				prefix:  prefix,
+				created: time.Now(),
+				done:    make(chan struct{}),
+			}
+			s.objects[prefix] = o
+		}
+		o.buf.Write(buf.Bytes())
+		waits = append(waits, o)
+
+		if o.buf.Len() >= s.config.FlushSize {
+			delete(s.objects, prefix)
+			flushes = append(flushes, o)
+		}
+	}
+
+	// no more batches could arrive while every sink consumer is waiting here, a batch is counted once
+	// even if it is written into several objects
+	s.waiting++
+	if s.waiting >= s.parallelism {
+		for prefix, o := range s.objects {
+			delete(s.objects, prefix)
+			flushes = append(flushes, o)
+		}
+	}
+	return waits, flushes, nil
+}
+
+func (s *Sink) takeObjects(expired func(o *object) bool) []*object {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var objs []*object
+	for prefix, o := range s.objects {
+		if expired(o) {
+			delete(s.objects, prefix)
+			objs = append(objs, o)
+		}
+	}
+	return objs
+}
+
+func (s *Sink) flushLoop() {
+	tick := s.config.FlushInterval / 2
+	if tick > time.Second {
+		tick = time.Second
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+
+		case <-ticker.C:
+			objs := s.takeObjects(func(o *object) bool {
+				return time.Since(o.created) >= s.config.FlushInterval
+			})
+			go s.flush(objs)
+		}
+	}
+}
+
+func (s *Sink) flush(objs []*object) {
+	if len(objs) == 0 {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, o := range objs {
+		obj := o
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			obj.err = s.upload(obj)
+			close(obj.done)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Sink) upload(o *object) error {
+	content, err := compress(s.config.Compression, o.buf.Bytes())
+	if err != nil {
+		return errors.WithMessage(err, "compress s3 object failed")
+	}
+
+	key := s.objectKey(o.prefix)
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+	if err := s.cli.Upload(ctx, key, content); err != nil {
+		log.Error("upload s3 object %s error: %+v", key, err)
+		return err
+	}
+
+	log.Debug("uploaded s3 object %s, size: %d", key, len(content))
+	return nil
+}
+
+// objectKey makes the object name unique among loggie agents and flushes
+func (s *Sink) objectKey(prefix string) string {
+	seq := atomic.AddUint64(&s.seq, 1)
+	now := time.Now().UTC().Format(amzDateLayout)
+	name := fmt.Sprintf("%s-%s-%d%s", now, sysconfig.NodeName, seq, s.config.extension())
+	if prefix == "" {
+		return name
+	}
+	return fmt.Sprintf("%s/%s", prefix, name)
+}
+
+func compress(compression string, content []byte) ([]byte, error) {
+	switch compression {
+	case CompressionGzip:
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		if _, err := w.Write(content); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+
+	case CompressionZstd:
+		w, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer w.Close()
+		return w.EncodeAll(content, nil), nil
+
+	default:
+		return content, nil
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package s3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/batch"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+// fakeS3 is a tiny S3-compatible stand-in which supports PutObject and multipart upload
+type fakeS3 struct {
+	lock    sync.Mutex
+	fail    bool
+	objects map[string][]byte
+	parts   map[string][][]byte
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		objects: make(map[string][]byte),
+		parts:   make(map[string][][]byte),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if !strings.HasPrefix(r.Header.Get("Authorization"), signAlgorithm) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && query.Get("uploads") == "" && strings.Contains(r.URL.RawQuery, "uploads"):
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", r.URL.Path)
+	case r.Method == http.MethodPut && query.Get("uploadId") != "":
+		f.parts[r.URL.Path] = append(f.parts[r.URL.Path], body)
+		w.Header().Set("ETag", fmt.Sprintf(`"%d"`, len(f.parts[r.URL.Path])))
+	case r.Method == http.MethodPost && query.Get("uploadId") != "":
+		f.objects[r.URL.Path] = bytes.Join(f.parts[r.URL.Path], nil)
+		delete(f.parts, r.URL.Path)
+	case r.Method == http.MethodPut:
+		f.objects[r.URL.Path] = body
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// lineCodec only writes the event body
+type lineCodec struct{}
+
+func (c *lineCodec) Init() {
+}
+
+func (c *lineCodec) Encode(e api.Event) ([]byte, error) {
+	return e.Body(), nil
+}
+
+func newTestSink(endpoint string, parallelism int) *Sink {
+	s := NewSink(pipeline.Info{SinkCount: parallelism})
+	s.config = &Config{
+		Endpoint:        endpoint,
+		Region:          "us-east-1",
+		Bucket:          "logs",
+		AccessKeyId:     "ak",
+		SecretAccessKey: "sk",
+		PathStyle:       true,
+		Prefix:          "${fields.service}",
+		Compression:     CompressionGzip,
+		FlushSize:       1024 * 1024,
+		FlushInterval:   time.Hour,
+		PartSize:        minPartSize,
+		Timeout:         10 * time.Second,
+	}
+	s.SetCodec(&lineCodec{})
+	s.prefixMatcher = [][]string{{"${fields.service}", "fields.service"}}
+	s.Start()
+	return s
+}
+
+func newTestBatch(service string, bodies ...string) api.Batch {
+	var events []api.Event
+	for _, b := range bodies {
+		events = append(events, event.NewEvent(map[string]interface{}{
+			"fields": map[string]interface{}{
+				"service": service,
+			},
+		}, []byte(b)))
+	}
+	return batch.NewBatchWithEvents(events)
+}
+
+func gunzip(t *testing.T, content []byte) string {
+	r, err := gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("gzip reader error: %v", err)
+	}
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("gunzip error: %v", err)
+	}
+	return string(out)
+}
+
+func TestSink_ConsumeGatherParallelBatches(t *testing.T) {
+	log.InitDefaultLogger()
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s := newTestSink(server.URL, 2)
+	defer s.Stop()
+
+	var wg sync.WaitGroup
+	results := make([]api.Result, 2)
+	for i := 0; i < 2; i++ {
+		index := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[index] = s.Consume(newTestBatch("app", fmt.Sprintf("line-%d", index)))
+		}()
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		if r.Status() != api.SUCCESS {
+			t.Fatalf("consume result status %v, err: %v", r.Status(), r.Error())
+		}
+	}
+	if len(fake.objects) != 1 {
+		t.Fatalf("expect 1 object, got %d", len(fake.objects))
+	}
+	for key, content := range fake.objects {
+		if !strings.HasPrefix(key, "/logs/app/") || !strings.HasSuffix(key, ".log.gz") {
+			t.Errorf("unexpected object key %s", key)
+		}
+		lines := gunzip(t, content)
+		if !strings.Contains(lines, "line-0") || !strings.Contains(lines, "line-1") {
+			t.Errorf("object content %s does not contain both batches", lines)
+		}
+	}
+}
+
+func TestSink_ConsumeBatchCountedOnce(t *testing.T) {
+	log.InitDefaultLogger()
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s := newTestSink(server.URL, 2)
+	defer s.Stop()
+
+	// the batch written into two objects is one of the two sink consumers, nothing is flushed until the other comes
+	multi := batch.NewBatchWithEvents(append(newTestBatch("app", "a").Events(), newTestBatch("db", "b").Events()...))
+	first := make(chan api.Result, 1)
+	go func() {
+		first <- s.Consume(multi)
+	}()
+	select {
+	case r := <-first:
+		t.Fatalf("batch is flushed before the other consumer arrives: %v", r.Status())
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	if r := s.Consume(newTestBatch("app", "c")); r.Status() != api.SUCCESS {
+		t.Fatalf("consume result status %v, err: %v", r.Status(), r.Error())
+	}
+	if r := <-first; r.Status() != api.SUCCESS {
+		t.Fatalf("consume result status %v, err: %v", r.Status(), r.Error())
+	}
+	if len(fake.objects) != 2 {
+		t.Errorf("expect 2 objects, got %d", len(fake.objects))
+	}
+}
+
+func TestSink_ConsumeAfterStop(t *testing.T) {
+	log.InitDefaultLogger()
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s := newTestSink(server.URL, 2)
+	s.Stop()
+
+	result := make(chan api.Result, 1)
+	go func() {
+		result <- s.Consume(newTestBatch("app", "line"))
+	}()
+	select {
+	case r := <-result:
+		if r.Status() != api.FAIL {
+			t.Errorf("expect failed result after stopped, got %v", r.Status())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("consume is blocked after stopped")
+	}
+}
+
+func TestSink_ConsumeFailedUploadNotAcked(t *testing.T) {
+	log.InitDefaultLogger()
+	fake := newFakeS3()
+	fake.fail = true
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s := newTestSink(server.URL, 1)
+	defer s.Stop()
+
+	r := s.Consume(newTestBatch("app", "line"))
+	if r.Status() != api.FAIL {
+		t.Fatalf("expect failed result when upload failed, got %v", r.Status())
+	}
+}
+
+func TestClient_MultipartUpload(t *testing.T) {
+	log.InitDefaultLogger()
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	s := newTestSink(server.URL, 1)
+	defer s.Stop()
+
+	content := bytes.Repeat([]byte("a"), minPartSize*2+10)
+	if err := s.cli.Upload(context.Background(), "big", content); err != nil {
+		t.Fatalf("multipart upload error: %v", err)
+	}
+	if !bytes.Equal(fake.objects["/logs/big"], content) {
+		t.Fatalf("multipart object content mismatch, got %d bytes", len(fake.objects["/logs/big"]))
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package splunk
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	eventPath = "/services/collector/event"
+	ackPath   = "/services/collector/ack"
+
+	channelHeader = "X-Splunk-Request-Channel"
+)
+
+// HEC status codes, see https://docs.splunk.com/Documentation/Splunk/latest/Data/TroubleshootHTTPEventCollector
+const (
+	CodeSuccess             = 0
+	CodeNoData              = 5
+	CodeInvalidDataFormat   = 6
+	CodeIncorrectIndex      = 7
+	CodeInternalServerError = 8
+	CodeServerBusy          = 9
+	CodeEventFieldRequired  = 12
+	CodeEventFieldBlank     = 13
+	CodeIndexedFieldsError  = 15
+	CodeServerQueueFull     = 18
+)
+
+type Response struct {
+	Text               string `json:"text"`
+	Code               int    `json:"code"`
+	InvalidEventNumber *int   `json:"invalid-event-number,omitempty"`
+	AckId              *int64 `json:"ackId,omitempty"`
+}
+
+// InvalidEvent reports whether the error is caused by the content of a single event,
+// splunk has indexed the events before it, so the rest could be resent without this one.
+func (r *Response) InvalidEvent() bool {
+	switch r.Code {
+	case CodeNoData, CodeInvalidDataFormat, CodeIncorrectIndex, CodeEventFieldRequired, CodeEventFieldBlank, CodeIndexedFieldsError:
+		return r.InvalidEventNumber != nil
+	}
+	return false
+}
+
+func (r *Response) Error() string {
+	return fmt.Sprintf("splunk hec response code %d: %s", r.Code, r.Text)
+}
+
+type ackRequest struct {
+	Acks []int64 `json:"acks"`
+}
+
+type ackResponse struct {
+	Acks map[string]bool `json:"acks"`
+}
+
+type Client struct {
+	config  *Config
+	channel string
+	hc      *http.Client
+}
+
+func NewClient(config *Config) (*Client, error) {
+	channel := config.Ack.Channel
+	if config.Ack.Enabled && channel == "" {
+		var err error
+		channel, err = newChannel()
+		if err != nil {
+			return nil, errors.WithMessage(err, "generate splunk hec channel failed")
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.InsecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+
+	return &Client{
+		config:  config,
+		channel: channel,
+		hc: &http.Client{
+			Timeout:   config.Timeout,
+			Transport: transport,
+		},
+	}, nil
+}
+
+// Send posts the events which are joined in one request body, the HEC response is returned
+// as long as splunk responds, the error is only returned when the request itself failed.
+func (c *Client) Send(events [][]byte) (*Response, error) {
+	body := bytes.Join(events, nil)
+	status, respBody, err := c.post(context.Background(), eventPath, body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &Response{}
+	if err := json.Unmarshal(respBody, resp); err != nil {
+		return nil, errors.Errorf("unmarshal splunk hec response(status %d) %s failed: %v", status, respBody, err)
+	}
+	return resp, nil
+}
+
+// WaitAcks polls the ack endpoint until all the ackIds are confirmed indexed or timeout
+func (c *Client) WaitAcks(ackIds []int64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.Ack.Timeout)
+	defer cancel()
+
+	pending := ackIds
+	ticker := time.NewTicker(c.config.Ack.PollInterval)
+	defer ticker.Stop()
+	for {
+		body, err := json.Marshal(ackRequest{Acks: pending})
+		if err != nil {
+			return err
+		}
+		status, respBody, err := c.post(ctx, ackPath, body)
+		if err != nil {
+			return errors.WithMessage(err, "query splunk hec ack failed")
+		}
+		if status != http.StatusOK {
+			return errors.Errorf("query splunk hec ack response status %d: %s", status, respBody)
+		}
+		resp := &ackResponse{}
+		if err := json.Unmarshal(respBody, resp); err != nil {
+			return errors.WithMessagef(err, "unmarshal splunk hec ack response %s failed", respBody)
+		}
+
+		var rest []int64
+		for _, id := range pending {
+			if !resp.Acks[strconv.FormatInt(id, 10)] {
+				rest = append(rest, id)
+			}
+		}
+		if len(rest) == 0 {
+			return nil
+		}
+		pending = rest
+
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("wait splunk hec acks %v timeout", pending)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Client) post(ctx context.Context, path string, body []byte) (int, []byte, error) {
+	url := strings.TrimSuffix(c.config.Endpoint, "/") + path
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", "Splunk "+c.config.Token)
+	req.Header.Set("Content-Type", "application/json")
+	if c.channel != "" {
+		req.Header.Set(channelHeader, c.channel)
+	}
+
+	resp, err := c.hc.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	out, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, err
+	}
+	return resp.StatusCode, out, nil
+}
+
+// newChannel generates a random UUID as the HEC request channel
+func newChannel() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
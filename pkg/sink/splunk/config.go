@@ -0,0 +1,49 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package splunk
+
+import (
+	"fmt"
+	"time"
+)
+
+type Config struct {
+	Endpoint           string        `yaml:"endpoint,omitempty" validate:"required"`
+	Token              string        `yaml:"token,omitempty" validate:"required"`
+	Index              string        `yaml:"index,omitempty"`
+	Sourcetype         string        `yaml:"sourcetype,omitempty"`
+	Source             string        `yaml:"source,omitempty"`
+	Host               string        `yaml:"host,omitempty"`
+	Timeout            time.Duration `yaml:"timeout,omitempty" default:"30s"`
+	InsecureSkipVerify bool          `yaml:"insecureSkipVerify,omitempty"`
+	Ack                Ack           `yaml:"ack,omitempty"`
+}
+
+// Ack enables HEC indexer acknowledgement, the batch is only acked after splunk confirms the events are indexed
+type Ack struct {
+	Enabled      bool          `yaml:"enabled,omitempty"`
+	Channel      string        `yaml:"channel,omitempty"`
+	PollInterval time.Duration `yaml:"pollInterval,omitempty" default:"1s"`
+	Timeout      time.Duration `yaml:"timeout,omitempty" default:"60s"`
+}
+
+func (c *Config) Validate() error {
+	if c.Ack.Enabled && c.Ack.PollInterval <= 0 {
+		return fmt.Errorf("splunk sink ack pollInterval must be positive")
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package splunk
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/core/result"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/sink/codec"
+	"github.com/loggie-io/loggie/pkg/util"
+	"github.com/loggie-io/loggie/pkg/util/runtime"
+)
+
+const Type = "splunk"
+
+// progressKey is the batch meta key of the send progress, which is kept while the batch is retried
+const progressKey = "splunk.progress"
+
+func init() {
+	pipeline.Register(api.SINK, Type, makeSink)
+}
+
+func makeSink(info pipeline.Info) api.Component {
+	return NewSink()
+}
+
+type hecEvent struct {
+	Time       float64         `json:"time,omitempty"`
+	Host       string          `json:"host,omitempty"`
+	Source     string          `json:"source,omitempty"`
+	Sourcetype string          `json:"sourcetype,omitempty"`
+	Index      string          `json:"index,omitempty"`
+	Event      json.RawMessage `json:"event"`
+}
+
+// batchProgress records the events of a batch already sent, so that the batch retried after a partial failure is
+// resent from the offset, instead of duplicating the events indexed
+type batchProgress struct {
+	// offset is the index of the first event not sent yet
+	offset int
+	// ackId is returned for the events sent from ackFrom, they are resent if not acked
+	ackId   *int64
+	ackFrom int
+}
+
+type Sink struct {
+	name   string
+	config *Config
+	cli    *Client
+	codec  codec.Codec
+
+	indexMatcher      [][]string
+	sourcetypeMatcher [][]string
+	sourceMatcher     [][]string
+	hostMatcher       [][]string
+}
+
+func NewSink() *Sink {
+	return &Sink{
+		config: &Config{},
+	}
+}
+
+func (s *Sink) Config() interface{} {
+	return s.config
+}
+
+func (s *Sink) SetCodec(c codec.Codec) {
+	s.codec = c
+}
+
+func (s *Sink) Category() api.Category {
+	return api.SINK
+}
+
+func (s *Sink) Type() api.Type {
+	return Type
+}
+
+func (s *Sink) String() string {
+	return fmt.Sprintf("%s/%s", api.SINK, Type)
+}
+
+func (s *Sink) Init(context api.Context) {
+	s.name = context.Name()
+	s.indexMatcher = util.InitMatcher(s.config.Index)
+	s.sourcetypeMatcher = util.InitMatcher(s.config.Sourcetype)
+	s.sourceMatcher = util.InitMatcher(s.config.Source)
+	s.hostMatcher = util.InitMatcher(s.config.Host)
+}
+
+func (s *Sink) Start() {
+	cli, err := NewClient(s.config)
+	if err != nil {
+		log.Error("start splunk sink failed: %+v", err)
+		return
+	}
+	s.cli = cli
+	log.Info("%s start, endpoint: %s, indexer ack: %t", s.String(), s.config.Endpoint, s.config.Ack.Enabled)
+}
+
+func (s *Sink) Stop() {
+}
+
+// Consume sends the batch to HEC. When splunk rejects an event because of its content, the events before it
+// have been indexed, so the invalid event is dropped and the rest are resent. Other errors fail the batch, which
+// would be retried by the retry interceptor from the events not sent yet.
+// No ackId is returned for the request rejected, so the events indexed before the invalid one are not guaranteed by
+// indexer acknowledgment.
+func (s *Sink) Consume(batch api.Batch) api.Result {
+	events := batch.Events()
+	if len(events) == 0 {
+		return result.Success()
+	}
+	if s.cli == nil {
+		return result.Fail(errors.New("splunk sink client not initialized"))
+	}
+
+	payloads := make([][]byte, 0, len(events))
+	for _, e := range events {
+		p, err := s.encode(e)
+		if err != nil {
+			log.Warn("encode event error: %+v", err)
+			return result.Fail(err)
+		}
+		payloads = append(payloads, p)
+	}
+
+	progress := progressOf(batch)
+	for start := progress.offset; start < len(payloads); {
+		resp, err := s.cli.Send(payloads[start:])
+		if err != nil {
+			log.Error("send to splunk hec error: %v", err)
+			return result.Fail(err)
+		}
+
+		if resp.Code == CodeSuccess {
+			progress.offset = len(payloads)
+			progress.ackId = resp.AckId
+			progress.ackFrom = start
+			break
+		}
+
+		if !resp.InvalidEvent() {
+			log.Error("send to splunk hec error: %s", resp.Error())
+			return result.Fail(resp)
+		}
+
+		invalid := start + *resp.InvalidEventNumber
+		if invalid >= len(payloads) {
+			return result.Fail(resp)
+		}
+		log.Warn("splunk hec rejected event: %s, drop it. %s", payloads[invalid], resp.Error())
+		start = invalid + 1
+		progress.offset = start
+	}
+
+	if s.config.Ack.Enabled && progress.ackId != nil {
+		if err := s.cli.WaitAcks([]int64{*progress.ackId}); err != nil {
+			log.Error("wait splunk hec indexer ack error: %v", err)
+			progress.offset = progress.ackFrom
+			progress.ackId = nil
+			return result.Fail(err)
+		}
+	}
+
+	return result.Success()
+}
+
+func progressOf(batch api.Batch) *batchProgress {
+	meta := batch.Meta()
+	if p, ok := meta[progressKey].(*batchProgress); ok {
+		return p
+	}
+	p := &batchProgress{}
+	if meta != nil {
+		meta[progressKey] = p
+	}
+	return p
+}
+
+func (s *Sink) encode(e api.Event) ([]byte, error) {
+	data, err := s.codec.Encode(e)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := runtime.NewObject(e.Header())
+	he := hecEvent{
+		Event: data,
+	}
+	if he.Index, err = runtime.PatternSelect(obj, s.config.Index, s.indexMatcher); err != nil {
+		return nil, err
+	}
+	if he.Sourcetype, err = runtime.PatternSelect(obj, s.config.Sourcetype, s.sourcetypeMatcher); err != nil {
+		return nil, err
+	}
+	if he.Source, err = runtime.PatternSelect(obj, s.config.Source, s.sourceMatcher); err != nil {
+		return nil, err
+	}
+	if he.Host, err = runtime.PatternSelect(obj, s.config.Host, s.hostMatcher); err != nil {
+		return nil, err
+	}
+
+	if e.Meta() != nil {
+		if t, ok := e.Meta().Get(event.SystemProductTimeKey); ok {
+			if ts, ok := t.(time.Time); ok {
+				he.Time = float64(util.UnixMilli(ts)) / 1e3
+			}
+		}
+	}
+
+	return json.Marshal(he)
+}
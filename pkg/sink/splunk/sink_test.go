@@ -0,0 +1,203 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package splunk
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/batch"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+)
+
+// fakeHEC indexes every event until the one whose body is "bad", just like splunk does
+type fakeHEC struct {
+	lock    sync.Mutex
+	busy    bool
+	indexed []hecEvent
+	acked   int
+
+	// busyOnResend makes the server busy after an invalid event rejected
+	busyOnResend bool
+}
+
+func (f *fakeHEC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if r.Header.Get("Authorization") != "Splunk token" {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"text":"Invalid token","code":4}`)
+		return
+	}
+
+	switch r.URL.Path {
+	case ackPath:
+		f.acked++
+		// confirm on the second poll
+		fmt.Fprintf(w, `{"acks":{"0":%t}}`, f.acked > 1)
+		return
+	case eventPath:
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if f.busy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"text":"Server is busy","code":9}`)
+		return
+	}
+
+	dec := json.NewDecoder(bufio.NewReader(r.Body))
+	for i := 0; dec.More(); i++ {
+		e := hecEvent{}
+		if err := dec.Decode(&e); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if string(e.Event) == `"bad"` {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"text":"Event field cannot be blank","code":13,"invalid-event-number":%d}`, i)
+			f.busy = f.busyOnResend
+			return
+		}
+		f.indexed = append(f.indexed, e)
+	}
+	fmt.Fprint(w, `{"text":"Success","code":0,"ackId":0}`)
+}
+
+// bodyCodec encodes the event body as a json string
+type bodyCodec struct{}
+
+func (c *bodyCodec) Init() {
+}
+
+func (c *bodyCodec) Encode(e api.Event) ([]byte, error) {
+	return json.Marshal(string(e.Body()))
+}
+
+func newTestSink(endpoint string, ack bool) *Sink {
+	s := NewSink()
+	s.config = &Config{
+		Endpoint: endpoint,
+		Token:    "token",
+		Index:    "${fields.index}",
+		Timeout:  5 * time.Second,
+		Ack: Ack{
+			Enabled:      ack,
+			PollInterval: 10 * time.Millisecond,
+			Timeout:      time.Second,
+		},
+	}
+	s.SetCodec(&bodyCodec{})
+	s.indexMatcher = [][]string{{"${fields.index}", "fields.index"}}
+	s.Start()
+	return s
+}
+
+func newTestBatch(bodies ...string) api.Batch {
+	var events []api.Event
+	for _, b := range bodies {
+		e := event.NewEvent(map[string]interface{}{
+			"fields": map[string]interface{}{
+				"index": "main",
+			},
+		}, []byte(b))
+		e.M = event.NewDefaultMeta()
+		events = append(events, e)
+	}
+	return batch.NewBatchWithEvents(events)
+}
+
+func TestSink_ConsumeDropInvalidEvent(t *testing.T) {
+	log.InitDefaultLogger()
+	hec := &fakeHEC{}
+	server := httptest.NewServer(hec)
+	defer server.Close()
+
+	s := newTestSink(server.URL, true)
+	r := s.Consume(newTestBatch("a", "bad", "b"))
+	if r.Status() != api.SUCCESS {
+		t.Fatalf("consume result status %v, err: %v", r.Status(), r.Error())
+	}
+
+	var got []string
+	for _, e := range hec.indexed {
+		if e.Index != "main" {
+			t.Errorf("event index %s, want main", e.Index)
+		}
+		got = append(got, string(e.Event))
+	}
+	if strings.Join(got, ",") != `"a","b"` {
+		t.Errorf("indexed events %v, want a and b", got)
+	}
+	if hec.acked < 2 {
+		t.Errorf("expect polling indexer ack until confirmed, polled %d times", hec.acked)
+	}
+}
+
+func TestSink_ConsumeServerBusy(t *testing.T) {
+	log.InitDefaultLogger()
+	hec := &fakeHEC{busy: true}
+	server := httptest.NewServer(hec)
+	defer server.Close()
+
+	s := newTestSink(server.URL, false)
+	r := s.Consume(newTestBatch("a"))
+	if r.Status() != api.FAIL {
+		t.Fatalf("expect failed result for retry when server busy, got %v", r.Status())
+	}
+	if !strings.Contains(r.Error().Error(), "code 9") {
+		t.Errorf("unexpected error %v", r.Error())
+	}
+}
+
+func TestSink_ConsumeRetryFromProgress(t *testing.T) {
+	log.InitDefaultLogger()
+	hec := &fakeHEC{busyOnResend: true}
+	server := httptest.NewServer(hec)
+	defer server.Close()
+
+	s := newTestSink(server.URL, false)
+	b := newTestBatch("a", "bad", "b")
+	if r := s.Consume(b); r.Status() != api.FAIL {
+		t.Fatalf("expect failed result when resending, got %v", r.Status())
+	}
+
+	// the batch retried is resent from the event after the invalid one
+	hec.busy = false
+	if r := s.Consume(b); r.Status() != api.SUCCESS {
+		t.Fatalf("consume result status %v, err: %v", r.Status(), r.Error())
+	}
+	var got []string
+	for _, e := range hec.indexed {
+		got = append(got, string(e.Event))
+	}
+	if strings.Join(got, ",") != `"a","b"` {
+		t.Errorf("indexed events %v, want a and b only once", got)
+	}
+}
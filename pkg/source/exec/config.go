@@ -0,0 +1,62 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	ModeInterval = "interval"
+	ModeDaemon   = "daemon"
+
+	SplitLine  = "line"
+	SplitWhole = "whole"
+)
+
+type Config struct {
+	// Command is the argv of the process, wrap it with sh -c to use the shell features like pipes
+	Command []string          `yaml:"command,omitempty" validate:"required"`
+	Env     map[string]string `yaml:"env,omitempty"`
+	WorkDir string            `yaml:"workDir,omitempty"`
+	// Mode interval runs the command periodically, daemon keeps the command running and restarts it after exited
+	Mode     string        `yaml:"mode,omitempty" default:"interval" validate:"oneof=interval daemon"`
+	Interval time.Duration `yaml:"interval,omitempty" default:"1m"`
+	// Timeout kills the command in interval mode when it runs too long
+	Timeout      time.Duration `yaml:"timeout,omitempty" default:"30s"`
+	RestartDelay time.Duration `yaml:"restartDelay,omitempty" default:"5s"`
+	// Split makes one event per stdout line, or one event of the whole stdout in interval mode
+	Split string `yaml:"split,omitempty" default:"line" validate:"oneof=line whole"`
+	// MaxOutputBytes limits the stdout and stderr kept in interval mode, the rest is discarded
+	MaxOutputBytes int `yaml:"maxOutputBytes,omitempty" default:"1048576"`
+}
+
+func (c *Config) Validate() error {
+	if c.Mode == ModeInterval {
+		if c.Interval <= 0 {
+			return fmt.Errorf("exec source interval must be positive")
+		}
+		if c.Timeout <= 0 {
+			return fmt.Errorf("exec source timeout must be positive")
+		}
+	}
+	if c.Mode == ModeDaemon && c.Split == SplitWhole {
+		return fmt.Errorf("exec source split whole is not supported in daemon mode")
+	}
+	return nil
+}
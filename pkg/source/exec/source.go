@@ -0,0 +1,330 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+const (
+	Type = "exec"
+
+	headerKey = "exec"
+)
+
+func init() {
+	pipeline.Register(api.SOURCE, Type, makeSource)
+}
+
+func makeSource(info pipeline.Info) api.Component {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Exec{
+		config:    &Config{},
+		eventPool: info.EventPool,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+type Exec struct {
+	name      string
+	config    *Config
+	eventPool *event.Pool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (s *Exec) Config() interface{} {
+	return s.config
+}
+
+func (s *Exec) Category() api.Category {
+	return api.SOURCE
+}
+
+func (s *Exec) Type() api.Type {
+	return Type
+}
+
+func (s *Exec) String() string {
+	return fmt.Sprintf("%s/%s", api.SOURCE, Type)
+}
+
+func (s *Exec) Init(context api.Context) {
+	s.name = context.Name()
+}
+
+func (s *Exec) Start() {
+	log.Info("%s start, command: %v, mode: %s", s.String(), s.config.Command, s.config.Mode)
+}
+
+func (s *Exec) Stop() {
+	// the running command would be killed
+	s.cancel()
+}
+
+func (s *Exec) ProductLoop(productFunc api.ProductFunc) {
+	log.Info("%s start product loop", s.String())
+
+	if s.config.Mode == ModeDaemon {
+		s.runDaemon(productFunc)
+		return
+	}
+	s.runInterval(productFunc)
+}
+
+func (s *Exec) Commit(events []api.Event) {
+	s.eventPool.PutAll(events)
+}
+
+// runInterval runs the command one at a time, a tick arrived while the command is still running is merged into
+// one run after it finished, so slow runs would not pile up.
+func (s *Exec) runInterval(productFunc api.ProductFunc) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		start := time.Now()
+		s.runOnce(productFunc)
+		if cost := time.Since(start); cost > s.config.Interval {
+			log.Warn("%s command %v took %s, longer than the interval %s", s.String(), s.config.Command, cost, s.config.Interval)
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Exec) command() *exec.Cmd {
+	cmd := exec.Command(s.config.Command[0], s.config.Command[1:]...)
+	cmd.Dir = s.config.WorkDir
+	if len(s.config.Env) > 0 {
+		cmd.Env = os.Environ()
+		for k, v := range s.config.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+	}
+	// run in a new process group, so the children forked by the command could be killed together
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return cmd
+}
+
+// killOnDone kills the process group of the started command when ctx is done, the returned function
+// should be called after the command exited.
+func killOnDone(ctx context.Context, cmd *exec.Cmd) func() {
+	exited := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		case <-exited:
+		}
+	}()
+	return func() {
+		close(exited)
+	}
+}
+
+func (s *Exec) runOnce(productFunc api.ProductFunc) {
+	ctx, cancel := context.WithTimeout(s.ctx, s.config.Timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: s.config.MaxOutputBytes}
+	stderr := &limitedBuffer{limit: s.config.MaxOutputBytes}
+	cmd := s.command()
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := cmd.Start()
+	if err == nil {
+		exited := killOnDone(ctx, cmd)
+		err = cmd.Wait()
+		exited()
+	}
+	duration := time.Since(start)
+
+	select {
+	case <-s.ctx.Done():
+		// killed by stop
+		return
+	default:
+	}
+
+	exitCode := 0
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	} else if err != nil {
+		exitCode = -1
+	}
+	timedOut := ctx.Err() == context.DeadlineExceeded
+	if err != nil {
+		log.Warn("%s run command %v error: %v, timed out: %v", s.String(), s.config.Command, err, timedOut)
+	}
+
+	fields := map[string]interface{}{
+		"command":   strings.Join(s.config.Command, " "),
+		"exitCode":  exitCode,
+		"duration":  duration.Seconds(),
+		"stderr":    stderr.String(),
+		"timedOut":  timedOut,
+		"truncated": stdout.truncated,
+	}
+
+	if s.config.Split == SplitWhole {
+		s.product(productFunc, fields, bytes.TrimRight(stdout.Bytes(), "\n"))
+		return
+	}
+
+	lines := 0
+	scanner := bufio.NewScanner(bytes.NewReader(stdout.Bytes()))
+	scanner.Buffer(make([]byte, 0, 64*1024), s.config.MaxOutputBytes+1)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		lines++
+		s.product(productFunc, fields, append([]byte(nil), line...))
+	}
+	if lines == 0 {
+		// still report the result, eg: the command failed without any output
+		s.product(productFunc, fields, nil)
+	}
+}
+
+// runDaemon keeps the command running, each line of stdout and stderr is an event
+func (s *Exec) runDaemon(productFunc api.ProductFunc) {
+	for {
+		cmd := s.command()
+		stdout, err := cmd.StdoutPipe()
+		if err == nil {
+			var stderr io.ReadCloser
+			stderr, err = cmd.StderrPipe()
+			if err == nil {
+				err = cmd.Start()
+			}
+			if err == nil {
+				pid := cmd.Process.Pid
+				exited := killOnDone(s.ctx, cmd)
+				var wg sync.WaitGroup
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					s.readLines(stdout, "stdout", pid, productFunc)
+				}()
+				go func() {
+					defer wg.Done()
+					s.readLines(stderr, "stderr", pid, productFunc)
+				}()
+				// pipes must be read out before wait
+				wg.Wait()
+				err = cmd.Wait()
+				exited()
+			}
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		default:
+		}
+		log.Warn("%s command %v exited: %v, restart after %s", s.String(), s.config.Command, err, s.config.RestartDelay)
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(s.config.RestartDelay):
+		}
+	}
+}
+
+func (s *Exec) readLines(r io.Reader, stream string, pid int, productFunc api.ProductFunc) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), s.config.MaxOutputBytes)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		s.product(productFunc, map[string]interface{}{
+			"command": strings.Join(s.config.Command, " "),
+			"stream":  stream,
+			"pid":     pid,
+		}, append([]byte(nil), line...))
+	}
+	if err := scanner.Err(); err != nil {
+		log.Warn("%s read %s of command %v error: %v", s.String(), stream, s.config.Command, err)
+		// drain the pipe so that the command would not be blocked
+		_, _ = io.Copy(io.Discard, r)
+	}
+}
+
+func (s *Exec) product(productFunc api.ProductFunc, fields map[string]interface{}, body []byte) {
+	e := s.eventPool.Get()
+	header := e.Header()
+	if header == nil {
+		header = make(map[string]interface{})
+	}
+	f := make(map[string]interface{}, len(fields))
+	for k, v := range fields {
+		f[k] = v
+	}
+	header[headerKey] = f
+	e.Fill(e.Meta(), header, body)
+	productFunc(e)
+}
+
+// limitedBuffer keeps the first limit bytes written, and discards the rest
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remain := b.limit - b.Len()
+	if remain <= 0 {
+		b.truncated = b.truncated || len(p) > 0
+		return len(p), nil
+	}
+	if len(p) > remain {
+		b.truncated = true
+		b.Buffer.Write(p[:remain])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
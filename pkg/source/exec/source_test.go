@@ -0,0 +1,136 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creasty/defaults"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+type collector struct {
+	lock   sync.Mutex
+	events []api.Event
+}
+
+func (c *collector) product(e api.Event) api.Result {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.events = append(c.events, e)
+	return nil
+}
+
+func (c *collector) get() []api.Event {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.events
+}
+
+func newTestExec(t *testing.T, config *Config) *Exec {
+	if err := defaults.Set(config); err != nil {
+		t.Fatal(err)
+	}
+	s := makeSource(pipeline.Info{EventPool: event.NewDefaultPool(100)}).(*Exec)
+	s.config = config
+	return s
+}
+
+func TestExec_RunOnceLines(t *testing.T) {
+	log.InitDefaultLogger()
+	s := newTestExec(t, &Config{
+		Command: []string{"sh", "-c", "echo a; echo; echo $GREETING; echo oops 1>&2; exit 3"},
+		Env:     map[string]string{"GREETING": "hello"},
+	})
+
+	c := &collector{}
+	s.runOnce(c.product)
+
+	events := c.get()
+	if len(events) != 2 || string(events[0].Body()) != "a" || string(events[1].Body()) != "hello" {
+		t.Fatalf("unexpected events %v", events)
+	}
+	fields := events[1].Header()[headerKey].(map[string]interface{})
+	if fields["exitCode"] != 3 || fields["stderr"] != "oops\n" || fields["timedOut"] != false {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
+
+func TestExec_RunOnceTimeout(t *testing.T) {
+	log.InitDefaultLogger()
+	s := newTestExec(t, &Config{
+		Command: []string{"sleep", "5"},
+		Timeout: 100 * time.Millisecond,
+		Split:   SplitWhole,
+	})
+
+	c := &collector{}
+	start := time.Now()
+	s.runOnce(c.product)
+	if time.Since(start) > 3*time.Second {
+		t.Fatalf("command was not killed after timeout")
+	}
+
+	events := c.get()
+	if len(events) != 1 {
+		t.Fatalf("expect 1 event, got %d", len(events))
+	}
+	if fields := events[0].Header()[headerKey].(map[string]interface{}); fields["timedOut"] != true {
+		t.Errorf("expect timed out, got fields %v", fields)
+	}
+}
+
+func TestExec_Daemon(t *testing.T) {
+	log.InitDefaultLogger()
+	s := newTestExec(t, &Config{
+		Command:      []string{"sh", "-c", "echo out; echo err 1>&2; sleep 10"},
+		Mode:         ModeDaemon,
+		RestartDelay: time.Hour,
+	})
+
+	c := &collector{}
+	done := make(chan struct{})
+	go func() {
+		s.ProductLoop(c.product)
+		close(done)
+	}()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(c.get()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	streams := map[string]string{}
+	for _, e := range c.get() {
+		streams[e.Header()[headerKey].(map[string]interface{})["stream"].(string)] = string(e.Body())
+	}
+	if streams["stdout"] != "out" || streams["stderr"] != "err" {
+		t.Errorf("unexpected events by stream %v", streams)
+	}
+
+	s.Stop()
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("product loop not exited after stop")
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	ModeSteady = "steady"
+	ModeBurst  = "burst"
+
+	defaultTemplate = `{{ip}} - - [{{timestamp "02/Jan/2006:15:04:05 -0700"}}] "{{pick "GET" "POST" "PUT" "DELETE"}} /api/v1/{{pick "users" "orders" "items"}}/{{randInt 1 10000}} HTTP/1.1" {{pick "200" "200" "200" "201" "404" "500"}} {{randInt 100 10000}} {{level}}`
+)
+
+type Config struct {
+	// Templates are go templates of the body, one of them is picked randomly for each event. Functions available:
+	// ip, timestamp [layout], level, pick a b..., randInt min max, uuid, seq
+	Templates []string `yaml:"templates,omitempty"`
+	// SplitLines sends each line of a multiline body as an event, eg: to reproduce multiline merging problems
+	SplitLines bool `yaml:"splitLines,omitempty"`
+
+	// Mode steady sends events at the rate, burst sends burstSize events every burstInterval as fast as possible
+	Mode string `yaml:"mode,omitempty" default:"steady" validate:"oneof=steady burst"`
+	// Rate is the events per second in steady mode, 0 means no limit
+	Rate          int           `yaml:"rate,omitempty" default:"1000"`
+	BurstSize     int           `yaml:"burstSize,omitempty" default:"10000"`
+	BurstInterval time.Duration `yaml:"burstInterval,omitempty" default:"10s"`
+	// Total stops generating after the count of events sent, 0 means no limit
+	Total int64 `yaml:"total,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if c.Rate < 0 || c.Total < 0 {
+		return fmt.Errorf("generator source rate and total should not be negative")
+	}
+	if c.Mode == ModeBurst && (c.BurstSize <= 0 || c.BurstInterval <= 0) {
+		return fmt.Errorf("generator source burstSize and burstInterval must be positive in burst mode")
+	}
+	for _, t := range c.templates() {
+		if _, err := newRenderer(t, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) templates() []string {
+	if len(c.Templates) == 0 {
+		return []string{defaultTemplate}
+	}
+	return c.Templates
+}
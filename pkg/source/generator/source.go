@@ -0,0 +1,229 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+const Type = "generator"
+
+func init() {
+	pipeline.Register(api.SOURCE, Type, makeSource)
+}
+
+func makeSource(info pipeline.Info) api.Component {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Generator{
+		config:    &Config{},
+		eventPool: info.EventPool,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Generator produces synthetic events for benchmarking pipelines
+type Generator struct {
+	name      string
+	config    *Config
+	eventPool *event.Pool
+	renderers []*renderer
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	sent       int64
+	startTime  time.Time
+	reportOnce sync.Once
+}
+
+func (g *Generator) Config() interface{} {
+	return g.config
+}
+
+func (g *Generator) Category() api.Category {
+	return api.SOURCE
+}
+
+func (g *Generator) Type() api.Type {
+	return Type
+}
+
+func (g *Generator) String() string {
+	return fmt.Sprintf("%s/%s", api.SOURCE, Type)
+}
+
+func (g *Generator) Init(context api.Context) {
+	g.name = context.Name()
+}
+
+func (g *Generator) Start() {
+	seed := time.Now().UnixNano()
+	for i, t := range g.config.templates() {
+		r, err := newRenderer(t, seed+int64(i))
+		if err != nil {
+			log.Error("%s init template failed: %v", g.String(), err)
+			continue
+		}
+		g.renderers = append(g.renderers, r)
+	}
+	g.startTime = time.Now()
+	log.Info("%s start, mode: %s, rate: %d, total: %d", g.String(), g.config.Mode, g.config.Rate, g.config.Total)
+}
+
+func (g *Generator) Stop() {
+	g.cancel()
+	g.report()
+}
+
+func (g *Generator) ProductLoop(productFunc api.ProductFunc) {
+	log.Info("%s start product loop", g.String())
+	if len(g.renderers) == 0 {
+		log.Error("%s has no valid template, stop generating", g.String())
+		return
+	}
+
+	if g.config.Mode == ModeBurst {
+		g.runBurst(productFunc)
+	} else {
+		g.runSteady(productFunc)
+	}
+	g.report()
+}
+
+func (g *Generator) Commit(events []api.Event) {
+	g.eventPool.PutAll(events)
+}
+
+// runSteady sends events at the configured rate, it sleeps whenever the events sent are ahead of the schedule,
+// so the rate would be kept in average without a ticker for every event.
+func (g *Generator) runSteady(productFunc api.ProductFunc) {
+	rate := int64(g.config.Rate)
+	for {
+		if rate > 0 {
+			sent := atomic.LoadInt64(&g.sent)
+			ahead := time.Duration(sent*int64(time.Second)/rate) - time.Since(g.startTime)
+			if ahead > 0 && !g.sleep(ahead) {
+				return
+			}
+		}
+
+		if !g.generate(productFunc) {
+			return
+		}
+	}
+}
+
+// runBurst sends burstSize events as fast as possible every burstInterval
+func (g *Generator) runBurst(productFunc api.ProductFunc) {
+	ticker := time.NewTicker(g.config.BurstInterval)
+	defer ticker.Stop()
+
+	for {
+		for i := 0; i < g.config.BurstSize; i++ {
+			if !g.generate(productFunc) {
+				return
+			}
+		}
+
+		select {
+		case <-g.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// generate sends one rendered body, a multiline body would be sent as multi events when splitLines enabled.
+// It returns false when the generator should stop.
+func (g *Generator) generate(productFunc api.ProductFunc) bool {
+	select {
+	case <-g.ctx.Done():
+		return false
+	default:
+	}
+
+	r := g.renderers[0]
+	if len(g.renderers) > 1 {
+		r = g.renderers[r.rnd.Intn(len(g.renderers))]
+	}
+	body, err := r.render()
+	if err != nil {
+		log.Warn("%s render template failed: %v", g.String(), err)
+		return true
+	}
+
+	if !g.config.SplitLines {
+		return g.product(productFunc, body)
+	}
+	for _, line := range bytes.Split(body, []byte{'\n'}) {
+		if !g.product(productFunc, line) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Generator) product(productFunc api.ProductFunc, body []byte) bool {
+	if g.config.Total > 0 && atomic.LoadInt64(&g.sent) >= g.config.Total {
+		return false
+	}
+
+	e := g.eventPool.Get()
+	e.Fill(e.Meta(), e.Header(), body)
+	productFunc(e)
+
+	sent := atomic.AddInt64(&g.sent, 1)
+	if g.config.Total > 0 && sent >= g.config.Total {
+		log.Info("%s reached total %d events", g.String(), g.config.Total)
+		return false
+	}
+	return true
+}
+
+func (g *Generator) sleep(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-g.ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// report logs the throughput achieved, only once for both reaching the total and stopping
+func (g *Generator) report() {
+	if g.startTime.IsZero() {
+		return
+	}
+	g.reportOnce.Do(func() {
+		sent := atomic.LoadInt64(&g.sent)
+		cost := time.Since(g.startTime)
+		log.Info("%s generated %d events in %s, throughput: %.2f events/s", g.String(), sent, cost, float64(sent)/cost.Seconds())
+	})
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creasty/defaults"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+type collector struct {
+	lock   sync.Mutex
+	events []api.Event
+}
+
+func (c *collector) product(e api.Event) api.Result {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.events = append(c.events, e)
+	return nil
+}
+
+func (c *collector) get() []api.Event {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.events
+}
+
+func newTestGenerator(t *testing.T, config *Config) *Generator {
+	if err := defaults.Set(config); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	g := makeSource(pipeline.Info{EventPool: event.NewDefaultPool(100)}).(*Generator)
+	g.config = config
+	g.Start()
+	return g
+}
+
+func TestRenderer(t *testing.T) {
+	r, err := newRenderer(`{{seq}} {{ip}} {{level}} {{pick "a" "b"}} {{randInt 3 3}} {{uuid}} {{timestamp "2006"}}`, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := r.render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := strings.Fields(string(body))
+	if len(fields) != 7 {
+		t.Fatalf("unexpected body %s", body)
+	}
+	if fields[0] != "1" || strings.Count(fields[1], ".") != 3 || fields[4] != "3" || len(fields[5]) != 36 ||
+		fields[6] != time.Now().Format("2006") {
+		t.Errorf("unexpected body %s", body)
+	}
+	if fields[3] != "a" && fields[3] != "b" {
+		t.Errorf("unexpected pick %s", fields[3])
+	}
+}
+
+func TestConfig_ValidateTemplate(t *testing.T) {
+	c := &Config{Templates: []string{"{{unknown}}"}}
+	if err := c.Validate(); err == nil {
+		t.Error("expect error of unknown template function")
+	}
+}
+
+func TestGenerator_TotalSplitLines(t *testing.T) {
+	log.InitDefaultLogger()
+	g := newTestGenerator(t, &Config{
+		Templates:  []string{"line {{seq}}\n  at stack"},
+		SplitLines: true,
+		Rate:       0,
+		Total:      5,
+	})
+
+	c := &collector{}
+	g.ProductLoop(c.product)
+
+	events := c.get()
+	if len(events) != 5 {
+		t.Fatalf("expect 5 events, got %d", len(events))
+	}
+	if string(events[0].Body()) != "line 1" || string(events[1].Body()) != "  at stack" || string(events[4].Body()) != "line 3" {
+		t.Errorf("unexpected events %v", events)
+	}
+}
+
+func TestGenerator_Rate(t *testing.T) {
+	log.InitDefaultLogger()
+	g := newTestGenerator(t, &Config{
+		Rate:  100,
+		Total: 20,
+	})
+
+	start := time.Now()
+	c := &collector{}
+	g.ProductLoop(c.product)
+
+	if len(c.get()) != 20 {
+		t.Fatalf("expect 20 events, got %d", len(c.get()))
+	}
+	if cost := time.Since(start); cost < 150*time.Millisecond {
+		t.Errorf("rate is not limited, cost %s", cost)
+	}
+}
+
+func TestGenerator_BurstStop(t *testing.T) {
+	log.InitDefaultLogger()
+	g := newTestGenerator(t, &Config{
+		Mode:          ModeBurst,
+		BurstSize:     10,
+		BurstInterval: time.Hour,
+	})
+
+	c := &collector{}
+	done := make(chan struct{})
+	go func() {
+		g.ProductLoop(c.product)
+		close(done)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	g.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("product loop not stopped")
+	}
+	if len(c.get()) != 10 {
+		t.Errorf("expect one burst of 10 events, got %d", len(c.get()))
+	}
+}
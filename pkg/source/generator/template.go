@@ -0,0 +1,107 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var levels = []string{"DEBUG", "INFO", "INFO", "INFO", "WARN", "ERROR"}
+
+// renderer renders a template, it is not safe for concurrent use
+type renderer struct {
+	tpl *template.Template
+	rnd *rand.Rand
+	seq int64
+	buf bytes.Buffer
+}
+
+func newRenderer(text string, seed int64) (*renderer, error) {
+	r := &renderer{
+		rnd: rand.New(rand.NewSource(seed)),
+	}
+	tpl, err := template.New("body").Funcs(template.FuncMap{
+		"ip":        r.ip,
+		"timestamp": r.timestamp,
+		"level":     r.level,
+		"pick":      r.pick,
+		"randInt":   r.randInt,
+		"uuid":      r.uuid,
+		"seq":       r.sequence,
+	}).Parse(text)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "parse generator template %s failed", text)
+	}
+	r.tpl = tpl
+	return r, nil
+}
+
+func (r *renderer) render() ([]byte, error) {
+	r.seq++
+	r.buf.Reset()
+	if err := r.tpl.Execute(&r.buf, nil); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), r.buf.Bytes()...), nil
+}
+
+func (r *renderer) ip() string {
+	return fmt.Sprintf("%d.%d.%d.%d", r.rnd.Intn(223)+1, r.rnd.Intn(256), r.rnd.Intn(256), r.rnd.Intn(254)+1)
+}
+
+func (r *renderer) timestamp(layout ...string) string {
+	if len(layout) > 0 {
+		return time.Now().Format(layout[0])
+	}
+	return time.Now().Format(time.RFC3339Nano)
+}
+
+func (r *renderer) level() string {
+	return levels[r.rnd.Intn(len(levels))]
+}
+
+func (r *renderer) pick(items ...string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	return items[r.rnd.Intn(len(items))]
+}
+
+func (r *renderer) randInt(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + r.rnd.Intn(max-min+1)
+}
+
+func (r *renderer) uuid() string {
+	b := make([]byte, 16)
+	r.rnd.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+func (r *renderer) sequence() int64 {
+	return r.seq
+}
@@ -23,8 +23,12 @@ import (
 	"github.com/loggie-io/loggie/pkg/core/event"
 	"github.com/loggie-io/loggie/pkg/core/log"
 	"github.com/loggie-io/loggie/pkg/pipeline"
+	_ "github.com/loggie-io/loggie/pkg/sink/grpc/compressor"
 	pb "github.com/loggie-io/loggie/pkg/sink/grpc/pb"
+	pbv2 "github.com/loggie-io/loggie/pkg/sink/grpc/pb/v2"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"io"
 	"net"
 )
@@ -52,6 +56,7 @@ type Source struct {
 	eventPool  *event.Pool
 	config     *Config
 	grpcServer *grpc.Server
+	health     *health.Server
 	bc         *batchChain
 }
 
@@ -82,6 +87,10 @@ func (s *Source) Stop() {
 	if s.bc != nil {
 		s.bc.stop()
 	}
+	// the sinks balance the batches to other aggregators before the connections are closed
+	if s.health != nil {
+		s.health.Shutdown()
+	}
 	if s.grpcServer != nil {
 		s.grpcServer.GracefulStop()
 	}
@@ -98,7 +107,11 @@ func (s *Source) ProductLoop(productFunc api.ProductFunc) {
 		log.Panic("grpc server listen ip(%s) err: %v", ip, err)
 	}
 	grpcServer := grpc.NewServer()
+	// logStream of v1 is kept for the sinks not upgraded yet
 	pb.RegisterLogServiceServer(grpcServer, s)
+	pbv2.RegisterLogServiceServer(grpcServer, &serverV2{s: s})
+	s.health = health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, s.health)
 	go grpcServer.Serve(listener)
 	s.grpcServer = grpcServer
 	log.Info("grpc server start listing: %s", ip)
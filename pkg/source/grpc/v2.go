@@ -0,0 +1,89 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"io"
+	"sync"
+
+	"github.com/loggie-io/loggie/pkg/core/log"
+	pb "github.com/loggie-io/loggie/pkg/sink/grpc/pb"
+	pbv2 "github.com/loggie-io/loggie/pkg/sink/grpc/pb/v2"
+)
+
+// serverV2 serves the v2 batch stream, batches received in the stream are acked separately once all the events
+// of a batch committed, so the sender could pipeline batches without waiting.
+type serverV2 struct {
+	pbv2.UnimplementedLogServiceServer
+	s *Source
+}
+
+func (v *serverV2) BatchStream(stream pbv2.LogService_BatchStreamServer) error {
+	var sendLock sync.Mutex
+	send := func(ack *pbv2.BatchAck) {
+		sendLock.Lock()
+		defer sendLock.Unlock()
+		if err := stream.Send(ack); err != nil {
+			log.Warn("send batch ack fail: %s", err)
+		}
+	}
+
+	// acks could only be sent before the handler returned
+	var waiting sync.WaitGroup
+	defer waiting.Wait()
+
+	for {
+		eventBatch, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		b := newBatch(v.s.config.Timeout)
+		for _, pe := range eventBatch.GetEvents() {
+			e := v.s.eventPool.Get()
+			meta := e.Meta()
+			for k, value := range pe.GetMeta() {
+				meta.Set(k, value.Interface())
+			}
+			e.Fill(meta, pbv2.ToMap(pe.GetHeader()), pe.GetBody())
+			b.append(e)
+		}
+		if b.size() == 0 {
+			send(&pbv2.BatchAck{Id: eventBatch.GetId(), Success: true})
+			continue
+		}
+
+		v.s.bc.append(b)
+		waiting.Add(1)
+		go func(id uint64, b *batch) {
+			defer waiting.Done()
+			send(newBatchAck(id, b.wait()))
+		}(eventBatch.GetId(), b)
+	}
+}
+
+func newBatchAck(id uint64, resp *pb.LogResp) *pbv2.BatchAck {
+	return &pbv2.BatchAck{
+		Id:       id,
+		Success:  resp.GetSuccess(),
+		Count:    resp.GetCount(),
+		ErrorMsg: resp.GetErrorMsg(),
+	}
+}
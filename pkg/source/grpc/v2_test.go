@@ -0,0 +1,121 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/sink/grpc/compressor"
+	pbv2 "github.com/loggie-io/loggie/pkg/sink/grpc/pb/v2"
+)
+
+func TestServerV2_BatchStream(t *testing.T) {
+	log.InitDefaultLogger()
+	s := &Source{
+		eventPool: event.NewDefaultPool(100),
+		config:    &Config{Timeout: 5 * time.Second, MaintenanceInterval: time.Second},
+	}
+
+	received := make(chan api.Event, 10)
+	s.bc = newBatchChain(func(e api.Event) api.Result {
+		received <- e
+		return nil
+	}, s.config.MaintenanceInterval)
+	go s.bc.run()
+	defer s.bc.stop()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	pbv2.RegisterLogServiceServer(server, &serverV2{s: s})
+	go server.Serve(listener)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := pbv2.NewLogServiceClient(conn).BatchStream(ctx, grpc.UseCompressor(compressor.Zstd))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// two batches are sent before any ack
+	for id := uint64(1); id <= 2; id++ {
+		err := stream.Send(&pbv2.EventBatch{
+			Id: id,
+			Events: []*pbv2.Event{{
+				Meta:   pbv2.NewValues(map[string]interface{}{"systemPipelineName": "agent"}),
+				Header: pbv2.NewValues(map[string]interface{}{"offset": 10, "fields": map[string]string{"app": "a"}}),
+				Body:   []byte("hello"),
+			}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var events []api.Event
+	for i := 0; i < 2; i++ {
+		select {
+		case e := <-received:
+			events = append(events, e)
+		case <-ctx.Done():
+			t.Fatal("events not received")
+		}
+	}
+	e := events[0]
+	if string(e.Body()) != "hello" || e.Header()["offset"] != int64(10) ||
+		e.Header()["fields"].(map[string]interface{})["app"] != "a" {
+		t.Errorf("unexpected event %s", e.String())
+	}
+	if v, _ := e.Meta().Get("systemPipelineName"); v != "agent" {
+		t.Errorf("unexpected meta %v", e.Meta().GetAll())
+	}
+
+	// ack the second batch first
+	s.bc.ack(events[1:])
+	s.bc.ack(events[:1])
+	acked := map[uint64]bool{}
+	for i := 0; i < 2; i++ {
+		ack, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ack.Success || ack.Count != 1 {
+			t.Errorf("unexpected ack %v", ack)
+		}
+		acked[ack.Id] = true
+	}
+	if !acked[1] || !acked[2] {
+		t.Errorf("unexpected acks %v", acked)
+	}
+}
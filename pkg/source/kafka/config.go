@@ -17,6 +17,8 @@ limitations under the License.
 package kafka
 
 import (
+	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/segmentio/kafka-go"
@@ -28,21 +30,42 @@ const (
 )
 
 type Config struct {
-	Brokers            []string      `yaml:"brokers,omitempty" validate:"required"`
-	Topic              string        `yaml:"topic,omitempty" validate:"required"`
-	GroupId            string        `yaml:"groupId,omitempty" default:"loggie"`
-	QueueCapacity      int           `yaml:"queueCapacity" default:"100"`
-	MinAcceptedBytes   int           `yaml:"minAcceptedBytes" default:"1"`
-	MaxAcceptedBytes   int           `yaml:"maxAcceptedBytes" default:"1e6"`
-	ReadMaxAttempts    int           `yaml:"readMaxAttempts" default:"3"`
-	MaxReadWait        time.Duration `yaml:"maxPollWait" default:"10s"`
-	ReadBackoffMin     time.Duration `yaml:"readBackoffMin" default:"100ms"`
-	ReadBackoffMax     time.Duration `yaml:"readBackoffMax" default:"1s"`
+	Brokers []string `yaml:"brokers,omitempty" validate:"required"`
+	// Topic is a regular expression, all the topics matched are subscribed
+	Topic string `yaml:"topic,omitempty"`
+	// Topics are subscribed by name
+	Topics []string `yaml:"topics,omitempty"`
+	// TopicRefreshInterval is how often the topics matching Topic are listed, the subscription is updated when they changed
+	TopicRefreshInterval time.Duration `yaml:"topicRefreshInterval,omitempty" default:"1m"`
+	GroupId              string        `yaml:"groupId,omitempty" default:"loggie"`
+	QueueCapacity        int           `yaml:"queueCapacity" default:"100"`
+	MinAcceptedBytes     int           `yaml:"minAcceptedBytes" default:"1"`
+	MaxAcceptedBytes     int           `yaml:"maxAcceptedBytes" default:"1e6"`
+	ReadMaxAttempts      int           `yaml:"readMaxAttempts" default:"3"`
+	MaxReadWait          time.Duration `yaml:"maxPollWait" default:"10s"`
+	ReadBackoffMin       time.Duration `yaml:"readBackoffMin" default:"100ms"`
+	ReadBackoffMax       time.Duration `yaml:"readBackoffMax" default:"1s"`
+	// EnableAutoCommit commits the offsets once messages are fetched, set it false to commit offsets after the sink acked
 	EnableAutoCommit   bool          `yaml:"enableAutoCommit" default:"true"`
 	AutoCommitInterval time.Duration `yaml:"autoCommitInterval" default:"1s"`
 	AutoOffsetReset    string        `yaml:"autoOffsetReset" default:"latest" validate:"oneof=earliest latest"`
 }
 
+func (c *Config) Validate() error {
+	if c.Topic == "" && len(c.Topics) == 0 {
+		return fmt.Errorf("kafka source requires topic or topics")
+	}
+	if c.Topic != "" {
+		if _, err := regexp.Compile(c.Topic); err != nil {
+			return fmt.Errorf("kafka source topic %s is not a valid regex: %v", c.Topic, err)
+		}
+	}
+	if c.TopicRefreshInterval <= 0 {
+		return fmt.Errorf("kafka source topicRefreshInterval must be positive")
+	}
+	return nil
+}
+
 func getAutoOffset(autoOffsetReset string) int64 {
 	switch autoOffsetReset {
 	case earliestOffsetReset:
@@ -20,6 +20,8 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -33,7 +35,13 @@ import (
 	"github.com/loggie-io/loggie/pkg/pipeline"
 )
 
-const Type = "kafka"
+const (
+	Type = "kafka"
+
+	ackKey = event.PrivateKeyPrefix + "KafkaMessage"
+
+	retryInterval = time.Second
+)
 
 func init() {
 	pipeline.Register(api.SOURCE, Type, makeSource)
@@ -44,6 +52,7 @@ func makeSource(info pipeline.Info) api.Component {
 		done:      make(chan struct{}),
 		config:    &Config{},
 		eventPool: info.EventPool,
+		tracker:   newOffsetTracker(),
 	}
 }
 
@@ -52,8 +61,15 @@ type Source struct {
 	done      chan struct{}
 	closeOnce sync.Once
 	config    *Config
-	consumer  *kafka.Reader
 	eventPool *event.Pool
+
+	topicRegex *regexp.Regexp
+	tracker    *offsetTracker
+
+	lock     sync.RWMutex
+	consumer *kafka.Reader
+	// topics subscribed by the consumer, sorted
+	topics []string
 }
 
 func (k *Source) Config() interface{} {
@@ -77,28 +93,75 @@ func (k *Source) Init(context api.Context) {
 }
 
 func (k *Source) Start() {
-	topicRegx, err := regexp.Compile(k.config.Topic)
-	if err != nil {
-		log.Error("compile kafka topic regex %s error: %s", k.config.Topic, err.Error())
-		return
+	if k.config.Topic != "" {
+		topicRegex, err := regexp.Compile(k.config.Topic)
+		if err != nil {
+			log.Error("compile kafka topic regex %s error: %s", k.config.Topic, err.Error())
+			return
+		}
+		k.topicRegex = topicRegex
 	}
 
-	client := &kafka.Client{
-		Addr: kafka.TCP(k.config.Brokers...),
+	if err := k.refreshTopics(); err != nil {
+		log.Error("%s subscribe topics error: %+v", k.String(), err)
 	}
-	kts, err := topics.ListRe(context.Background(), client, topicRegx)
-	if err != nil {
-		log.Error("list kafka topics that match a regex error: %s", err.Error())
-		return
+	if k.topicRegex != nil {
+		go k.refreshLoop()
+	}
+}
+
+func (k *Source) refreshLoop() {
+	ticker := time.NewTicker(k.config.TopicRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-k.done:
+			return
+
+		case <-ticker.C:
+			if err := k.refreshTopics(); err != nil {
+				log.Warn("%s refresh topics error: %+v", k.String(), err)
+			}
+		}
 	}
+}
 
-	var groupTopics []string
-	for _, t := range kts {
-		groupTopics = append(groupTopics, t.Name)
+// refreshTopics lists the topics matching the regex, and replaces the consumer when the topics changed
+func (k *Source) refreshTopics() error {
+	topicSet := make(map[string]struct{})
+	for _, t := range k.config.Topics {
+		topicSet[t] = struct{}{}
 	}
-	if len(groupTopics) <= 0 {
-		log.Error("regex %s matched zero kafka topics", k.config.Topic)
-		return
+
+	if k.topicRegex != nil {
+		client := &kafka.Client{
+			Addr: kafka.TCP(k.config.Brokers...),
+		}
+		kts, err := topics.ListRe(context.Background(), client, k.topicRegex)
+		if err != nil {
+			return errors.WithMessage(err, "list kafka topics that match a regex failed")
+		}
+		for _, t := range kts {
+			topicSet[t.Name] = struct{}{}
+		}
+	}
+
+	groupTopics := make([]string, 0, len(topicSet))
+	for t := range topicSet {
+		groupTopics = append(groupTopics, t)
+	}
+	sort.Strings(groupTopics)
+
+	k.lock.RLock()
+	changed := strings.Join(groupTopics, ",") != strings.Join(k.topics, ",")
+	k.lock.RUnlock()
+	if !changed {
+		return nil
+	}
+	if len(groupTopics) == 0 {
+		log.Warn("regex %s matched zero kafka topics", k.config.Topic)
+		return nil
 	}
 
 	readerCfg := kafka.ReaderConfig{
@@ -116,22 +179,51 @@ func (k *Source) Start() {
 		StartOffset:    getAutoOffset(k.config.AutoOffsetReset),
 	}
 
+	k.lock.Lock()
+	select {
+	case <-k.done:
+		k.lock.Unlock()
+		return nil
+	default:
+	}
+	old := k.consumer
 	k.consumer = kafka.NewReader(readerCfg)
+	k.topics = groupTopics
+	k.lock.Unlock()
+
+	if old != nil {
+		// the uncommitted messages would be redelivered by the new consumer
+		k.tracker.reset()
+		if err := old.Close(); err != nil {
+			log.Warn("close kafka consumer error: %+v", err)
+		}
+	}
+	log.Info("%s subscribe topics: %v", k.String(), groupTopics)
+	return nil
 }
 
 func (k *Source) Stop() {
 	k.closeOnce.Do(func() {
-		if k.consumer != nil {
-			err := k.consumer.Close()
+		k.lock.Lock()
+		close(k.done)
+		consumer := k.consumer
+		k.lock.Unlock()
+
+		if consumer != nil {
+			err := consumer.Close()
 			if err != nil {
-				log.Panic("close kafka consumer error: %+v", err)
+				log.Error("close kafka consumer error: %+v", err)
 			}
 		}
-
-		close(k.done)
 	})
 }
 
+func (k *Source) getConsumer() *kafka.Reader {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	return k.consumer
+}
+
 func (k *Source) ProductLoop(productFunc api.ProductFunc) {
 	log.Info("%s start product loop", k.String())
 
@@ -142,30 +234,44 @@ func (k *Source) ProductLoop(productFunc api.ProductFunc) {
 
 		default:
 			err := k.consume(productFunc)
-			if err != nil {
-				log.Error("%+v", err)
+			if err == nil {
+				continue
+			}
+
+			log.Error("%+v", err)
+			select {
+			case <-k.done:
+				return
+			case <-time.After(retryInterval):
 			}
 		}
 	}
 }
 
 func (k *Source) consume(productFunc api.ProductFunc) error {
-	if k.consumer == nil {
+	consumer := k.getConsumer()
+	if consumer == nil {
 		return fmt.Errorf("kakfa consumer not initialized yet")
 	}
 
 	ctx := context.Background()
-	msg, err := k.consumer.FetchMessage(ctx)
+	msg, err := consumer.FetchMessage(ctx)
 	if err != nil {
+		if consumer != k.getConsumer() {
+			// the consumer was replaced after topics changed
+			return nil
+		}
 		return errors.Errorf("consumer read message error: %v", err)
 	}
 
-	// auto commit message, commit before sink ack
 	if k.config.EnableAutoCommit {
-		err := k.consumer.CommitMessages(ctx, msg)
+		// auto commit message, commit before sink ack
+		err := consumer.CommitMessages(ctx, msg)
 		if err != nil {
 			return errors.Errorf("consumer auto commit message error: %v", err)
 		}
+	} else {
+		k.tracker.fetched(msg)
 	}
 
 	e := k.eventPool.Get()
@@ -173,57 +279,61 @@ func (k *Source) consume(productFunc api.ProductFunc) error {
 	if header == nil {
 		header = make(map[string]interface{})
 	}
+
+	msgHeaders := make(map[string]interface{}, len(msg.Headers))
+	for _, h := range msg.Headers {
+		header[h.Key] = string(h.Value)
+		msgHeaders[h.Key] = string(h.Value)
+	}
 	header["kafka"] = map[string]interface{}{
 		"offset":    msg.Offset,
 		"partition": msg.Partition,
 		"timestamp": msg.Time.Format(time.RFC3339),
 		"topic":     msg.Topic,
-	}
-
-	for _, h := range msg.Headers {
-		header[h.Key] = string(h.Value)
+		"key":       string(msg.Key),
+		"headers":   msgHeaders,
 	}
 	header["@timestamp"] = time.Now().Format(time.RFC3339)
-	e.Fill(e.Meta(), header, msg.Value)
+
+	meta := e.Meta()
+	meta.Set(ackKey, kafka.Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+	})
+	e.Fill(meta, header, msg.Value)
 	productFunc(e)
 	return nil
 }
 
+// Commit commits the offsets when sink acked, an offset is committed only after all the messages before it
+// in the same partition are acked, so that no message would be lost when loggie crashed.
 func (k *Source) Commit(events []api.Event) {
-	// commit when sink ack
-	if !k.config.EnableAutoCommit {
-		var msgs []kafka.Message
-		for _, e := range events {
-			h := e.Header()
-			if _, exist := h["kafka"]; !exist {
-				continue
-			}
-
-			k, ok := h["kafka"].(map[string]interface{})
-			if !ok {
-				continue
-			}
-			if _, exist := k["topic"]; !exist {
-				continue
-			}
-			if _, exist := k["partition"]; !exist {
-				continue
-			}
-			if _, exist := k["offset"]; !exist {
-				continue
-			}
+	defer k.eventPool.PutAll(events)
+	if k.config.EnableAutoCommit {
+		return
+	}
 
-			msgs = append(msgs, kafka.Message{
-				Topic:     k["topic"].(string),
-				Partition: k["partition"].(int),
-				Offset:    k["offset"].(int64),
-			})
+	var msgs []kafka.Message
+	for _, e := range events {
+		v, ok := e.Meta().Get(ackKey)
+		if !ok {
+			continue
 		}
-		if len(msgs) > 0 {
-			err := k.consumer.CommitMessages(context.Background(), msgs...)
-			log.Error("consumer manually commit messgage error: %v", err)
+		if msg, ok := v.(kafka.Message); ok {
+			msgs = append(msgs, msg)
 		}
 	}
 
-	k.eventPool.PutAll(events)
+	commits := k.tracker.ack(msgs)
+	if len(commits) == 0 {
+		return
+	}
+	consumer := k.getConsumer()
+	if consumer == nil {
+		return
+	}
+	if err := consumer.CommitMessages(context.Background(), commits...); err != nil {
+		log.Error("consumer manually commit message error: %v", err)
+	}
 }
@@ -0,0 +1,105 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type partition struct {
+	topic     string
+	partition int
+}
+
+// offsetTracker makes sure the offset committed is not beyond any message still in flight,
+// since the events of one partition could be acked out of order by parallel sink consumers.
+type offsetTracker struct {
+	lock sync.Mutex
+	// offsets fetched but not committed yet in fetched order, with whether they are acked
+	pending map[partition][]*pendingOffset
+}
+
+type pendingOffset struct {
+	offset int64
+	acked  bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		pending: make(map[partition][]*pendingOffset),
+	}
+}
+
+func (t *offsetTracker) fetched(msg kafka.Message) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	p := partition{topic: msg.Topic, partition: msg.Partition}
+	t.pending[p] = append(t.pending[p], &pendingOffset{offset: msg.Offset})
+}
+
+// ack marks the messages acked, and returns the latest message of each partition which could be committed
+func (t *offsetTracker) ack(msgs []kafka.Message) []kafka.Message {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	touched := make(map[partition]struct{})
+	for _, msg := range msgs {
+		p := partition{topic: msg.Topic, partition: msg.Partition}
+		for _, po := range t.pending[p] {
+			if po.offset == msg.Offset && !po.acked {
+				po.acked = true
+				touched[p] = struct{}{}
+				break
+			}
+		}
+	}
+
+	var commits []kafka.Message
+	for p := range touched {
+		offsets := t.pending[p]
+		i := 0
+		for i < len(offsets) && offsets[i].acked {
+			i++
+		}
+		if i == 0 {
+			continue
+		}
+
+		commits = append(commits, kafka.Message{
+			Topic:     p.topic,
+			Partition: p.partition,
+			Offset:    offsets[i-1].offset,
+		})
+		if i == len(offsets) {
+			delete(t.pending, p)
+		} else {
+			t.pending[p] = offsets[i:]
+		}
+	}
+	return commits
+}
+
+// reset drops all the pending offsets, which happens when the reader is replaced and the messages would be redelivered
+func (t *offsetTracker) reset() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.pending = make(map[partition][]*pendingOffset)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kafka
+
+import (
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func msg(topic string, p int, offset int64) kafka.Message {
+	return kafka.Message{Topic: topic, Partition: p, Offset: offset}
+}
+
+func TestOffsetTracker_AckOutOfOrder(t *testing.T) {
+	tracker := newOffsetTracker()
+	for i := int64(0); i < 4; i++ {
+		tracker.fetched(msg("a", 0, i))
+	}
+	tracker.fetched(msg("b", 1, 10))
+
+	// offset 0 is still in flight, nothing could be committed
+	if commits := tracker.ack([]kafka.Message{msg("a", 0, 1), msg("a", 0, 2)}); len(commits) != 0 {
+		t.Fatalf("expect no commit, got %v", commits)
+	}
+
+	commits := tracker.ack([]kafka.Message{msg("a", 0, 0), msg("b", 1, 10)})
+	if len(commits) != 2 {
+		t.Fatalf("expect 2 commits, got %v", commits)
+	}
+	for _, c := range commits {
+		if c.Topic == "a" && c.Offset != 2 {
+			t.Errorf("expect topic a committed to offset 2, got %d", c.Offset)
+		}
+		if c.Topic == "b" && c.Offset != 10 {
+			t.Errorf("expect topic b committed to offset 10, got %d", c.Offset)
+		}
+	}
+
+	commits = tracker.ack([]kafka.Message{msg("a", 0, 3)})
+	if len(commits) != 1 || commits[0].Offset != 3 {
+		t.Fatalf("expect commit offset 3, got %v", commits)
+	}
+	if len(tracker.pending) != 0 {
+		t.Errorf("expect no pending offsets, got %v", tracker.pending)
+	}
+}
+
+func TestOffsetTracker_AckAfterReset(t *testing.T) {
+	tracker := newOffsetTracker()
+	tracker.fetched(msg("a", 0, 5))
+	tracker.reset()
+
+	if commits := tracker.ack([]kafka.Message{msg("a", 0, 5)}); len(commits) != 0 {
+		t.Fatalf("expect no commit for messages fetched before reset, got %v", commits)
+	}
+}
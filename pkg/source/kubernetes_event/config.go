@@ -16,8 +16,38 @@ limitations under the License.
 
 package kubernetes_event
 
+import "time"
+
 type Config struct {
 	KubeConfig string `yaml:"kubeconfig,omitempty"`
 	Master     string `yaml:"master,omitempty"`
 	BufferSize int    `yaml:"bufferSize,omitempty" default:"1000" validate:"gte=1"`
+
+	// Namespaces to watch, all namespaces are watched when empty
+	Namespaces []string `yaml:"namespaces,omitempty"`
+	// FieldSelector is passed to the apiserver, eg: involvedObject.kind=Pod
+	FieldSelector string `yaml:"fieldSelector,omitempty"`
+	Filter        Filter `yaml:"filter,omitempty"`
+
+	// FieldsKey is the header key which the event fields are put under
+	FieldsKey string `yaml:"fieldsKey,omitempty" default:"kubeEvent"`
+	// Fields maps the header field name to the flattened event field, eg: kind: involvedObject.kind,
+	// all the event fields are added when empty
+	Fields map[string]string `yaml:"fields,omitempty"`
+
+	// ResourceVersionDir is where the last committed resourceVersion is persisted, so the events
+	// already sent would not be replayed after restart
+	ResourceVersionDir   string        `yaml:"resourceVersionDir,omitempty" default:"./data"`
+	ResourceVersionFlush time.Duration `yaml:"resourceVersionFlush,omitempty" default:"5s"`
+
+	// BodyFormat is json to send the whole event as body, or message to send the message of event only
+	BodyFormat string `yaml:"bodyFormat,omitempty" default:"json" validate:"oneof=json message"`
+}
+
+// Filter drops the events not matched, empty means no filtering
+type Filter struct {
+	Reasons             []string `yaml:"reasons,omitempty"`
+	Types               []string `yaml:"types,omitempty"`
+	InvolvedObjectKinds []string `yaml:"involvedObjectKinds,omitempty"`
+	InvolvedObjectNames []string `yaml:"involvedObjectNames,omitempty"`
 }
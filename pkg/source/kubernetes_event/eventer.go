@@ -19,17 +19,31 @@ package kubernetes_event
 import (
 	"encoding/json"
 	"fmt"
-	"github.com/loggie-io/loggie/pkg/core/api"
-	"github.com/loggie-io/loggie/pkg/core/event"
-	"github.com/loggie-io/loggie/pkg/core/log"
-	"github.com/loggie-io/loggie/pkg/pipeline"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
 )
 
-const Type = "kubeEvent"
+const (
+	Type = "kubeEvent"
+
+	resourceVersionKey = event.PrivateKeyPrefix + "KubeEventResourceVersion"
+	informerKey        = event.PrivateKeyPrefix + "KubeEventInformer"
+
+	BodyFormatJson    = "json"
+	BodyFormatMessage = "message"
+)
 
 func init() {
 	pipeline.Register(api.SOURCE, Type, makeSource)
@@ -37,18 +51,32 @@ func init() {
 
 func makeSource(info pipeline.Info) api.Component {
 	return &KubeEvent{
-		config:    &Config{},
-		stop:      make(chan struct{}),
-		eventPool: info.EventPool,
+		config:       &Config{},
+		stop:         make(chan struct{}),
+		eventPool:    info.EventPool,
+		pipelineName: info.PipelineName,
+		deduper:      newDeduper(),
 	}
 }
 
 type KubeEvent struct {
-	name      string
-	config    *Config
-	event     chan interface{}
-	stop      chan struct{}
-	eventPool *event.Pool
+	name         string
+	pipelineName string
+	config       *Config
+	event        chan kubeEvent
+	stop         chan struct{}
+	stopOnce     sync.Once
+	eventPool    *event.Pool
+
+	deduper *deduper
+	// versions are the resourceVersion stores of informers, key: namespace of informer
+	versions map[string]*resourceVersionStore
+}
+
+// kubeEvent is the event received by the informer of namespace
+type kubeEvent struct {
+	obj       *corev1.Event
+	namespace string
 }
 
 func (k *KubeEvent) Config() interface{} {
@@ -69,10 +97,29 @@ func (k *KubeEvent) String() string {
 
 func (k *KubeEvent) Init(context api.Context) {
 	k.name = context.Name()
-	k.event = make(chan interface{}, k.config.BufferSize)
+	k.event = make(chan kubeEvent, k.config.BufferSize)
+
+	namespaces := k.config.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	k.versions = make(map[string]*resourceVersionStore, len(namespaces))
+	for _, ns := range namespaces {
+		name := fmt.Sprintf("kube-event-%s-%s", k.pipelineName, k.name)
+		if ns != metav1.NamespaceAll {
+			name = fmt.Sprintf("%s-%s", name, ns)
+		}
+		k.versions[ns] = newResourceVersionStore(k.config.ResourceVersionDir, name)
+	}
 }
 
 func (k *KubeEvent) Start() {
+	for _, versions := range k.versions {
+		if err := versions.load(); err != nil {
+			log.Warn("load kubernetes event resourceVersion error: %+v", err)
+		}
+	}
+
 	config, err := clientcmd.BuildConfigFromFlags(k.config.Master, k.config.KubeConfig)
 	if err != nil {
 		log.Error("cannot build config: %v", err)
@@ -84,46 +131,152 @@ func (k *KubeEvent) Start() {
 		return
 	}
 
-	informerFactory := informers.NewSharedInformerFactory(clientset, 0)
-	eventInformer := informerFactory.Core().V1().Events()
-	eventInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: func(obj interface{}) {
-			k.event <- obj
-		},
-		UpdateFunc: func(oldObj, newObj interface{}) {
-			k.event <- newObj
-		},
-		DeleteFunc: func(obj interface{}) {
+	for ns := range k.versions {
+		namespace := ns
+		informerFactory := informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+			informers.WithNamespace(ns),
+			informers.WithTweakListOptions(func(options *metav1.ListOptions) {
+				options.FieldSelector = k.config.FieldSelector
+			}))
+		eventInformer := informerFactory.Core().V1().Events()
+		eventInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				k.enqueue(namespace, obj)
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				k.enqueue(namespace, newObj)
+			},
+			DeleteFunc: func(obj interface{}) {
+				if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+					obj = tombstone.Obj
+				}
+				if e, ok := obj.(*corev1.Event); ok {
+					k.deduper.forget(e.UID)
+				}
+			},
+		})
 
-		},
-	})
+		informerFactory.Start(k.stop)
+		informerFactory.WaitForCacheSync(k.stop)
+		k.versions[ns].synced()
+	}
 
-	informerFactory.Start(k.stop)
-	informerFactory.WaitForCacheSync(k.stop)
+	go k.flushLoop()
+}
+
+// enqueue filters the events, and drops the ones already sent
+func (k *KubeEvent) enqueue(namespace string, obj interface{}) {
+	e, ok := obj.(*corev1.Event)
+	if !ok {
+		return
+	}
+	if !k.config.Filter.match(e) {
+		return
+	}
+	if k.deduper.seen(e) {
+		return
+	}
+	versions := k.versions[namespace]
+	if versions.sent(e.ResourceVersion) {
+		return
+	}
+
+	// the event holds the watermark down from now on, even if it is still waiting in the channel
+	versions.track(e.ResourceVersion)
+	select {
+	case k.event <- kubeEvent{obj: e, namespace: namespace}:
+	case <-k.stop:
+	}
+}
+
+func (k *KubeEvent) flushLoop() {
+	ticker := time.NewTicker(k.config.ResourceVersionFlush)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-k.stop:
+			k.flush()
+			return
+
+		case <-ticker.C:
+			k.flush()
+		}
+	}
+}
+
+func (k *KubeEvent) flush() {
+	for _, versions := range k.versions {
+		if err := versions.flush(); err != nil {
+			log.Warn("%+v", err)
+		}
+	}
 }
 
 func (k *KubeEvent) Stop() {
-	close(k.stop)
+	k.stopOnce.Do(func() {
+		close(k.stop)
+	})
 }
 
 func (k *KubeEvent) ProductLoop(productFunc api.ProductFunc) {
 	log.Info("%s start product loop", k.String())
 
-	for obj := range k.event {
-		jsonBytes, err := json.Marshal(obj)
-		if err != nil {
-			log.Warn("json parse error: %s", err.Error())
+	for {
+		select {
+		case <-k.stop:
 			return
-		}
 
-		e := k.eventPool.Get()
-		e.Fill(e.Meta(), e.Header(), jsonBytes)
+		case ke := <-k.event:
+			obj := ke.obj
+			body := []byte(obj.Message)
+			if k.config.BodyFormat == BodyFormatJson {
+				jsonBytes, err := json.Marshal(obj)
+				if err != nil {
+					log.Warn("json parse error: %s", err.Error())
+					// the event dropped should not hold the watermark
+					k.versions[ke.namespace].commit([]string{obj.ResourceVersion})
+					continue
+				}
+				body = jsonBytes
+			}
 
-		productFunc(e)
-	}
+			e := k.eventPool.Get()
+			header := e.Header()
+			if header == nil {
+				header = make(map[string]interface{})
+			}
+			header[k.config.FieldsKey] = eventFields(obj, k.config.Fields)
+
+			meta := e.Meta()
+			meta.Set(resourceVersionKey, obj.ResourceVersion)
+			meta.Set(informerKey, ke.namespace)
+			e.Fill(meta, header, body)
 
+			productFunc(e)
+		}
+	}
 }
 
 func (k *KubeEvent) Commit(events []api.Event) {
+	committed := make(map[string][]string)
+	for _, e := range events {
+		v, ok := e.Meta().Get(resourceVersionKey)
+		if !ok {
+			continue
+		}
+		ns, _ := e.Meta().Get(informerKey)
+		rv, ok := v.(string)
+		namespace, nsOk := ns.(string)
+		if !ok || !nsOk {
+			continue
+		}
+		committed[namespace] = append(committed[namespace], rv)
+	}
+	for ns, rvs := range committed {
+		if versions, ok := k.versions[ns]; ok {
+			versions.commit(rvs)
+		}
+	}
 	k.eventPool.PutAll(events)
 }
@@ -0,0 +1,154 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes_event
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) match(e *corev1.Event) bool {
+	if len(f.Reasons) > 0 && !contains(f.Reasons, e.Reason) {
+		return false
+	}
+	if len(f.Types) > 0 && !contains(f.Types, e.Type) {
+		return false
+	}
+	if len(f.InvolvedObjectKinds) > 0 && !contains(f.InvolvedObjectKinds, e.InvolvedObject.Kind) {
+		return false
+	}
+	if len(f.InvolvedObjectNames) > 0 && !contains(f.InvolvedObjectNames, e.InvolvedObject.Name) {
+		return false
+	}
+	return true
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// eventCount is the times the event occurred, newer reporters record it in the series instead
+func eventCount(e *corev1.Event) int32 {
+	if e.Series != nil && e.Series.Count > e.Count {
+		return e.Series.Count
+	}
+	return e.Count
+}
+
+// flatten converts the event into a map with dot joined keys
+func flatten(e *corev1.Event) map[string]interface{} {
+	return map[string]interface{}{
+		"namespace":                 e.Namespace,
+		"name":                      e.Name,
+		"uid":                       string(e.UID),
+		"resourceVersion":           e.ResourceVersion,
+		"type":                      e.Type,
+		"reason":                    e.Reason,
+		"message":                   e.Message,
+		"count":                     eventCount(e),
+		"firstTimestamp":            formatTime(e.FirstTimestamp.Time),
+		"lastTimestamp":             formatTime(e.LastTimestamp.Time),
+		"eventTime":                 formatTime(e.EventTime.Time),
+		"reportingComponent":        e.ReportingController,
+		"reportingInstance":         e.ReportingInstance,
+		"source.component":          e.Source.Component,
+		"source.host":               e.Source.Host,
+		"involvedObject.kind":       e.InvolvedObject.Kind,
+		"involvedObject.namespace":  e.InvolvedObject.Namespace,
+		"involvedObject.name":       e.InvolvedObject.Name,
+		"involvedObject.uid":        string(e.InvolvedObject.UID),
+		"involvedObject.apiVersion": e.InvolvedObject.APIVersion,
+		"involvedObject.fieldPath":  e.InvolvedObject.FieldPath,
+	}
+}
+
+// eventFields returns the header fields of the event, the mapped fields when mapping configured,
+// otherwise all the flattened fields nested by the dots.
+func eventFields(e *corev1.Event, mapping map[string]string) map[string]interface{} {
+	flat := flatten(e)
+	fields := make(map[string]interface{})
+	if len(mapping) > 0 {
+		for name, path := range mapping {
+			if v, ok := flat[path]; ok {
+				fields[name] = v
+			}
+		}
+		return fields
+	}
+
+	for k, v := range flat {
+		parts := strings.SplitN(k, ".", 2)
+		if len(parts) == 1 {
+			fields[k] = v
+			continue
+		}
+		sub, ok := fields[parts[0]].(map[string]interface{})
+		if !ok {
+			sub = make(map[string]interface{})
+			fields[parts[0]] = sub
+		}
+		sub[parts[1]] = v
+	}
+	return fields
+}
+
+// deduper drops the updates which does not increase the count of the event, eg: the resync or
+// the apiserver replaying the same event after a relist.
+type deduper struct {
+	lock   sync.Mutex
+	counts map[types.UID]int32
+}
+
+func newDeduper() *deduper {
+	return &deduper{
+		counts: make(map[types.UID]int32),
+	}
+}
+
+func (d *deduper) seen(e *corev1.Event) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	count := eventCount(e)
+	if last, ok := d.counts[e.UID]; ok && count <= last {
+		return true
+	}
+	d.counts[e.UID] = count
+	return false
+}
+
+func (d *deduper) forget(uid types.UID) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.counts, uid)
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes_event
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	ctx "github.com/loggie-io/loggie/pkg/core/context"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+func newTestEvent(uid string, count int32, resourceVersion string) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "default",
+			Name:            "nginx.16a",
+			UID:             types.UID(uid),
+			ResourceVersion: resourceVersion,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			Kind: "Pod",
+			Name: "nginx",
+		},
+		Type:    corev1.EventTypeWarning,
+		Reason:  "BackOff",
+		Message: "Back-off restarting failed container",
+		Count:   count,
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	e := newTestEvent("a", 1, "1")
+	filters := []struct {
+		filter Filter
+		match  bool
+	}{
+		{Filter{}, true},
+		{Filter{Types: []string{corev1.EventTypeWarning}, InvolvedObjectKinds: []string{"Pod"}}, true},
+		{Filter{Reasons: []string{"Pulled"}}, false},
+		{Filter{InvolvedObjectNames: []string{"redis"}}, false},
+	}
+	for i, f := range filters {
+		if f.filter.match(e) != f.match {
+			t.Errorf("case %d: expect match %v", i, f.match)
+		}
+	}
+}
+
+func TestEventFields(t *testing.T) {
+	e := newTestEvent("a", 3, "1")
+
+	fields := eventFields(e, nil)
+	involved, ok := fields["involvedObject"].(map[string]interface{})
+	if !ok || involved["kind"] != "Pod" || fields["reason"] != "BackOff" || fields["count"] != int32(3) {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+
+	fields = eventFields(e, map[string]string{"kind": "involvedObject.kind", "missing": "foo"})
+	if len(fields) != 1 || fields["kind"] != "Pod" {
+		t.Fatalf("unexpected mapped fields %v", fields)
+	}
+}
+
+func TestDeduper(t *testing.T) {
+	d := newDeduper()
+	if d.seen(newTestEvent("a", 1, "1")) {
+		t.Fatalf("first event should not be seen")
+	}
+	if !d.seen(newTestEvent("a", 1, "1")) {
+		t.Fatalf("resync of the same event should be seen")
+	}
+	if d.seen(newTestEvent("a", 2, "2")) {
+		t.Fatalf("event with increased count should not be seen")
+	}
+	d.forget("a")
+	if d.seen(newTestEvent("a", 2, "2")) {
+		t.Fatalf("event forgotten should not be seen")
+	}
+}
+
+func TestResourceVersionStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kube-event")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := newResourceVersionStore(dir, "rv")
+	if err := s.load(); err != nil {
+		t.Fatalf("load without file error: %v", err)
+	}
+	s.synced()
+	for _, rv := range []string{"12", "15", "18", "20"} {
+		s.track(rv)
+	}
+	// acked out of order, 18 is pending yet
+	s.commit([]string{"15", "12", "not-a-number"})
+	s.commit([]string{"20"})
+	if err := s.flush(); err != nil {
+		t.Fatalf("flush error: %v", err)
+	}
+
+	restarted := newResourceVersionStore(dir, "rv")
+	if err := restarted.load(); err != nil {
+		t.Fatalf("load error: %v", err)
+	}
+	if !restarted.sent("15") || !restarted.sent("17") || restarted.sent("18") || restarted.sent("20") || restarted.sent("abc") {
+		t.Fatalf("unexpected sent result after restart, version: %d", restarted.version)
+	}
+
+	// the watermark reaches the largest one committed when nothing is pending
+	s.commit([]string{"18"})
+	if s.version != 20 {
+		t.Fatalf("unexpected watermark %d", s.version)
+	}
+	// the watermark raised in this run is not used to skip events, only the one loaded
+	if s.sent("19") {
+		t.Fatalf("event newer than the loaded watermark is treated as sent")
+	}
+}
+
+func TestKubeEvent_OutOfOrderResourceVersions(t *testing.T) {
+	log.InitDefaultLogger()
+	dir, err := ioutil.TempDir("", "kube-event")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	k := makeSource(pipeline.Info{EventPool: event.NewDefaultPool(10)}).(*KubeEvent)
+	k.config = &Config{BufferSize: 10, FieldsKey: "kubeEvent", ResourceVersionDir: dir, BodyFormat: BodyFormatMessage}
+	k.Init(ctx.NewContext("events", Type, api.SOURCE, nil))
+
+	produced := make(chan api.Event, 10)
+	go k.ProductLoop(func(e api.Event) api.Result {
+		produced <- e
+		return nil
+	})
+	defer k.Stop()
+
+	consume := func() api.Event {
+		select {
+		case e := <-produced:
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("event not produced")
+			return nil
+		}
+	}
+
+	// the initial list is ordered by key, 300 is listed after 500 is produced and acked
+	k.enqueue(metav1.NamespaceAll, newTestEvent("a", 1, "500"))
+	k.Commit([]api.Event{consume()})
+	k.enqueue(metav1.NamespaceAll, newTestEvent("b", 1, "300"))
+	k.enqueue(metav1.NamespaceAll, newTestEvent("c", 1, "400"))
+	e300 := consume()
+	if v, _ := e300.Meta().Get(resourceVersionKey); v != "300" {
+		t.Fatalf("expect event of resourceVersion 300 produced, got %v", v)
+	}
+	k.Commit([]api.Event{e300})
+	k.versions[metav1.NamespaceAll].synced()
+	k.flush()
+
+	// 400 is not acked, so it is listed again after restart
+	restarted := newResourceVersionStore(dir, fmt.Sprintf("kube-event--%s", "events"))
+	if err := restarted.load(); err != nil {
+		t.Fatal(err)
+	}
+	if !restarted.sent("300") || restarted.sent("400") {
+		t.Fatalf("unexpected watermark %d after restart", restarted.version)
+	}
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes_event
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// resourceVersionStore persists the low watermark of resourceVersion for one informer, all the events not newer than
+// it are committed, so they are skipped when the informer lists all the events after restart. resourceVersions are
+// only ordered in the same watch, so each informer has its own store.
+type resourceVersionStore struct {
+	file string
+
+	lock sync.Mutex
+	// loaded is the watermark persisted by the last run, the events listed are not ordered by resourceVersion,
+	// so only the ones not newer than it are skipped, instead of comparing with the watermark raised in this run
+	loaded  uint64
+	version uint64
+	dirty   bool
+	// listed is true when the initial list of the informer is synced, the watermark is not raised before that,
+	// because the events listed later may be older than the ones committed
+	listed bool
+	// committed is the largest resourceVersion committed
+	committed uint64
+	// pending are the resourceVersions produced but not committed yet, value: count of events
+	pending map[uint64]int
+}
+
+func newResourceVersionStore(dir string, name string) *resourceVersionStore {
+	return &resourceVersionStore{
+		file:    filepath.Join(dir, name),
+		pending: make(map[uint64]int),
+	}
+}
+
+func (s *resourceVersionStore) load() error {
+	content, err := ioutil.ReadFile(s.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	version, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+	if err != nil {
+		return errors.WithMessagef(err, "parse resourceVersion in %s failed", s.file)
+	}
+	s.lock.Lock()
+	s.loaded = version
+	s.version = version
+	s.committed = version
+	s.lock.Unlock()
+	return nil
+}
+
+// sent returns true when the event with the resourceVersion is not newer than the watermark loaded,
+// resourceVersion is opaque in theory, so an unparsable one is never treated as sent
+func (s *resourceVersionStore) sent(resourceVersion string) bool {
+	v, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		return false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return v <= s.loaded
+}
+
+// track holds the watermark below the resourceVersion until it is committed
+func (s *resourceVersionStore) track(resourceVersion string) {
+	v, err := strconv.ParseUint(resourceVersion, 10, 64)
+	if err != nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pending[v]++
+}
+
+// commit raises the watermark to the largest resourceVersion committed, but not beyond the events still pending,
+// so the events acked out of order are not lost after a crash
+func (s *resourceVersionStore) commit(resourceVersions []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, rv := range resourceVersions {
+		v, err := strconv.ParseUint(rv, 10, 64)
+		if err != nil {
+			continue
+		}
+		if n, ok := s.pending[v]; ok {
+			if n <= 1 {
+				delete(s.pending, v)
+			} else {
+				s.pending[v] = n - 1
+			}
+		}
+		if v > s.committed {
+			s.committed = v
+		}
+	}
+	s.raise()
+}
+
+// synced is called after the initial list of the informer is synced, and raises the watermark held until now
+func (s *resourceVersionStore) synced() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.listed = true
+	s.raise()
+}
+
+func (s *resourceVersionStore) raise() {
+	if !s.listed {
+		return
+	}
+	watermark := s.committed
+	for v := range s.pending {
+		if v <= watermark {
+			watermark = v - 1
+		}
+	}
+	if watermark > s.version {
+		s.version = watermark
+		s.dirty = true
+	}
+}
+
+func (s *resourceVersionStore) flush() error {
+	s.lock.Lock()
+	if !s.dirty {
+		s.lock.Unlock()
+		return nil
+	}
+	content := strconv.FormatUint(s.version, 10)
+	s.dirty = false
+	s.lock.Unlock()
+
+	if err := s.write(content); err != nil {
+		s.lock.Lock()
+		s.dirty = true
+		s.lock.Unlock()
+		return errors.WithMessagef(err, "write resourceVersion to %s failed", s.file)
+	}
+	return nil
+}
+
+func (s *resourceVersionStore) write(content string) error {
+	if err := os.MkdirAll(filepath.Dir(s.file), os.ModePerm); err != nil {
+		return err
+	}
+	tmp := s.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(content), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.file)
+}
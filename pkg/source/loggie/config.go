@@ -0,0 +1,27 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loggie
+
+type Config struct {
+	// Level is the lowest level of the log records received
+	Level string `yaml:"level,omitempty" default:"info" validate:"oneof=debug info warn error"`
+	// RateLimit is the max events per second, the exceeded records are dropped. The records of the pipeline's own
+	// components are always dropped, it is a backstop in case the errors are amplified through other components
+	RateLimit int `yaml:"rateLimit,omitempty" default:"100" validate:"gte=1"`
+	// BufferSize is the count of records buffered, records are dropped instead of blocking the logging when full
+	BufferSize int `yaml:"bufferSize,omitempty" default:"1024" validate:"gte=1"`
+}
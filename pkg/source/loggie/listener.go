@@ -0,0 +1,88 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loggie
+
+import (
+	"sync"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/eventbus"
+)
+
+const errorListenerName = "loggieSource"
+
+var errorEvents = &errorListener{
+	sources: make(map[*Loggie]struct{}),
+}
+
+func init() {
+	eventbus.Registry(errorEvents, eventbus.WithTopic(eventbus.ErrorTopic))
+}
+
+// errorListener forwards the error events of eventbus to the running loggie sources. It is registered once, because
+// the listeners of eventbus could not be removed.
+type errorListener struct {
+	lock    sync.RWMutex
+	sources map[*Loggie]struct{}
+}
+
+func (l *errorListener) Init(ctx api.Context) {
+}
+
+func (l *errorListener) Start() {
+}
+
+func (l *errorListener) Stop() {
+}
+
+func (l *errorListener) Name() string {
+	return errorListenerName
+}
+
+func (l *errorListener) Config() interface{} {
+	return &struct{}{}
+}
+
+func (l *errorListener) Subscribe(event eventbus.Event) {
+	data, ok := event.Data.(eventbus.ErrorMetricData)
+	if !ok {
+		return
+	}
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+	for s := range l.sources {
+		s.receive(&record{
+			level:      levelError,
+			message:    data.ErrorMsg,
+			time:       event.PublishTime,
+			errorEvent: true,
+		})
+	}
+}
+
+func (l *errorListener) add(s *Loggie) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.sources[s] = struct{}{}
+}
+
+func (l *errorListener) remove(s *Loggie) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	delete(l.sources, s)
+}
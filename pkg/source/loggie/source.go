@@ -0,0 +1,268 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loggie
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+const (
+	Type = "loggie"
+
+	headerKey  = "loggie"
+	levelError = "error"
+
+	// an error log record is published to eventbus as well, the error event is dropped if the same message
+	// logged within the window
+	errorDedupeWindow = 10 * time.Second
+	componentEventbus = "eventbus"
+)
+
+func init() {
+	pipeline.Register(api.SOURCE, Type, makeSource)
+}
+
+func makeSource(info pipeline.Info) api.Component {
+	return &Loggie{
+		config:       &Config{},
+		eventPool:    info.EventPool,
+		pipelineName: info.PipelineName,
+		done:         make(chan struct{}),
+	}
+}
+
+// Loggie receives the log records and error events of loggie itself
+type Loggie struct {
+	name      string
+	config    *Config
+	eventPool *event.Pool
+	done      chan struct{}
+
+	// the records logged for the pipeline this source belongs to are dropped, otherwise the errors logged while
+	// sending the records would be fed back endlessly. The records of the same components in other pipelines are kept.
+	pipelineName string
+
+	level   zerolog.Level
+	records chan *record
+	dropped int64
+
+	recentErrors map[string]time.Time
+	windowStart  time.Time
+	windowCount  int
+}
+
+type record struct {
+	level      string
+	message    string
+	caller     string
+	pipeline   string
+	time       time.Time
+	raw        []byte
+	errorEvent bool
+}
+
+func (l *Loggie) Config() interface{} {
+	return l.config
+}
+
+func (l *Loggie) Category() api.Category {
+	return api.SOURCE
+}
+
+func (l *Loggie) Type() api.Type {
+	return Type
+}
+
+func (l *Loggie) String() string {
+	return fmt.Sprintf("%s/%s", api.SOURCE, Type)
+}
+
+func (l *Loggie) Init(context api.Context) {
+	l.name = context.Name()
+}
+
+func (l *Loggie) Start() {
+	level, err := zerolog.ParseLevel(l.config.Level)
+	if err != nil {
+		log.Warn("%s parse level %s failed, use info instead", l.String(), l.config.Level)
+		level = zerolog.InfoLevel
+	}
+	l.level = level
+	l.records = make(chan *record, l.config.BufferSize)
+	l.recentErrors = make(map[string]time.Time)
+	log.Info("%s start, level: %s", l.String(), l.config.Level)
+}
+
+func (l *Loggie) Stop() {
+	// the tap is removed before logging, or the record would be sent to itself
+	log.RemoveTap(l.tapName())
+	errorEvents.remove(l)
+	close(l.done)
+	log.Info("stopping source loggie: %s, %d records dropped", l.name, atomic.LoadInt64(&l.dropped))
+}
+
+func (l *Loggie) ProductLoop(productFunc api.ProductFunc) {
+	log.Info("%s start product loop", l.String())
+
+	log.AddTap(l.tapName(), l.tap)
+	errorEvents.add(l)
+
+	cleanup := time.NewTicker(errorDedupeWindow)
+	defer cleanup.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+
+		case r := <-l.records:
+			l.product(r, productFunc)
+
+		case now := <-cleanup.C:
+			for msg, t := range l.recentErrors {
+				if now.Sub(t) > errorDedupeWindow {
+					delete(l.recentErrors, msg)
+				}
+			}
+		}
+	}
+}
+
+func (l *Loggie) Commit(events []api.Event) {
+	l.eventPool.PutAll(events)
+}
+
+func (l *Loggie) tapName() string {
+	return fmt.Sprintf("%s/%p", l.String(), l)
+}
+
+// tap is called by the logging goroutines, so it only filters and buffers the records without blocking
+func (l *Loggie) tap(level zerolog.Level, raw []byte) {
+	if level < l.level || level == zerolog.NoLevel {
+		return
+	}
+	l.receive(&record{raw: raw, time: time.Now()})
+}
+
+func (l *Loggie) receive(r *record) {
+	select {
+	case l.records <- r:
+	default:
+		atomic.AddInt64(&l.dropped, 1)
+	}
+}
+
+func (l *Loggie) product(r *record, productFunc api.ProductFunc) {
+	if r.raw != nil && !l.parse(r) {
+		return
+	}
+
+	c := component(r)
+	if r.level == levelError {
+		if r.errorEvent {
+			if t, ok := l.recentErrors[r.message]; ok && r.time.Sub(t) < errorDedupeWindow {
+				return
+			}
+		} else {
+			// the records of own pipeline are recorded as well, so that the error events of them are dropped
+			l.recentErrors[r.message] = r.time
+		}
+	}
+	if r.pipeline != "" && r.pipeline == l.pipelineName {
+		return
+	}
+
+	if !l.allow(r.time) {
+		atomic.AddInt64(&l.dropped, 1)
+		return
+	}
+
+	fields := map[string]interface{}{
+		"level":     r.level,
+		"time":      r.time.Format(time.RFC3339Nano),
+		"component": c,
+	}
+	if r.caller != "" {
+		fields["caller"] = r.caller
+	}
+	if r.pipeline != "" {
+		fields["pipeline"] = r.pipeline
+	}
+	if dropped := atomic.SwapInt64(&l.dropped, 0); dropped > 0 {
+		fields["dropped"] = dropped
+	}
+
+	e := l.eventPool.Get()
+	header := e.Header()
+	header[headerKey] = fields
+	e.Fill(e.Meta(), header, []byte(r.message))
+	productFunc(e)
+}
+
+// allow limits the events in each second
+func (l *Loggie) allow(t time.Time) bool {
+	if t.Sub(l.windowStart) >= time.Second || t.Before(l.windowStart) {
+		l.windowStart = t
+		l.windowCount = 0
+	}
+	if l.windowCount >= l.config.RateLimit {
+		return false
+	}
+	l.windowCount++
+	return true
+}
+
+func (l *Loggie) parse(r *record) bool {
+	var fields struct {
+		Level    string `json:"level"`
+		Message  string `json:"message"`
+		Caller   string `json:"caller"`
+		Pipeline string `json:"pipeline"`
+	}
+	if err := json.Unmarshal(r.raw, &fields); err != nil {
+		// the record could not be logged here, it would be fed back
+		return false
+	}
+	r.level = fields.Level
+	r.message = fields.Message
+	r.caller = fields.Caller
+	r.pipeline = fields.Pipeline
+	return true
+}
+
+// component is derived from the package of caller, eg: sink/kafka for pkg/sink/kafka/sink.go:100
+func component(r *record) string {
+	if r.errorEvent {
+		return componentEventbus
+	}
+	caller := r.caller
+	if i := strings.LastIndex(caller, "/pkg/"); i >= 0 {
+		caller = caller[i+len("/pkg/"):]
+	}
+	return path.Dir(caller)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loggie
+
+import (
+	"testing"
+	"time"
+
+	"github.com/creasty/defaults"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/eventbus"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+func startTestLoggie(t *testing.T, config *Config, pipelineName string) (*Loggie, chan api.Event) {
+	log.InitDefaultLogger()
+	if err := defaults.Set(config); err != nil {
+		t.Fatal(err)
+	}
+	l := makeSource(pipeline.Info{EventPool: event.NewDefaultPool(100), PipelineName: pipelineName}).(*Loggie)
+	l.config = config
+	l.Start()
+
+	events := make(chan api.Event, 100)
+	go l.ProductLoop(func(e api.Event) api.Result {
+		events <- e
+		return nil
+	})
+	// wait for the tap added
+	time.Sleep(50 * time.Millisecond)
+	return l, events
+}
+
+func receive(t *testing.T, events chan api.Event) api.Event {
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(time.Second):
+		t.Fatal("event not received")
+		return nil
+	}
+}
+
+func TestLoggie_Records(t *testing.T) {
+	l, events := startTestLoggie(t, &Config{Level: "warn"}, "")
+	defer l.Stop()
+
+	log.Info("ignored by level")
+	log.Warn("disk %s is almost full", "sda")
+
+	e := receive(t, events)
+	fields := e.Header()[headerKey].(map[string]interface{})
+	if string(e.Body()) != "disk sda is almost full" || fields["level"] != "warn" || fields["component"] != "source/loggie" {
+		t.Errorf("unexpected event %s", e.String())
+	}
+	if fields["caller"] == nil {
+		t.Error("caller is missing")
+	}
+
+	// the error event published along with the error record is deduplicated
+	log.Error("write failed")
+	errorEvents.Subscribe(eventbus.NewEvent(eventbus.ErrorTopic, eventbus.ErrorMetricData{ErrorMsg: "write failed"}))
+	errorEvents.Subscribe(eventbus.NewEvent(eventbus.ErrorTopic, eventbus.ErrorMetricData{ErrorMsg: "published only"}))
+
+	e = receive(t, events)
+	if string(e.Body()) != "write failed" || e.Header()[headerKey].(map[string]interface{})["level"] != "error" {
+		t.Errorf("unexpected event %s", e.String())
+	}
+	e = receive(t, events)
+	fields = e.Header()[headerKey].(map[string]interface{})
+	if string(e.Body()) != "published only" || fields["component"] != componentEventbus {
+		t.Errorf("unexpected event %s", e.String())
+	}
+}
+
+func TestLoggie_RateLimit(t *testing.T) {
+	l, events := startTestLoggie(t, &Config{RateLimit: 2}, "")
+	defer l.Stop()
+
+	for i := 0; i < 5; i++ {
+		log.Info("record %d", i)
+	}
+	receive(t, events)
+	receive(t, events)
+	select {
+	case e := <-events:
+		t.Fatalf("rate limit exceeded: %s", e.String())
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	time.Sleep(time.Second)
+	log.Info("next window")
+	e := receive(t, events)
+	fields := e.Header()[headerKey].(map[string]interface{})
+	if string(e.Body()) != "next window" || fields["dropped"] != int64(3) {
+		t.Errorf("unexpected event %s", e.String())
+	}
+}
+
+func TestLoggie_DropOwnPipeline(t *testing.T) {
+	l, events := startTestLoggie(t, &Config{}, "self")
+	defer l.Stop()
+
+	log.ForPipeline("self").Warn("dropped warn")
+	log.ForPipeline("self").Error("dropped error")
+	errorEvents.Subscribe(eventbus.NewEvent(eventbus.ErrorTopic, eventbus.ErrorMetricData{ErrorMsg: "dropped error"}))
+	// the same component in other pipelines is kept
+	log.ForPipeline("other").Error("kept error")
+
+	e := receive(t, events)
+	fields := e.Header()[headerKey].(map[string]interface{})
+	if string(e.Body()) != "kept error" || fields["pipeline"] != "other" || fields["component"] != "source/loggie" {
+		t.Errorf("unexpected event %s", e.String())
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("record of own pipeline is not dropped: %s", e.String())
+	case <-time.After(100 * time.Millisecond):
+	}
+}
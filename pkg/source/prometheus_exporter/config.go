@@ -1,19 +1,69 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
 package prometheus_exporter
 
 import (
+	"fmt"
 	"net/url"
+	"regexp"
 	"time"
 )
 
+const (
+	FormatText   = "text"
+	FormatJson   = "json"
+	FormatSample = "sample"
+)
+
 type Config struct {
-	Endpoints  []string      `yaml:"endpoints,omitempty" validate:"required"`
+	Endpoints  []string      `yaml:"endpoints,omitempty"`
 	Interval   time.Duration `yaml:"interval,omitempty" default:"30s"`
 	Timeout    time.Duration `yaml:"timeout,omitempty" default:"5s"`
 	BufferSize int           `yaml:"bufferSize,omitempty" default:"1000" validate:"gte=1"`
-	ToJson     bool          `yaml:"toJson,omitempty"`
+	// Deprecated: use format json instead
+	ToJson bool `yaml:"toJson,omitempty"`
+	// Format is text for the raw response, json for a json of all the metric families, or
+	// sample for one event per sample
+	Format string `yaml:"format,omitempty" default:"text" validate:"oneof=text json sample"`
+	// MetricRelabelConfigs are applied to the samples in order, only available in sample format
+	MetricRelabelConfigs []RelabelConfig `yaml:"metricRelabelConfigs,omitempty"`
+	Kubernetes           KubernetesSD    `yaml:"kubernetes,omitempty"`
+}
+
+// KubernetesSD discovers the pods on the node by the prometheus annotations, which requires kubernetes discovery enabled
+type KubernetesSD struct {
+	Enabled          bool   `yaml:"enabled,omitempty"`
+	AnnotationPrefix string `yaml:"annotationPrefix,omitempty" default:"prometheus.io"`
+}
+
+type RelabelConfig struct {
+	SourceLabels []string `yaml:"sourceLabels,omitempty"`
+	Separator    string   `yaml:"separator,omitempty" default:";"`
+	Regex        string   `yaml:"regex,omitempty" default:"(.*)"`
+	TargetLabel  string   `yaml:"targetLabel,omitempty"`
+	Replacement  string   `yaml:"replacement,omitempty" default:"$1"`
+	Action       string   `yaml:"action,omitempty" default:"replace"`
 }
 
 func (c *Config) Validate() error {
+	if len(c.Endpoints) == 0 && !c.Kubernetes.Enabled {
+		return fmt.Errorf("prometheus exporter requires endpoints or kubernetes enabled")
+	}
+
 	// check endpoints
 	for _, ep := range c.Endpoints {
 		_, err := url.ParseRequestURI(ep)
@@ -21,5 +71,41 @@ func (c *Config) Validate() error {
 			return err
 		}
 	}
+
+	if len(c.MetricRelabelConfigs) > 0 && c.format() != FormatSample {
+		return fmt.Errorf("metricRelabelConfigs are only available in sample format")
+	}
+	for _, r := range c.MetricRelabelConfigs {
+		if err := r.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Config) format() string {
+	if c.ToJson {
+		return FormatJson
+	}
+	return c.Format
+}
+
+func (r *RelabelConfig) validate() error {
+	if _, err := regexp.Compile(r.Regex); err != nil {
+		return fmt.Errorf("relabel regex %s is invalid: %v", r.Regex, err)
+	}
+	switch r.Action {
+	case actionReplace:
+		if r.TargetLabel == "" {
+			return fmt.Errorf("relabel action replace requires targetLabel")
+		}
+	case actionKeep, actionDrop:
+		if len(r.SourceLabels) == 0 {
+			return fmt.Errorf("relabel action %s requires sourceLabels", r.Action)
+		}
+	case actionLabelDrop, actionLabelKeep:
+	default:
+		return fmt.Errorf("relabel action %s is not supported", r.Action)
+	}
 	return nil
 }
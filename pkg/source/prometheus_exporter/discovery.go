@@ -0,0 +1,126 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus_exporter
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/external"
+)
+
+const defaultMetricsPath = "/metrics"
+
+// target is an endpoint to scrape, labels are added to all the samples scraped from it
+type target struct {
+	url    string
+	labels map[string]string
+}
+
+func staticTargets(endpoints []string) []target {
+	targets := make([]target, 0, len(endpoints))
+	for _, ep := range endpoints {
+		t := target{
+			url:    ep,
+			labels: map[string]string{},
+		}
+		if u, err := url.Parse(ep); err == nil {
+			t.labels["instance"] = u.Host
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+// podTargets discovers the pods annotated with <prefix>/scrape: "true",
+// the port and path could be specified by <prefix>/port and <prefix>/path.
+func podTargets(pods []*corev1.Pod, prefix string) []target {
+	var targets []target
+	for _, pod := range pods {
+		annotations := pod.Annotations
+		if annotations[prefix+"/scrape"] != "true" {
+			continue
+		}
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+
+		port := annotations[prefix+"/port"]
+		if port == "" {
+			port = firstContainerPort(pod)
+		}
+		if port == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(port); err != nil {
+			continue
+		}
+
+		path := annotations[prefix+"/path"]
+		if path == "" {
+			path = defaultMetricsPath
+		}
+		scheme := annotations[prefix+"/scheme"]
+		if scheme == "" {
+			scheme = "http"
+		}
+
+		host := net.JoinHostPort(pod.Status.PodIP, port)
+		targets = append(targets, target{
+			url: fmt.Sprintf("%s://%s%s", scheme, host, path),
+			labels: map[string]string{
+				"instance":  host,
+				"namespace": pod.Namespace,
+				"pod":       pod.Name,
+			},
+		})
+	}
+	return targets
+}
+
+func firstContainerPort(pod *corev1.Pod) string {
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Protocol == "" || p.Protocol == corev1.ProtocolTCP {
+				return strconv.Itoa(int(p.ContainerPort))
+			}
+		}
+	}
+	return ""
+}
+
+func (k *PromExporter) targets() []target {
+	targets := staticTargets(k.config.Endpoints)
+	if !k.config.Kubernetes.Enabled {
+		return targets
+	}
+
+	lister, ok := external.PodLister()
+	if !ok {
+		return targets
+	}
+	pods, err := lister.List(labels.Everything())
+	if err != nil {
+		return targets
+	}
+	return append(targets, podTargets(pods, k.config.Kubernetes.AnnotationPrefix)...)
+}
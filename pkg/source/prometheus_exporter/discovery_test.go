@@ -0,0 +1,69 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus_exporter
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestPod(name string, annotations map[string]string, ports ...int32) *corev1.Pod {
+	var containerPorts []corev1.ContainerPort
+	for _, p := range ports {
+		containerPorts = append(containerPorts, corev1.ContainerPort{ContainerPort: p})
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "default",
+			Name:        name,
+			Annotations: annotations,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Ports: containerPorts}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			PodIP: "10.0.0.1",
+		},
+	}
+}
+
+func TestPodTargets(t *testing.T) {
+	pods := []*corev1.Pod{
+		newTestPod("annotated", map[string]string{
+			"prometheus.io/scrape": "true",
+			"prometheus.io/port":   "9100",
+			"prometheus.io/path":   "/stats",
+		}),
+		newTestPod("container-port", map[string]string{"prometheus.io/scrape": "true"}, 8080),
+		newTestPod("no-port", map[string]string{"prometheus.io/scrape": "true"}),
+		newTestPod("not-annotated", nil, 8080),
+	}
+
+	targets := podTargets(pods, "prometheus.io")
+	if len(targets) != 2 {
+		t.Fatalf("expect 2 targets, got %+v", targets)
+	}
+	if targets[0].url != "http://10.0.0.1:9100/stats" || targets[0].labels["pod"] != "annotated" {
+		t.Errorf("unexpected target %+v", targets[0])
+	}
+	if targets[1].url != "http://10.0.0.1:8080/metrics" || targets[1].labels["instance"] != "10.0.0.1:8080" {
+		t.Errorf("unexpected target %+v", targets[1])
+	}
+}
@@ -1,12 +1,14 @@
 package prometheus_exporter
 
 import (
+	"bytes"
 	ctx "context"
 	"encoding/json"
 	"fmt"
 	"github.com/loggie-io/loggie/pkg/core/api"
 	"github.com/loggie-io/loggie/pkg/core/event"
 	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/discovery/kubernetes/external"
 	"github.com/loggie-io/loggie/pkg/pipeline"
 	"github.com/loggie-io/loggie/pkg/util"
 	"github.com/pkg/errors"
@@ -16,6 +18,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"sync"
 	"time"
 )
 
@@ -34,12 +37,12 @@ func makeSource(info pipeline.Info) api.Component {
 }
 
 type PromExporter struct {
-	name        string
-	config      *Config
-	client      *http.Client
-	requestPool []*http.Request
-	done        chan struct{}
-	eventPool   *event.Pool
+	name       string
+	config     *Config
+	client     *http.Client
+	relabelers []*relabeler
+	done       chan struct{}
+	eventPool  *event.Pool
 }
 
 func (k *PromExporter) Config() interface{} {
@@ -62,23 +65,21 @@ const acceptHeader = `application/openmetrics-text; version=0.0.1,text/plain;ver
 
 func (k *PromExporter) Init(context api.Context) {
 	k.name = context.Name()
-
 	k.client = &http.Client{}
-	for _, ep := range k.config.Endpoints {
-		req, err := http.NewRequest(http.MethodGet, ep, nil)
-		if err != nil {
-			log.Warn("request to endpoint %s error: %v", ep, err)
-			continue
-		}
 
-		req.Header.Add("Accept", acceptHeader)
-		req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", strconv.FormatFloat(k.config.Timeout.Seconds(), 'f', -1, 64))
-
-		k.requestPool = append(k.requestPool, req)
+	relabelers, err := newRelabelers(k.config.MetricRelabelConfigs)
+	if err != nil {
+		log.Error("compile metric relabel configs error: %v", err)
 	}
+	k.relabelers = relabelers
 }
 
 func (k *PromExporter) Start() {
+	if k.config.Kubernetes.Enabled {
+		if _, ok := external.PodLister(); !ok {
+			log.Warn("%s kubernetes discovery is not enabled, pods would not be scraped", k.String())
+		}
+	}
 }
 
 func (k *PromExporter) Stop() {
@@ -110,23 +111,135 @@ func (k *PromExporter) Commit(events []api.Event) {
 }
 
 func (k *PromExporter) batchScrape(c ctx.Context, productFunc api.ProductFunc) {
-	for _, req := range k.requestPool {
-		metrics, err := k.scrape(c, req)
+	var wg sync.WaitGroup
+	for _, t := range k.targets() {
+		tg := t
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			k.scrapeTarget(c, tg, productFunc)
+		}()
+	}
+	wg.Wait()
+}
+
+func (k *PromExporter) scrapeTarget(c ctx.Context, t target, productFunc api.ProductFunc) {
+	start := time.Now()
+	body, err := k.scrape(c, t.url)
+	if err != nil {
+		log.Warn("request to exporter %s error: %+v", t.url, err)
+	}
+
+	switch k.config.format() {
+	case FormatSample:
+		k.productSamples(t, body, err, start, productFunc)
+
+	case FormatJson:
+		if err != nil {
+			return
+		}
+		out, err := promToJson(bytes.NewReader(body))
+		if err != nil {
+			log.Warn("convert prometheus metrics of %s to json failed: %+v", t.url, err)
+			return
+		}
+		k.product(t.labels, out, productFunc)
+
+	default:
+		if err != nil {
+			return
+		}
+		k.product(t.labels, body, productFunc)
+	}
+}
+
+// productSamples sends one event per sample, along with the scrape health samples of the target like prometheus does
+func (k *PromExporter) productSamples(t target, body []byte, scrapeErr error, start time.Time, productFunc api.ProductFunc) {
+	nowMs := util.UnixMilli(start)
+
+	var samples []*sample
+	up := 0.0
+	if scrapeErr == nil {
+		parsed, err := parseSamples(bytes.NewReader(body), nowMs, t.labels)
 		if err != nil {
-			log.Warn("request to exporter error: %+v", err)
+			log.Warn("parse prometheus metrics of %s failed: %+v", t.url, err)
+		} else {
+			samples = parsed
+			up = 1
+		}
+	}
+
+	scraped := len(samples)
+	kept := 0
+	for _, s := range samples {
+		if !relabel(s, k.relabelers) {
 			continue
 		}
+		kept++
+		k.productSample(s, productFunc)
+	}
 
-		e := k.eventPool.Get()
-		e.Fill(e.Meta(), e.Header(), metrics)
+	health := func(name string, value float64) *sample {
+		labels := make(map[string]string, len(t.labels))
+		for k, v := range t.labels {
+			labels[k] = v
+		}
+		return &sample{
+			name:        name,
+			metricType:  "gauge",
+			labels:      labels,
+			value:       value,
+			timestampMs: nowMs,
+		}
+	}
+	k.productSample(health("up", up), productFunc)
+	k.productSample(health("scrape_duration_seconds", time.Since(start).Seconds()), productFunc)
+	k.productSample(health("scrape_samples_scraped", float64(scraped)), productFunc)
+	k.productSample(health("scrape_samples_post_metric_relabeling", float64(kept)), productFunc)
+}
+
+// product sends the whole scraped metrics as the body, with the target labels in the header
+func (k *PromExporter) product(labels map[string]string, body []byte, productFunc api.ProductFunc) {
+	e := k.eventPool.Get()
+	header := e.Header()
+	if header == nil {
+		header = make(map[string]interface{})
+	}
+	if len(labels) > 0 {
+		l := make(map[string]interface{}, len(labels))
+		for k, v := range labels {
+			l[k] = v
+		}
+		header["labels"] = l
+	}
+	e.Fill(e.Meta(), header, body)
+	productFunc(e)
+}
 
-		productFunc(e)
+func (k *PromExporter) productSample(s *sample, productFunc api.ProductFunc) {
+	e := k.eventPool.Get()
+	header := e.Header()
+	if header == nil {
+		header = make(map[string]interface{})
+	}
+	for k, v := range s.header() {
+		header[k] = v
 	}
+	e.Fill(e.Meta(), header, nil)
+	productFunc(e)
 }
 
-func (k *PromExporter) scrape(c ctx.Context, req *http.Request) ([]byte, error) {
+func (k *PromExporter) scrape(c ctx.Context, url string) ([]byte, error) {
 	ct, cancel := ctx.WithTimeout(c, k.config.Timeout)
 	defer cancel()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", acceptHeader)
+	req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", strconv.FormatFloat(k.config.Timeout.Seconds(), 'f', -1, 64))
+
 	resp, err := k.client.Do(req.WithContext(ct))
 	if err != nil {
 		return nil, err
@@ -137,14 +250,6 @@ func (k *PromExporter) scrape(c ctx.Context, req *http.Request) ([]byte, error)
 		return nil, errors.Errorf("server returned HTTP status %s", resp.Status)
 	}
 
-	if k.config.ToJson {
-		out, err := promToJson(resp.Body)
-		if err != nil {
-			return nil, errors.WithMessage(err, "convert prometheus metrics to json failed")
-		}
-		return out, nil
-	}
-
 	out, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, errors.WithMessage(err, "read response body failed")
@@ -0,0 +1,123 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus_exporter
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	actionReplace   = "replace"
+	actionKeep      = "keep"
+	actionDrop      = "drop"
+	actionLabelDrop = "labeldrop"
+	actionLabelKeep = "labelkeep"
+
+	// nameLabel refers to the metric name in relabel rules, the same as prometheus
+	nameLabel = "__name__"
+)
+
+type relabeler struct {
+	config *RelabelConfig
+	regex  *regexp.Regexp
+}
+
+func newRelabelers(configs []RelabelConfig) ([]*relabeler, error) {
+	var rs []*relabeler
+	for i := range configs {
+		c := &configs[i]
+		// anchored as prometheus does
+		regex, err := regexp.Compile("^(?:" + c.Regex + ")$")
+		if err != nil {
+			return nil, err
+		}
+		rs = append(rs, &relabeler{
+			config: c,
+			regex:  regex,
+		})
+	}
+	return rs, nil
+}
+
+// relabel applies the rules to the sample, returns false when the sample should be dropped
+func relabel(s *sample, rs []*relabeler) bool {
+	for _, r := range rs {
+		if !r.apply(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *relabeler) apply(s *sample) bool {
+	c := r.config
+	switch c.Action {
+	case actionKeep:
+		return r.regex.MatchString(s.sourceValue(c.SourceLabels, c.Separator))
+
+	case actionDrop:
+		return !r.regex.MatchString(s.sourceValue(c.SourceLabels, c.Separator))
+
+	case actionLabelDrop:
+		for name := range s.labels {
+			if r.regex.MatchString(name) {
+				delete(s.labels, name)
+			}
+		}
+
+	case actionLabelKeep:
+		for name := range s.labels {
+			if !r.regex.MatchString(name) {
+				delete(s.labels, name)
+			}
+		}
+
+	case actionReplace:
+		value := s.sourceValue(c.SourceLabels, c.Separator)
+		indexes := r.regex.FindStringSubmatchIndex(value)
+		if indexes == nil {
+			return true
+		}
+		target := string(r.regex.ExpandString(nil, c.TargetLabel, value, indexes))
+		result := string(r.regex.ExpandString(nil, c.Replacement, value, indexes))
+		if target == nameLabel {
+			if result != "" {
+				s.name = result
+			}
+			return true
+		}
+		if result == "" {
+			delete(s.labels, target)
+			return true
+		}
+		s.labels[target] = result
+	}
+	return true
+}
+
+func (s *sample) sourceValue(sourceLabels []string, separator string) string {
+	values := make([]string, 0, len(sourceLabels))
+	for _, l := range sourceLabels {
+		if l == nameLabel {
+			values = append(values, s.name)
+			continue
+		}
+		values = append(values, s.labels[l])
+	}
+	return strings.Join(values, separator)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus_exporter
+
+import (
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+type sample struct {
+	name        string
+	metricType  string
+	labels      map[string]string
+	value       float64
+	timestampMs int64
+}
+
+// header converts the sample to the event header, NaN and Inf are not valid in json, so they are formatted as string
+func (s *sample) header() map[string]interface{} {
+	labels := make(map[string]interface{}, len(s.labels))
+	for k, v := range s.labels {
+		labels[k] = v
+	}
+
+	var value interface{} = s.value
+	if math.IsNaN(s.value) || math.IsInf(s.value, 0) {
+		value = strconv.FormatFloat(s.value, 'f', -1, 64)
+	}
+	return map[string]interface{}{
+		"name":      s.name,
+		"type":      s.metricType,
+		"labels":    labels,
+		"value":     value,
+		"timestamp": s.timestampMs,
+	}
+}
+
+func parseSamples(in io.Reader, nowMs int64, targetLabels map[string]string) ([]*sample, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(in)
+	if err != nil {
+		return nil, errors.WithMessage(err, "reading text format failed")
+	}
+
+	var samples []*sample
+	for name, f := range families {
+		metricType := strings.ToLower(f.GetType().String())
+		for _, m := range f.Metric {
+			ts := nowMs
+			if m.TimestampMs != nil {
+				ts = m.GetTimestampMs()
+			}
+			newSample := func(name string, value float64, extra ...string) *sample {
+				labels := make(map[string]string, len(m.Label)+len(targetLabels)+len(extra)/2)
+				for k, v := range targetLabels {
+					labels[k] = v
+				}
+				for _, l := range m.Label {
+					labels[l.GetName()] = l.GetValue()
+				}
+				for i := 0; i+1 < len(extra); i += 2 {
+					labels[extra[i]] = extra[i+1]
+				}
+				return &sample{
+					name:        name,
+					metricType:  metricType,
+					labels:      labels,
+					value:       value,
+					timestampMs: ts,
+				}
+			}
+
+			switch f.GetType() {
+			case dto.MetricType_COUNTER:
+				samples = append(samples, newSample(name, m.GetCounter().GetValue()))
+			case dto.MetricType_GAUGE:
+				samples = append(samples, newSample(name, m.GetGauge().GetValue()))
+			case dto.MetricType_UNTYPED:
+				samples = append(samples, newSample(name, m.GetUntyped().GetValue()))
+			case dto.MetricType_SUMMARY:
+				s := m.GetSummary()
+				for _, q := range s.Quantile {
+					samples = append(samples, newSample(name, q.GetValue(), "quantile", formatFloat(q.GetQuantile())))
+				}
+				samples = append(samples, newSample(name+"_sum", s.GetSampleSum()))
+				samples = append(samples, newSample(name+"_count", float64(s.GetSampleCount())))
+			case dto.MetricType_HISTOGRAM:
+				h := m.GetHistogram()
+				hasInf := false
+				for _, b := range h.Bucket {
+					if math.IsInf(b.GetUpperBound(), 1) {
+						hasInf = true
+					}
+					samples = append(samples, newSample(name+"_bucket", float64(b.GetCumulativeCount()), "le", formatFloat(b.GetUpperBound())))
+				}
+				if !hasInf {
+					samples = append(samples, newSample(name+"_bucket", float64(h.GetSampleCount()), "le", "+Inf"))
+				}
+				samples = append(samples, newSample(name+"_sum", h.GetSampleSum()))
+				samples = append(samples, newSample(name+"_count", float64(h.GetSampleCount())))
+			}
+		}
+	}
+	return samples, nil
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prometheus_exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/creasty/defaults"
+)
+
+const metricsText = `# TYPE http_requests_total counter
+http_requests_total{code="200",method="get"} 1027
+http_requests_total{code="500",method="get"} 3
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="0.1"} 10
+request_duration_seconds_bucket{le="+Inf"} 12
+request_duration_seconds_sum 1.5
+request_duration_seconds_count 12
+# TYPE go_goroutines gauge
+go_goroutines 42
+`
+
+func parseTestSamples(t *testing.T) map[string]*sample {
+	samples, err := parseSamples(strings.NewReader(metricsText), 1000, map[string]string{"instance": "10.0.0.1:8080"})
+	if err != nil {
+		t.Fatalf("parse samples error: %v", err)
+	}
+	m := make(map[string]*sample)
+	for _, s := range samples {
+		m[s.name+"{"+s.labels["code"]+s.labels["le"]+"}"] = s
+	}
+	return m
+}
+
+func TestParseSamples(t *testing.T) {
+	samples := parseTestSamples(t)
+	if len(samples) != 7 {
+		t.Fatalf("expect 7 samples, got %d", len(samples))
+	}
+
+	s := samples["http_requests_total{500}"]
+	if s == nil || s.value != 3 || s.metricType != "counter" || s.labels["method"] != "get" ||
+		s.labels["instance"] != "10.0.0.1:8080" || s.timestampMs != 1000 {
+		t.Errorf("unexpected counter sample %+v", s)
+	}
+	if s := samples["request_duration_seconds_bucket{+Inf}"]; s == nil || s.value != 12 {
+		t.Errorf("unexpected histogram bucket sample %+v", s)
+	}
+	if s := samples["request_duration_seconds_sum{}"]; s == nil || s.value != 1.5 {
+		t.Errorf("unexpected histogram sum sample %+v", s)
+	}
+}
+
+func TestRelabel(t *testing.T) {
+	configs := []RelabelConfig{
+		{SourceLabels: []string{nameLabel}, Regex: "go_.*", Action: actionDrop},
+		{SourceLabels: []string{"code"}, Regex: "5..", Action: actionKeep},
+		{SourceLabels: []string{"method", "code"}, Regex: "(.+);(.+)", TargetLabel: "route", Replacement: "${1}_$2"},
+		{Regex: "method", Action: actionLabelDrop},
+	}
+	for i := range configs {
+		if err := defaults.Set(&configs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rs, err := newRelabelers(configs)
+	if err != nil {
+		t.Fatalf("new relabelers error: %v", err)
+	}
+
+	var kept []*sample
+	for _, s := range parseTestSamples(t) {
+		if relabel(s, rs) {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) != 1 {
+		t.Fatalf("expect 1 sample kept, got %d", len(kept))
+	}
+	s := kept[0]
+	if s.name != "http_requests_total" || s.labels["route"] != "get_500" {
+		t.Errorf("unexpected sample after relabel %+v", s)
+	}
+	if _, ok := s.labels["method"]; ok {
+		t.Errorf("label method should be dropped")
+	}
+}
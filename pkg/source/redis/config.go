@@ -0,0 +1,67 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/loggie-io/loggie/pkg/util/redis"
+)
+
+const (
+	ModeList   = "list"
+	ModeStream = "stream"
+	ModePubSub = "pubsub"
+)
+
+type Config struct {
+	redis.Options `yaml:",inline"`
+	Mode          string        `yaml:"mode,omitempty" default:"list" validate:"oneof=list stream pubsub"`
+	Keys          []string      `yaml:"keys,omitempty"`
+	Patterns      []string      `yaml:"patterns,omitempty"`
+	BlockTimeout  time.Duration `yaml:"blockTimeout,omitempty" default:"1s"`
+	// Count is the max number of entries read from a stream at a time
+	Count int `yaml:"count,omitempty" default:"100"`
+	// Reliable moves the list element into a processing list instead of popping it, the element is
+	// removed from the processing list on Commit. Requires redis >= 6.2
+	Reliable bool   `yaml:"reliable,omitempty"`
+	Group    string `yaml:"group,omitempty" default:"loggie"`
+	// Consumer is the consumer name in the stream group, or the suffix of the processing list, defaults to node name
+	Consumer  string `yaml:"consumer,omitempty"`
+	BodyField string `yaml:"bodyField,omitempty" default:"body"`
+}
+
+func (c *Config) Validate() error {
+	if c.Mode == ModePubSub {
+		if len(c.Keys) == 0 && len(c.Patterns) == 0 {
+			return fmt.Errorf("redis source requires keys or patterns to subscribe")
+		}
+		return nil
+	}
+
+	if len(c.Keys) == 0 {
+		return fmt.Errorf("redis source %s mode requires keys", c.Mode)
+	}
+	if len(c.Patterns) > 0 {
+		return fmt.Errorf("redis source patterns are only supported in pubsub mode")
+	}
+	if c.Reliable && c.Mode != ModeList {
+		return fmt.Errorf("redis source reliable is only supported in list mode")
+	}
+	return nil
+}
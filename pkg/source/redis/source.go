@@ -0,0 +1,521 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/core/sysconfig"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/util/redis"
+)
+
+const (
+	Type = "redis"
+
+	headerKey = "redis"
+	ackKey    = event.PrivateKeyPrefix + "RedisAck"
+
+	reconnectInterval = time.Second
+)
+
+func init() {
+	pipeline.Register(api.SOURCE, Type, makeSource)
+}
+
+func makeSource(info pipeline.Info) api.Component {
+	return &Source{
+		done:      make(chan struct{}),
+		config:    &Config{},
+		eventPool: info.EventPool,
+	}
+}
+
+// ack records what should be done on Commit for the event
+type ack struct {
+	key   string
+	id    string
+	value []byte
+}
+
+type Source struct {
+	name      string
+	done      chan struct{}
+	closeOnce sync.Once
+	config    *Config
+	eventPool *event.Pool
+
+	consumer string
+	pool     *redis.Pool
+
+	lock sync.Mutex
+	conn *redis.Conn
+	// readPending is true before the pending entries of the stream group are all consumed
+	readPending bool
+	// pendingIds records the last pending entry id read from each stream, the next read resumes after it
+	pendingIds map[string]string
+}
+
+func (s *Source) Config() interface{} {
+	return s.config
+}
+
+func (s *Source) Category() api.Category {
+	return api.SOURCE
+}
+
+func (s *Source) Type() api.Type {
+	return Type
+}
+
+func (s *Source) String() string {
+	return fmt.Sprintf("%s/%s", api.SOURCE, Type)
+}
+
+func (s *Source) Init(context api.Context) {
+	s.name = context.Name()
+	s.consumer = s.config.Consumer
+	if s.consumer == "" {
+		s.consumer = sysconfig.NodeName
+	}
+}
+
+func (s *Source) Start() {
+	s.pool = redis.NewPool(&s.config.Options, 1)
+	s.readPending = true
+	s.pendingIds = make(map[string]string, len(s.config.Keys))
+	for _, key := range s.config.Keys {
+		s.pendingIds[key] = "0"
+	}
+
+	if err := s.prepare(); err != nil {
+		log.Error("prepare %s failed: %+v", s.String(), err)
+	}
+	log.Info("%s start, addr: %s, mode: %s", s.String(), s.config.Addr, s.config.Mode)
+}
+
+// prepare creates the stream consumer groups, or recovers the elements left in the processing lists by the last run
+func (s *Source) prepare() error {
+	if s.config.Mode != ModeStream && !s.config.Reliable {
+		return nil
+	}
+
+	conn, err := s.pool.Get()
+	if err != nil {
+		return err
+	}
+	defer s.pool.Put(conn)
+
+	for _, key := range s.config.Keys {
+		if s.config.Mode == ModeStream {
+			_, err := conn.Do("XGROUP", "CREATE", key, s.config.Group, "$", "MKSTREAM")
+			if err != nil && !isBusyGroup(err) {
+				return errors.WithMessagef(err, "create group %s of stream %s failed", s.config.Group, key)
+			}
+			continue
+		}
+
+		// move back to the head of the source list, keeping the original order
+		processing := s.processingKey(key)
+		for {
+			reply, err := conn.Do("LMOVE", processing, key, "LEFT", "LEFT")
+			if err != nil {
+				return errors.WithMessagef(err, "recover processing list %s failed", processing)
+			}
+			if reply == nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func isBusyGroup(err error) bool {
+	e, ok := err.(redis.Error)
+	return ok && len(e) >= 9 && e[:9] == "BUSYGROUP"
+}
+
+func (s *Source) processingKey(key string) string {
+	return key + ":loggie:" + s.consumer
+}
+
+func (s *Source) Stop() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+
+		// unblock the blocking read
+		s.lock.Lock()
+		if s.conn != nil {
+			s.conn.Close()
+			s.conn = nil
+		}
+		s.lock.Unlock()
+
+		if s.pool != nil {
+			s.pool.Close()
+		}
+	})
+}
+
+func (s *Source) ProductLoop(productFunc api.ProductFunc) {
+	log.Info("%s start product loop", s.String())
+
+	for {
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+
+		conn, err := s.getConn()
+		if err == nil {
+			switch s.config.Mode {
+			case ModeStream:
+				err = s.readStream(conn, productFunc)
+			case ModePubSub:
+				err = s.subscribe(conn, productFunc)
+			default:
+				err = s.readList(conn, productFunc)
+			}
+		}
+		if err == nil {
+			continue
+		}
+
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		log.Error("%s consume error: %+v", s.String(), err)
+		s.resetConn()
+		select {
+		case <-s.done:
+			return
+		case <-time.After(reconnectInterval):
+		}
+	}
+}
+
+func (s *Source) getConn() (*redis.Conn, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.conn != nil {
+		return s.conn, nil
+	}
+	conn, err := redis.Dial(&s.config.Options)
+	if err != nil {
+		return nil, err
+	}
+	s.conn = conn
+	return conn, nil
+}
+
+func (s *Source) resetConn() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func (s *Source) readList(conn *redis.Conn, productFunc api.ProductFunc) error {
+	if s.config.Reliable {
+		return s.moveList(conn, productFunc)
+	}
+
+	args := make([]interface{}, 0, len(s.config.Keys)+2)
+	args = append(args, "BLPOP")
+	for _, key := range s.config.Keys {
+		args = append(args, key)
+	}
+	args = append(args, strconv.FormatFloat(s.config.BlockTimeout.Seconds(), 'f', -1, 64))
+
+	reply, err := conn.DoWithBlock(s.config.BlockTimeout, args...)
+	if err != nil {
+		return err
+	}
+	if reply == nil {
+		return nil
+	}
+	values, err := redis.Values(reply)
+	if err != nil || len(values) != 2 {
+		return errors.Errorf("unexpected BLPOP reply: %v", reply)
+	}
+	key, _ := redis.String(values[0])
+	body, _ := values[1].([]byte)
+
+	s.product(productFunc, map[string]interface{}{
+		headerKey: map[string]interface{}{
+			"key": key,
+		},
+	}, body, nil)
+	return nil
+}
+
+// moveList moves one element from each list into its processing list, and waits blockTimeout when all of them are empty
+func (s *Source) moveList(conn *redis.Conn, productFunc api.ProductFunc) error {
+	moved := false
+	for _, key := range s.config.Keys {
+		processing := s.processingKey(key)
+		reply, err := conn.Do("LMOVE", key, processing, "LEFT", "LEFT")
+		if err != nil {
+			return err
+		}
+		if reply == nil {
+			continue
+		}
+		body, _ := reply.([]byte)
+		moved = true
+
+		s.product(productFunc, map[string]interface{}{
+			headerKey: map[string]interface{}{
+				"key": key,
+			},
+		}, body, &ack{key: processing, value: body})
+	}
+
+	if !moved {
+		select {
+		case <-s.done:
+		case <-time.After(s.config.BlockTimeout):
+		}
+	}
+	return nil
+}
+
+func (s *Source) readStream(conn *redis.Conn, productFunc api.ProductFunc) error {
+	args := []interface{}{"XREADGROUP", "GROUP", s.config.Group, s.consumer, "COUNT", s.config.Count}
+	if !s.readPending {
+		args = append(args, "BLOCK", s.config.BlockTimeout.Milliseconds())
+	}
+	args = append(args, "STREAMS")
+	for _, key := range s.config.Keys {
+		args = append(args, key)
+	}
+	for _, key := range s.config.Keys {
+		if s.readPending {
+			args = append(args, s.pendingIds[key])
+		} else {
+			args = append(args, ">")
+		}
+	}
+
+	reply, err := conn.DoWithBlock(s.config.BlockTimeout, args...)
+	if err != nil {
+		return err
+	}
+	if reply == nil {
+		return nil
+	}
+	streams, err := redis.Values(reply)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, st := range streams {
+		pair, err := redis.Values(st)
+		if err != nil || len(pair) != 2 {
+			return errors.Errorf("unexpected XREADGROUP reply: %v", st)
+		}
+		key, _ := redis.String(pair[0])
+		entries, _ := redis.Values(pair[1])
+		for _, en := range entries {
+			entry, err := redis.Values(en)
+			if err != nil || len(entry) != 2 {
+				return errors.Errorf("unexpected stream entry: %v", en)
+			}
+			entryId, _ := redis.String(entry[0])
+			count++
+			if s.readPending {
+				s.pendingIds[key] = entryId
+			}
+
+			fields, _ := redis.Values(entry[1])
+			if fields == nil {
+				// the pending entry has been deleted from the stream, nothing to deliver
+				if _, err := conn.Do("XACK", key, s.config.Group, entryId); err != nil {
+					return err
+				}
+				continue
+			}
+
+			header := map[string]interface{}{
+				headerKey: map[string]interface{}{
+					"key": key,
+					"id":  entryId,
+				},
+			}
+			var body []byte
+			for i := 0; i+1 < len(fields); i += 2 {
+				field, _ := redis.String(fields[i])
+				value, _ := fields[i+1].([]byte)
+				if field == s.config.BodyField {
+					body = value
+					continue
+				}
+				header[field] = string(value)
+			}
+			s.product(productFunc, header, body, &ack{key: key, id: entryId})
+		}
+	}
+
+	if s.readPending && count == 0 {
+		s.readPending = false
+	}
+	return nil
+}
+
+func (s *Source) subscribe(conn *redis.Conn, productFunc api.ProductFunc) error {
+	if len(s.config.Keys) > 0 {
+		args := []interface{}{"SUBSCRIBE"}
+		for _, key := range s.config.Keys {
+			args = append(args, key)
+		}
+		if err := conn.Send(args...); err != nil {
+			return err
+		}
+	}
+	if len(s.config.Patterns) > 0 {
+		args := []interface{}{"PSUBSCRIBE"}
+		for _, p := range s.config.Patterns {
+			args = append(args, p)
+		}
+		if err := conn.Send(args...); err != nil {
+			return err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		return err
+	}
+
+	for {
+		reply, err := conn.Receive(0)
+		if err != nil {
+			return err
+		}
+		values, err := redis.Values(reply)
+		if err != nil || len(values) < 3 {
+			return errors.Errorf("unexpected pub/sub reply: %v", reply)
+		}
+		kind, _ := redis.String(values[0])
+
+		var channel string
+		var body []byte
+		switch kind {
+		case "message":
+			channel, _ = redis.String(values[1])
+			body, _ = values[2].([]byte)
+		case "pmessage":
+			if len(values) != 4 {
+				return errors.Errorf("unexpected pub/sub reply: %v", reply)
+			}
+			channel, _ = redis.String(values[2])
+			body, _ = values[3].([]byte)
+		default:
+			// subscribe confirmations
+			continue
+		}
+
+		// messages are lost when nobody subscribes, so there is nothing to ack
+		s.product(productFunc, map[string]interface{}{
+			headerKey: map[string]interface{}{
+				"channel": channel,
+			},
+		}, body, nil)
+	}
+}
+
+func (s *Source) product(productFunc api.ProductFunc, header map[string]interface{}, body []byte, a *ack) {
+	e := s.eventPool.Get()
+	header["@timestamp"] = time.Now().Format(time.RFC3339)
+	meta := e.Meta()
+	if a != nil {
+		meta.Set(ackKey, a)
+	}
+	e.Fill(meta, header, body)
+	productFunc(e)
+}
+
+// Commit acks the stream entries with XACK, or removes the elements from the processing lists in reliable list mode
+func (s *Source) Commit(events []api.Event) {
+	defer s.eventPool.PutAll(events)
+
+	var acks []*ack
+	for _, e := range events {
+		v, ok := e.Meta().Get(ackKey)
+		if !ok {
+			continue
+		}
+		if a, ok := v.(*ack); ok {
+			acks = append(acks, a)
+		}
+	}
+	if len(acks) == 0 {
+		return
+	}
+
+	if err := s.ack(acks); err != nil {
+		log.Error("%s commit %d events failed: %+v", s.String(), len(acks), err)
+	}
+}
+
+func (s *Source) ack(acks []*ack) error {
+	conn, err := s.pool.Get()
+	if err != nil {
+		return err
+	}
+
+	for _, a := range acks {
+		if s.config.Mode == ModeStream {
+			err = conn.Send("XACK", a.key, s.config.Group, a.id)
+		} else {
+			err = conn.Send("LREM", a.key, 1, a.value)
+		}
+		if err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	if err := conn.Flush(); err != nil {
+		conn.Close()
+		return err
+	}
+
+	var replyErr error
+	for range acks {
+		if _, err := conn.Receive(s.config.ReadTimeout); err != nil {
+			if _, ok := err.(redis.Error); !ok {
+				conn.Close()
+				return err
+			}
+			replyErr = err
+		}
+	}
+	s.pool.Put(conn)
+	return replyErr
+}
@@ -0,0 +1,250 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/util/redis"
+)
+
+// fakeRedis records the commands and answers them with the reply returned by handler
+type fakeRedis struct {
+	lock     sync.Mutex
+	commands []string
+	handler  func(args []string) string
+}
+
+func (f *fakeRedis) serve(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go f.handle(conn)
+		}
+	}()
+	return l
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		f.lock.Lock()
+		f.commands = append(f.commands, strings.Join(args, " "))
+		reply := f.handler(args)
+		f.lock.Unlock()
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *fakeRedis) received(prefix string) []string {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var cmds []string
+	for _, c := range f.commands {
+		if strings.HasPrefix(c, prefix) {
+			cmds = append(cmds, c)
+		}
+	}
+	return cmds
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+	return args, nil
+}
+
+func newTestSource(addr string, mode string) *Source {
+	s := makeSource(pipeline.Info{EventPool: event.NewDefaultPool(10)}).(*Source)
+	s.config = &Config{
+		Options: redis.Options{
+			Addr:         addr,
+			DialTimeout:  time.Second,
+			ReadTimeout:  time.Second,
+			WriteTimeout: time.Second,
+		},
+		Mode:         mode,
+		Keys:         []string{"logs"},
+		BlockTimeout: 10 * time.Millisecond,
+		Count:        10,
+		Group:        "loggie",
+		Consumer:     "node1",
+		BodyField:    "body",
+	}
+	s.consumer = s.config.Consumer
+	return s
+}
+
+func consumeOne(t *testing.T, s *Source) api.Event {
+	events := make(chan api.Event, 1)
+	go s.ProductLoop(func(e api.Event) api.Result {
+		events <- e
+		return nil
+	})
+	select {
+	case e := <-events:
+		return e
+	case <-time.After(3 * time.Second):
+		t.Fatalf("no event consumed")
+	}
+	return nil
+}
+
+func TestSource_StreamCommit(t *testing.T) {
+	log.InitDefaultLogger()
+	fake := &fakeRedis{}
+	fake.handler = func(args []string) string {
+		switch args[0] {
+		case "XGROUP":
+			return "-BUSYGROUP Consumer Group name already exists\r\n"
+		case "XREADGROUP":
+			switch args[len(args)-1] {
+			case "0":
+				// the entry stays pending until acked, reading from 0 always returns it
+				return "*1\r\n*2\r\n$4\r\nlogs\r\n*1\r\n*2\r\n$3\r\n1-0\r\n*4\r\n$4\r\nbody\r\n$5\r\nhello\r\n$3\r\napp\r\n$3\r\nweb\r\n"
+			case ">":
+				return "*-1\r\n"
+			}
+			return "*1\r\n*2\r\n$4\r\nlogs\r\n*0\r\n"
+		case "XACK":
+			return ":1\r\n"
+		}
+		return "-ERR unknown command\r\n"
+	}
+	l := fake.serve(t)
+	defer l.Close()
+
+	s := newTestSource(l.Addr().String(), ModeStream)
+	s.Start()
+	defer s.Stop()
+
+	events := make(chan api.Event, 10)
+	go s.ProductLoop(func(e api.Event) api.Result {
+		events <- e
+		return nil
+	})
+	var e api.Event
+	select {
+	case e = <-events:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("no event consumed")
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for len(fake.received("XREADGROUP GROUP loggie node1 COUNT 10 BLOCK")) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("pending entries are never finished, commands %v", fake.received("XREADGROUP"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(events) != 0 {
+		t.Fatalf("pending entry is delivered %d more times", len(events))
+	}
+	if resumed := fake.received("XREADGROUP GROUP loggie node1 COUNT 10 STREAMS logs 1-0"); len(resumed) != 1 {
+		t.Errorf("expect pending read resumed after 1-0, got %v", fake.received("XREADGROUP"))
+	}
+
+	if string(e.Body()) != "hello" || e.Header()["app"] != "web" {
+		t.Fatalf("unexpected event body %s, header %v", e.Body(), e.Header())
+	}
+	if r := e.Header()[headerKey].(map[string]interface{}); r["id"] != "1-0" || r["key"] != "logs" {
+		t.Errorf("unexpected redis header %v", r)
+	}
+
+	s.Commit([]api.Event{e})
+	acks := fake.received("XACK")
+	if len(acks) != 1 || acks[0] != "XACK logs loggie 1-0" {
+		t.Fatalf("unexpected acks %v", acks)
+	}
+}
+
+func TestSource_ReliableListCommit(t *testing.T) {
+	log.InitDefaultLogger()
+	fake := &fakeRedis{}
+	moved := false
+	fake.handler = func(args []string) string {
+		switch {
+		case args[0] == "LMOVE" && args[1] == "logs" && !moved:
+			moved = true
+			return "$5\r\nhello\r\n"
+		case args[0] == "LMOVE":
+			return "$-1\r\n"
+		case args[0] == "LREM":
+			return ":1\r\n"
+		}
+		return "-ERR unknown command\r\n"
+	}
+	l := fake.serve(t)
+	defer l.Close()
+
+	s := newTestSource(l.Addr().String(), ModeList)
+	s.config.Reliable = true
+	s.Start()
+	defer s.Stop()
+
+	e := consumeOne(t, s)
+	if string(e.Body()) != "hello" {
+		t.Fatalf("unexpected event body %s", e.Body())
+	}
+	if recovered := fake.received("LMOVE logs:loggie:node1 logs"); len(recovered) != 1 {
+		t.Errorf("expect processing list recovered on start, got %v", recovered)
+	}
+
+	s.Commit([]api.Event{e})
+	rems := fake.received("LREM")
+	if len(rems) != 1 || rems[0] != "LREM logs:loggie:node1 1 hello" {
+		t.Fatalf("unexpected LREM commands %v", rems)
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tcp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/loggie-io/loggie/pkg/util/framing"
+)
+
+type Config struct {
+	Addr    string         `yaml:"addr,omitempty" validate:"required"`
+	Framing framing.Config `yaml:"framing,omitempty"`
+	// MaxBytes is the max size of a message, the connection would be closed when exceeded
+	MaxBytes       int `yaml:"maxBytes,omitempty" default:"40960"`
+	MaxConnections int `yaml:"maxConnections,omitempty" default:"512"`
+	// Timeout closes the connection which has nothing to read within the duration
+	Timeout time.Duration `yaml:"timeout,omitempty" default:"5m"`
+	TLS     *TLS          `yaml:"tls,omitempty"`
+	// PeerField is the header key of the peer address, set to `-` to disable it
+	PeerField string `yaml:"peerField,omitempty" default:"peer"`
+}
+
+type TLS struct {
+	CertFile string `yaml:"certFile,omitempty" validate:"required"`
+	KeyFile  string `yaml:"keyFile,omitempty" validate:"required"`
+	// CAFile is used to verify client certificates, clients are required to present one when it is set
+	CAFile string `yaml:"caFile,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if c.MaxBytes <= 0 {
+		return fmt.Errorf("tcp source maxBytes must be positive")
+	}
+	return c.Framing.Validate()
+}
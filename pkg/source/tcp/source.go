@@ -0,0 +1,275 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tcp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/netutil"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/util/framing"
+)
+
+const (
+	Type = "tcp"
+
+	disablePeerField = "-"
+
+	// the retry delay of accepting after an error, eg: too many open files, doubled up to the max like net/http
+	minAcceptDelay = 5 * time.Millisecond
+	maxAcceptDelay = time.Second
+)
+
+func init() {
+	pipeline.Register(api.SOURCE, Type, makeSource)
+}
+
+func makeSource(info pipeline.Info) api.Component {
+	return &Tcp{
+		config:    &Config{},
+		eventPool: info.EventPool,
+		done:      make(chan struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+type Tcp struct {
+	name      string
+	config    *Config
+	eventPool *event.Pool
+	done      chan struct{}
+	closeOnce sync.Once
+
+	lock     sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+}
+
+func (t *Tcp) Config() interface{} {
+	return t.config
+}
+
+func (t *Tcp) Category() api.Category {
+	return api.SOURCE
+}
+
+func (t *Tcp) Type() api.Type {
+	return Type
+}
+
+func (t *Tcp) String() string {
+	return fmt.Sprintf("%s/%s", api.SOURCE, Type)
+}
+
+func (t *Tcp) Init(context api.Context) {
+	t.name = context.Name()
+}
+
+func (t *Tcp) Start() {
+	log.Info("%s start, listening on %s", t.String(), t.config.Addr)
+}
+
+func (t *Tcp) Stop() {
+	log.Info("stopping source tcp: %s", t.name)
+	t.closeOnce.Do(func() {
+		close(t.done)
+	})
+
+	// closing the listener and connections interrupts the blocking accept and reads
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.listener != nil {
+		t.listener.Close()
+	}
+	for conn := range t.conns {
+		conn.Close()
+	}
+}
+
+func (t *Tcp) ProductLoop(productFunc api.ProductFunc) {
+	log.Info("%s start product loop", t.String())
+
+	listener, err := t.listen()
+	if err != nil {
+		log.Error("setup tcp listener failed: %v", err)
+		return
+	}
+	defer listener.Close()
+
+	var delay time.Duration
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-t.done:
+				return
+			default:
+			}
+
+			if delay == 0 {
+				delay = minAcceptDelay
+			} else if delay *= 2; delay > maxAcceptDelay {
+				delay = maxAcceptDelay
+			}
+			log.Warn("tcp listener accept connection failed: %v, retrying in %v", err, delay)
+			select {
+			case <-t.done:
+				return
+			case <-time.After(delay):
+			}
+			continue
+		}
+		delay = 0
+
+		if !t.track(conn) {
+			conn.Close()
+			return
+		}
+		go t.handleConn(conn, productFunc)
+	}
+}
+
+func (t *Tcp) Commit(events []api.Event) {
+	t.eventPool.PutAll(events)
+}
+
+func (t *Tcp) listen() (net.Listener, error) {
+	listener, err := net.Listen("tcp", t.config.Addr)
+	if err != nil {
+		return nil, err
+	}
+	// limit the raw connections, so the tls handshakes are limited as well
+	if t.config.MaxConnections > 0 {
+		listener = netutil.LimitListener(listener, t.config.MaxConnections)
+	}
+	if t.config.TLS != nil {
+		tlsConfig, err := newTLSConfig(t.config.TLS)
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	select {
+	case <-t.done:
+		listener.Close()
+		return nil, errors.New("source is stopped")
+	default:
+	}
+	t.listener = listener
+	return listener, nil
+}
+
+// track records the connection to be closed when stopping, it returns false if the source is stopped already
+func (t *Tcp) track(conn net.Conn) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	select {
+	case <-t.done:
+		return false
+	default:
+	}
+	t.conns[conn] = struct{}{}
+	return true
+}
+
+func (t *Tcp) untrack(conn net.Conn) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	delete(t.conns, conn)
+}
+
+func (t *Tcp) handleConn(conn net.Conn, productFunc api.ProductFunc) {
+	defer func() {
+		t.untrack(conn)
+		conn.Close()
+	}()
+
+	peer := conn.RemoteAddr().String()
+	scan, err := framing.NewScanner(t.config.Framing, conn, t.config.MaxBytes)
+	if err != nil {
+		log.Error("%s create scanner failed: %v", t.String(), err)
+		return
+	}
+
+	for {
+		if err := conn.SetReadDeadline(time.Now().Add(t.config.Timeout)); err != nil {
+			log.Warn("set connection timeout error: %v", err)
+		}
+
+		if !scan.Scan() {
+			if err := scan.Err(); err != nil {
+				select {
+				case <-t.done:
+				default:
+					log.Warn("scan connection from %s error: %v", peer, err)
+				}
+			}
+			return
+		}
+
+		// the bytes of scanner would be overwritten by the next scan
+		body := make([]byte, len(scan.Bytes()))
+		copy(body, scan.Bytes())
+
+		e := t.eventPool.Get()
+		header := e.Header()
+		if t.config.PeerField != disablePeerField {
+			header[t.config.PeerField] = peer
+		}
+		e.Fill(e.Meta(), header, body)
+		productFunc(e)
+	}
+}
+
+func newTLSConfig(c *TLS) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, errors.WithMessage(err, "load tls certificate failed")
+	}
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if c.CAFile != "" {
+		ca, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, errors.WithMessagef(err, "read ca file %s failed", c.CAFile)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.Errorf("no certificate found in ca file %s", c.CAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return config, nil
+}
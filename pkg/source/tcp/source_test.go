@@ -0,0 +1,154 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tcp
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creasty/defaults"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/util/framing"
+)
+
+type collector struct {
+	lock   sync.Mutex
+	events []api.Event
+}
+
+func (c *collector) product(e api.Event) api.Result {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.events = append(c.events, e)
+	return nil
+}
+
+func (c *collector) get() []api.Event {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.events
+}
+
+func startTestTcp(t *testing.T, config *Config, c *collector) (*Tcp, string, chan struct{}) {
+	log.InitDefaultLogger()
+	if err := defaults.Set(config); err != nil {
+		t.Fatal(err)
+	}
+	s := makeSource(pipeline.Info{EventPool: event.NewDefaultPool(100)}).(*Tcp)
+	s.config = config
+
+	done := make(chan struct{})
+	go func() {
+		s.ProductLoop(c.product)
+		close(done)
+	}()
+
+	for i := 0; i < 100; i++ {
+		s.lock.Lock()
+		l := s.listener
+		s.lock.Unlock()
+		if l != nil {
+			return s, l.Addr().String(), done
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("tcp listener not started")
+	return nil, "", nil
+}
+
+func waitEvents(c *collector, n int) []api.Event {
+	for i := 0; i < 100 && len(c.get()) < n; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return c.get()
+}
+
+func TestTcp_OctetCounted(t *testing.T) {
+	c := &collector{}
+	s, addr, done := startTestTcp(t, &Config{
+		Addr:    "127.0.0.1:0",
+		Framing: framing.Config{Type: framing.OctetCounted},
+	}, c)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("5 hello11 hello\nworld")); err != nil {
+		t.Fatal(err)
+	}
+
+	events := waitEvents(c, 2)
+	if len(events) != 2 || string(events[0].Body()) != "hello" || string(events[1].Body()) != "hello\nworld" {
+		t.Fatalf("unexpected events %v", events)
+	}
+	if events[0].Header()["peer"] != conn.LocalAddr().String() {
+		t.Errorf("unexpected peer %v", events[0].Header()["peer"])
+	}
+
+	s.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("product loop not stopped")
+	}
+	// the connection is closed by stopping
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("expect connection closed")
+	}
+	// stopping again is harmless
+	s.Stop()
+}
+
+func TestTcp_MaxBytes(t *testing.T) {
+	c := &collector{}
+	s, addr, _ := startTestTcp(t, &Config{
+		Addr:      "127.0.0.1:0",
+		MaxBytes:  8,
+		PeerField: disablePeerField,
+	}, c)
+	defer s.Stop()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("a\nlonger than max bytes\nb\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	events := waitEvents(c, 1)
+	if len(events) != 1 || string(events[0].Body()) != "a" {
+		t.Fatalf("unexpected events %v", events)
+	}
+	if _, ok := events[0].Header()["peer"]; ok {
+		t.Error("peer field should be disabled")
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("expect connection closed when message too long")
+	}
+}
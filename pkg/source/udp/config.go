@@ -0,0 +1,42 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package udp
+
+import (
+	"fmt"
+
+	"github.com/loggie-io/loggie/pkg/util/framing"
+)
+
+type Config struct {
+	Addr string `yaml:"addr,omitempty" validate:"required"`
+	// Framing splits messages inside a datagram, a datagram without any separator is one message
+	Framing framing.Config `yaml:"framing,omitempty"`
+	// MaxBytes is the max size of a datagram, the exceeded part would be truncated
+	MaxBytes int `yaml:"maxBytes,omitempty" default:"65535"`
+	// ReadBufferSize sets the socket receive buffer, increase it when datagrams are dropped in bursts
+	ReadBufferSize int `yaml:"readBufferSize,omitempty"`
+	// PeerField is the header key of the peer address, set to `-` to disable it
+	PeerField string `yaml:"peerField,omitempty" default:"peer"`
+}
+
+func (c *Config) Validate() error {
+	if c.MaxBytes <= 0 {
+		return fmt.Errorf("udp source maxBytes must be positive")
+	}
+	return c.Framing.Validate()
+}
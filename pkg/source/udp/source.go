@@ -0,0 +1,187 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package udp
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+	"github.com/loggie-io/loggie/pkg/util/framing"
+)
+
+const (
+	Type = "udp"
+
+	disablePeerField = "-"
+)
+
+func init() {
+	pipeline.Register(api.SOURCE, Type, makeSource)
+}
+
+func makeSource(info pipeline.Info) api.Component {
+	return &Udp{
+		config:    &Config{},
+		eventPool: info.EventPool,
+		done:      make(chan struct{}),
+	}
+}
+
+type Udp struct {
+	name      string
+	config    *Config
+	eventPool *event.Pool
+	done      chan struct{}
+	closeOnce sync.Once
+
+	lock sync.Mutex
+	conn *net.UDPConn
+}
+
+func (u *Udp) Config() interface{} {
+	return u.config
+}
+
+func (u *Udp) Category() api.Category {
+	return api.SOURCE
+}
+
+func (u *Udp) Type() api.Type {
+	return Type
+}
+
+func (u *Udp) String() string {
+	return fmt.Sprintf("%s/%s", api.SOURCE, Type)
+}
+
+func (u *Udp) Init(context api.Context) {
+	u.name = context.Name()
+}
+
+func (u *Udp) Start() {
+	log.Info("%s start, listening on %s", u.String(), u.config.Addr)
+}
+
+func (u *Udp) Stop() {
+	log.Info("stopping source udp: %s", u.name)
+	u.closeOnce.Do(func() {
+		close(u.done)
+	})
+
+	// closing the connection interrupts the blocking read
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if u.conn != nil {
+		u.conn.Close()
+	}
+}
+
+func (u *Udp) ProductLoop(productFunc api.ProductFunc) {
+	log.Info("%s start product loop", u.String())
+
+	conn, err := u.listen()
+	if err != nil {
+		log.Error("setup udp listener failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// one more byte to find out the truncated datagrams
+	buf := make([]byte, u.config.MaxBytes+1)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-u.done:
+				return
+			default:
+			}
+			log.Warn("udp read failed: %v", err)
+			continue
+		}
+
+		if n > u.config.MaxBytes {
+			log.Warn("datagram from %s exceeds maxBytes %d, truncated", addr, u.config.MaxBytes)
+			n = u.config.MaxBytes
+		}
+		u.handleDatagram(buf[:n], addr.String(), productFunc)
+	}
+}
+
+func (u *Udp) Commit(events []api.Event) {
+	u.eventPool.PutAll(events)
+}
+
+func (u *Udp) listen() (*net.UDPConn, error) {
+	addr, err := net.ResolveUDPAddr("udp", u.config.Addr)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if u.config.ReadBufferSize > 0 {
+		if err := conn.SetReadBuffer(u.config.ReadBufferSize); err != nil {
+			log.Warn("set udp read buffer size failed: %v", err)
+		}
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	select {
+	case <-u.done:
+		conn.Close()
+		return nil, errors.New("source is stopped")
+	default:
+	}
+	u.conn = conn
+	return conn, nil
+}
+
+func (u *Udp) handleDatagram(data []byte, peer string, productFunc api.ProductFunc) {
+	scan, err := framing.NewScanner(u.config.Framing, bytes.NewReader(data), len(data)+1)
+	if err != nil {
+		log.Error("%s create scanner failed: %v", u.String(), err)
+		return
+	}
+
+	for scan.Scan() {
+		// the read buffer would be overwritten by the next datagram
+		body := make([]byte, len(scan.Bytes()))
+		copy(body, scan.Bytes())
+
+		e := u.eventPool.Get()
+		header := e.Header()
+		if u.config.PeerField != disablePeerField {
+			header[u.config.PeerField] = peer
+		}
+		e.Fill(e.Meta(), header, body)
+		productFunc(e)
+	}
+	if err := scan.Err(); err != nil {
+		log.Warn("scan datagram from %s error: %v", peer, err)
+	}
+}
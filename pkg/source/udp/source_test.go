@@ -0,0 +1,118 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package udp
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/creasty/defaults"
+
+	"github.com/loggie-io/loggie/pkg/core/api"
+	"github.com/loggie-io/loggie/pkg/core/event"
+	"github.com/loggie-io/loggie/pkg/core/log"
+	"github.com/loggie-io/loggie/pkg/pipeline"
+)
+
+type collector struct {
+	lock   sync.Mutex
+	events []api.Event
+}
+
+func (c *collector) product(e api.Event) api.Result {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.events = append(c.events, e)
+	return nil
+}
+
+func (c *collector) get() []api.Event {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.events
+}
+
+func TestUdp_Datagrams(t *testing.T) {
+	log.InitDefaultLogger()
+	config := &Config{
+		Addr:     "127.0.0.1:0",
+		MaxBytes: 16,
+	}
+	if err := defaults.Set(config); err != nil {
+		t.Fatal(err)
+	}
+	s := makeSource(pipeline.Info{EventPool: event.NewDefaultPool(100)}).(*Udp)
+	s.config = config
+
+	c := &collector{}
+	done := make(chan struct{})
+	go func() {
+		s.ProductLoop(c.product)
+		close(done)
+	}()
+
+	var addr string
+	for i := 0; i < 100 && addr == ""; i++ {
+		s.lock.Lock()
+		if s.conn != nil {
+			addr = s.conn.LocalAddr().String()
+		}
+		s.lock.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if addr == "" {
+		t.Fatal("udp listener not started")
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	for _, d := range []string{"a\nb", "single", "truncated datagram"} {
+		if _, err := conn.Write([]byte(d)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for i := 0; i < 100 && len(c.get()) < 4; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	events := c.get()
+	if len(events) != 4 {
+		t.Fatalf("expect 4 events, got %v", events)
+	}
+	for i, want := range []string{"a", "b", "single", "truncated datagr"} {
+		if string(events[i].Body()) != want {
+			t.Errorf("event %d: got %s, want %s", i, events[i].Body(), want)
+		}
+	}
+	if events[0].Header()["peer"] != conn.LocalAddr().String() {
+		t.Errorf("unexpected peer %v", events[0].Header()["peer"])
+	}
+
+	s.Stop()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("product loop not stopped")
+	}
+	// stopping again is harmless
+	s.Stop()
+}
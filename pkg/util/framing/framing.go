@@ -0,0 +1,146 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framing
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+const (
+	Newline      = "newline"
+	OctetCounted = "octetCounted"
+	Delimiter    = "delimiter"
+)
+
+// Config describes how messages are split from a stream
+type Config struct {
+	// Type is one of newline, octetCounted(RFC 6587, eg: `11 hello world`) and delimiter
+	Type string `yaml:"type,omitempty" default:"newline" validate:"oneof=newline octetCounted delimiter"`
+	// Delimiter is the separator of messages when type is delimiter, escapes like \x00 and \r\n are supported
+	Delimiter string `yaml:"delimiter,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if c.Type == Delimiter {
+		d, err := c.delimiter()
+		if err != nil {
+			return err
+		}
+		if len(d) == 0 {
+			return fmt.Errorf("delimiter is required when framing type is delimiter")
+		}
+	}
+	return nil
+}
+
+func (c *Config) delimiter() ([]byte, error) {
+	d, err := strconv.Unquote(`"` + c.Delimiter + `"`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid framing delimiter %s: %v", c.Delimiter, err)
+	}
+	return []byte(d), nil
+}
+
+// NewScanner returns a scanner split by the framing, a message larger than maxBytes makes the scan failed with
+// bufio.ErrTooLong.
+func NewScanner(c Config, r interface{ Read([]byte) (int, error) }, maxBytes int) (*bufio.Scanner, error) {
+	split, err := SplitFunc(c)
+	if err != nil {
+		return nil, err
+	}
+	scan := bufio.NewScanner(r)
+	initSize := maxBytes / 4
+	if initSize > 4096 {
+		initSize = 4096
+	}
+	scan.Buffer(make([]byte, initSize), maxBytes)
+	scan.Split(split)
+	return scan, nil
+}
+
+func SplitFunc(c Config) (bufio.SplitFunc, error) {
+	switch c.Type {
+	case OctetCounted:
+		return scanOctetCounted, nil
+
+	case Delimiter:
+		d, err := c.delimiter()
+		if err != nil {
+			return nil, err
+		}
+		return scanDelimiter(d), nil
+
+	default:
+		return bufio.ScanLines, nil
+	}
+}
+
+// scanOctetCounted splits messages in the format of `MSG-LEN SP MSG`, a trailing newline between frames sent by
+// some clients is skipped.
+func scanOctetCounted(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	start := 0
+	for start < len(data) && (data[start] == '\n' || data[start] == '\r') {
+		start++
+	}
+	if start == len(data) {
+		return start, nil, nil
+	}
+
+	sp := bytes.IndexByte(data[start:], ' ')
+	if sp < 0 {
+		if atEOF {
+			return 0, nil, fmt.Errorf("incomplete octet counted frame")
+		}
+		if len(data)-start > 10 {
+			return 0, nil, fmt.Errorf("invalid octet counted frame length %q", data[start:start+10])
+		}
+		return start, nil, nil
+	}
+
+	length, err := strconv.Atoi(string(data[start : start+sp]))
+	if err != nil || length < 0 {
+		return 0, nil, fmt.Errorf("invalid octet counted frame length %q", data[start:start+sp])
+	}
+
+	end := start + sp + 1 + length
+	if end > len(data) {
+		if atEOF {
+			return 0, nil, fmt.Errorf("incomplete octet counted frame")
+		}
+		// request more data
+		return start, nil, nil
+	}
+	return end, data[start+sp+1 : end], nil
+}
+
+func scanDelimiter(delimiter []byte) bufio.SplitFunc {
+	return func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if atEOF && len(data) == 0 {
+			return 0, nil, nil
+		}
+		if i := bytes.Index(data, delimiter); i >= 0 {
+			return i + len(delimiter), data[:i], nil
+		}
+		if atEOF {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}
+}
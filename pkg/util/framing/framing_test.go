@@ -0,0 +1,96 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framing
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func scanAll(t *testing.T, c Config, input string, maxBytes int) ([]string, error) {
+	scan, err := NewScanner(c, strings.NewReader(input), maxBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result []string
+	for scan.Scan() {
+		result = append(result, scan.Text())
+	}
+	return result, scan.Err()
+}
+
+func TestNewScanner(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		input  string
+		want   []string
+	}{
+		{
+			name:   "newline",
+			config: Config{Type: Newline},
+			input:  "a\r\nb\nc",
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name:   "octet counted",
+			config: Config{Type: OctetCounted},
+			input:  "5 hello11 hello\nworld\n0 3 end",
+			want:   []string{"hello", "hello\nworld", "", "end"},
+		},
+		{
+			name:   "delimiter",
+			config: Config{Type: Delimiter, Delimiter: `\x00`},
+			input:  "a\nb\x00c\x00",
+			want:   []string{"a\nb", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scanAll(t, tt.config, tt.input, 1024)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scan got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewScanner_Error(t *testing.T) {
+	if _, err := scanAll(t, Config{Type: OctetCounted}, "5 hel", 1024); err == nil {
+		t.Error("expect error of incomplete frame")
+	}
+	if _, err := scanAll(t, Config{Type: OctetCounted}, "abc hello", 1024); err == nil {
+		t.Error("expect error of invalid frame length")
+	}
+	if _, err := scanAll(t, Config{Type: Newline}, strings.Repeat("a", 100)+"\n", 16); err != bufio.ErrTooLong {
+		t.Errorf("expect ErrTooLong, got %v", err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := (&Config{Type: Delimiter}).Validate(); err == nil {
+		t.Error("expect error of empty delimiter")
+	}
+	if err := (&Config{Type: Delimiter, Delimiter: `\r\n`}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/loggie-io/loggie/pkg/core/log"
+)
+
+const namespaceEnv = "POD_NAMESPACE"
+
+type Config struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// LeaseName defaults to loggie-<pipeline>-<source>
+	LeaseName string `yaml:"leaseName,omitempty"`
+	// Namespace of the lease, defaults to the namespace of loggie pod by env POD_NAMESPACE
+	Namespace     string        `yaml:"namespace,omitempty"`
+	LeaseDuration time.Duration `yaml:"leaseDuration,omitempty" default:"15s"`
+	RenewDeadline time.Duration `yaml:"renewDeadline,omitempty" default:"10s"`
+	RetryPeriod   time.Duration `yaml:"retryPeriod,omitempty" default:"2s"`
+	KubeConfig    string        `yaml:"kubeconfig,omitempty"`
+	Master        string        `yaml:"master,omitempty"`
+}
+
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.RenewDeadline >= c.LeaseDuration {
+		return fmt.Errorf("leaderElection renewDeadline must be less than leaseDuration")
+	}
+	if c.RetryPeriod <= 0 || c.RetryPeriod >= c.RenewDeadline {
+		return fmt.Errorf("leaderElection retryPeriod must be positive and less than renewDeadline")
+	}
+	return nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// DefaultLeaseName makes a valid lease name from the pipeline and source name
+func DefaultLeaseName(pipelineName string, sourceName string) string {
+	name := strings.ToLower(fmt.Sprintf("loggie-%s-%s", pipelineName, sourceName))
+	name = invalidNameChars.ReplaceAllString(name, "-")
+	return strings.Trim(name, "-.")
+}
+
+// Identity distinguishes the loggie instances competing for the lease, which is the pod name in kubernetes
+func Identity() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return fmt.Sprintf("loggie-%d", os.Getpid())
+	}
+	return hostname
+}
+
+type Callbacks struct {
+	OnStartedLeading func()
+	OnStoppedLeading func()
+}
+
+// Run keeps competing for the lease until ctx is done, the lease is released when ctx is done
+// so that the other instances could take over immediately.
+func Run(ctx context.Context, config *Config, leaseName string, callbacks Callbacks) error {
+	restConfig, err := clientcmd.BuildConfigFromFlags(config.Master, config.KubeConfig)
+	if err != nil {
+		return errors.WithMessage(err, "build kubernetes config failed")
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return errors.WithMessage(err, "build kubernetes client failed")
+	}
+
+	namespace := config.Namespace
+	if namespace == "" {
+		namespace = os.Getenv(namespaceEnv)
+	}
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+
+	identity := Identity()
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      leaseName,
+			Namespace: namespace,
+		},
+		Client: client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   config.LeaseDuration,
+		RenewDeadline:   config.RenewDeadline,
+		RetryPeriod:     config.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            leaseName,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				log.Info("%s became the leader of lease %s/%s", identity, namespace, leaseName)
+				callbacks.OnStartedLeading()
+			},
+			OnStoppedLeading: func() {
+				log.Info("%s is no longer the leader of lease %s/%s", identity, namespace, leaseName)
+				callbacks.OnStoppedLeading()
+			},
+			OnNewLeader: func(leader string) {
+				if leader != identity {
+					log.Info("lease %s/%s is held by %s", namespace, leaseName, leader)
+				}
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Run returns once the leadership is lost, compete again unless ctx is done
+	for {
+		elector.Run(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDefaultLeaseName(t *testing.T) {
+	tests := []struct {
+		pipeline string
+		source   string
+		want     string
+	}{
+		{"local", "events", "loggie-local-events"},
+		{"Cluster_Events", "kube event", "loggie-cluster-events-kube-event"},
+		{"-a", "b.", "loggie--a-b"},
+	}
+	for _, tt := range tests {
+		if got := DefaultLeaseName(tt.pipeline, tt.source); got != tt.want {
+			t.Errorf("DefaultLeaseName(%s, %s) = %s, want %s", tt.pipeline, tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	c := &Config{
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 20 * time.Second,
+		RetryPeriod:   2 * time.Second,
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("disabled config should not be validated, got %v", err)
+	}
+
+	c.Enabled = true
+	if err := c.Validate(); err == nil {
+		t.Errorf("expect error when renewDeadline is larger than leaseDuration")
+	}
+
+	c.RenewDeadline = 10 * time.Second
+	if err := c.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNil is returned when redis replies a nil bulk string or array
+var ErrNil = errors.New("redis: nil reply")
+
+// Error is the error reply from redis server
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+type Options struct {
+	Addr         string        `yaml:"addr,omitempty" validate:"required"`
+	UserName     string        `yaml:"username,omitempty"`
+	Password     string        `yaml:"password,omitempty"`
+	DB           int           `yaml:"db,omitempty"`
+	TLS          bool          `yaml:"tls,omitempty"`
+	DialTimeout  time.Duration `yaml:"dialTimeout,omitempty" default:"5s"`
+	ReadTimeout  time.Duration `yaml:"readTimeout,omitempty" default:"10s"`
+	WriteTimeout time.Duration `yaml:"writeTimeout,omitempty" default:"10s"`
+}
+
+// Conn is a connection speaking RESP2, it is not safe for concurrent use
+type Conn struct {
+	opts *Options
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+func Dial(opts *Options) (*Conn, error) {
+	var (
+		conn net.Conn
+		err  error
+	)
+	dialer := &net.Dialer{Timeout: opts.DialTimeout}
+	if opts.TLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", opts.Addr, &tls.Config{})
+	} else {
+		conn, err = dialer.Dial("tcp", opts.Addr)
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "dial redis %s failed", opts.Addr)
+	}
+
+	c := &Conn{
+		opts: opts,
+		conn: conn,
+		r:    bufio.NewReader(conn),
+		w:    bufio.NewWriter(conn),
+	}
+
+	if opts.Password != "" {
+		args := []interface{}{"AUTH", opts.Password}
+		if opts.UserName != "" {
+			args = []interface{}{"AUTH", opts.UserName, opts.Password}
+		}
+		if _, err := c.Do(args...); err != nil {
+			c.Close()
+			return nil, errors.WithMessage(err, "redis auth failed")
+		}
+	}
+	if opts.DB != 0 {
+		if _, err := c.Do("SELECT", opts.DB); err != nil {
+			c.Close()
+			return nil, errors.WithMessagef(err, "redis select db %d failed", opts.DB)
+		}
+	}
+	return c, nil
+}
+
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Do sends the command and waits for the reply, readTimeout is extended by block
+// for blocking commands like BLPOP or XREADGROUP with BLOCK.
+func (c *Conn) Do(args ...interface{}) (interface{}, error) {
+	return c.DoWithBlock(0, args...)
+}
+
+func (c *Conn) DoWithBlock(block time.Duration, args ...interface{}) (interface{}, error) {
+	if err := c.Send(args...); err != nil {
+		return nil, err
+	}
+	if err := c.Flush(); err != nil {
+		return nil, err
+	}
+	return c.Receive(c.opts.ReadTimeout + block)
+}
+
+// Send writes the command to the buffer, it is used with Flush and Receive to pipeline commands
+func (c *Conn) Send(args ...interface{}) error {
+	c.w.WriteString("*")
+	c.w.WriteString(strconv.Itoa(len(args)))
+	c.w.WriteString("\r\n")
+	for _, arg := range args {
+		var b []byte
+		switch a := arg.(type) {
+		case string:
+			b = []byte(a)
+		case []byte:
+			b = a
+		case int:
+			b = []byte(strconv.Itoa(a))
+		case int64:
+			b = []byte(strconv.FormatInt(a, 10))
+		default:
+			b = []byte(fmt.Sprint(a))
+		}
+		c.w.WriteString("$")
+		c.w.WriteString(strconv.Itoa(len(b)))
+		c.w.WriteString("\r\n")
+		c.w.Write(b)
+		if _, err := c.w.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Conn) Flush() error {
+	if c.opts.WriteTimeout > 0 {
+		if err := c.conn.SetWriteDeadline(time.Now().Add(c.opts.WriteTimeout)); err != nil {
+			return err
+		}
+	}
+	return c.w.Flush()
+}
+
+// Receive reads one reply, timeout 0 means blocking until a reply arrives, which is used by pub/sub
+func (c *Conn) Receive(timeout time.Duration) (interface{}, error) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if err := c.conn.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	reply, err := c.readReply()
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := reply.(Error); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+func (c *Conn) readLine() ([]byte, error) {
+	line, err := c.r.ReadSlice('\n')
+	if err != nil {
+		if err == bufio.ErrBufferFull {
+			return nil, errors.New("redis: reply line too long")
+		}
+		return nil, err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return nil, errors.New("redis: bad reply line terminator")
+	}
+	return line[:len(line)-2], nil
+}
+
+func (c *Conn) readReply() (interface{}, error) {
+	line, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return Error(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(string(line[1:]), 10, 64)
+	case '$':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil {
+			return nil, errors.WithMessage(err, "redis: bad bulk length")
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil {
+			return nil, errors.WithMessage(err, "redis: bad array length")
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := c.readReply()
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, errors.Errorf("redis: unexpected reply type %q", line[0])
+}
+
+// Pool keeps idle connections for concurrent users like sink consumers
+type Pool struct {
+	opts *Options
+	lock sync.Mutex
+	idle []*Conn
+	max  int
+}
+
+func NewPool(opts *Options, maxIdle int) *Pool {
+	return &Pool{
+		opts: opts,
+		max:  maxIdle,
+	}
+}
+
+func (p *Pool) Get() (*Conn, error) {
+	p.lock.Lock()
+	if l := len(p.idle); l > 0 {
+		c := p.idle[l-1]
+		p.idle = p.idle[:l-1]
+		p.lock.Unlock()
+		return c, nil
+	}
+	p.lock.Unlock()
+	return Dial(p.opts)
+}
+
+// Put returns the connection to the pool, broken connections should be closed by caller instead
+func (p *Pool) Put(c *Conn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if len(p.idle) >= p.max {
+		c.Close()
+		return
+	}
+	p.idle = append(p.idle, c)
+}
+
+func (p *Pool) Close() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, c := range p.idle {
+		c.Close()
+	}
+	p.idle = nil
+}
+
+// String converts bulk string or simple string reply to string
+func String(reply interface{}) (string, error) {
+	switch r := reply.(type) {
+	case []byte:
+		return string(r), nil
+	case string:
+		return r, nil
+	case nil:
+		return "", ErrNil
+	}
+	return "", errors.Errorf("redis: unexpected reply type %T for string", reply)
+}
+
+// Values converts array reply to slice
+func Values(reply interface{}) ([]interface{}, error) {
+	switch r := reply.(type) {
+	case []interface{}:
+		return r, nil
+	case nil:
+		return nil, ErrNil
+	}
+	return nil, errors.Errorf("redis: unexpected reply type %T for array", reply)
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 Loggie Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serve answers each command with the reply returned by handler, until the listener is closed
+func serve(t *testing.T, handler func(args []string) string) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen error: %v", err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				c := &Conn{opts: &Options{}, conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+				for {
+					reply, err := c.readReply()
+					if err != nil {
+						return
+					}
+					var args []string
+					for _, arg := range reply.([]interface{}) {
+						args = append(args, string(arg.([]byte)))
+					}
+					if _, err := conn.Write([]byte(handler(args))); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return l
+}
+
+func TestConn_Do(t *testing.T) {
+	var lock sync.Mutex
+	var commands []string
+	l := serve(t, func(args []string) string {
+		lock.Lock()
+		defer lock.Unlock()
+		commands = append(commands, strings.Join(args, " "))
+		switch args[0] {
+		case "AUTH", "SELECT":
+			return "+OK\r\n"
+		case "LRANGE":
+			return "*3\r\n$1\r\na\r\n$-1\r\n:3\r\n"
+		default:
+			return "-ERR unknown command\r\n"
+		}
+	})
+	defer l.Close()
+
+	c, err := Dial(&Options{Addr: l.Addr().String(), Password: "pass", DB: 1, ReadTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("dial error: %v", err)
+	}
+	defer c.Close()
+
+	reply, err := c.Do("LRANGE", "key", 0, -1)
+	if err != nil {
+		t.Fatalf("do error: %v", err)
+	}
+	values, err := Values(reply)
+	if err != nil {
+		t.Fatalf("values error: %v", err)
+	}
+	if s, _ := String(values[0]); s != "a" || values[1] != nil || values[2] != int64(3) {
+		t.Errorf("unexpected reply %v", values)
+	}
+
+	_, err = c.Do("FOO")
+	if _, ok := err.(Error); !ok {
+		t.Errorf("expect redis error reply, got %v", err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	expect := []string{"AUTH pass", "SELECT 1", "LRANGE key 0 -1", "FOO"}
+	if fmt.Sprint(commands) != fmt.Sprint(expect) {
+		t.Errorf("expect commands %v, got %v", expect, commands)
+	}
+}